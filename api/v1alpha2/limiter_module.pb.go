@@ -52,15 +52,63 @@ func (Limiter_RateLimitBackend) EnumDescriptor() ([]byte, []int) {
 	return fileDescriptor_4827d40f7d98bcf0, []int{0, 0}
 }
 
+type MetricSource_Type int32
+
+const (
+	// query the handlers in metric.prometheus of module config
+	MetricSource_prometheus MetricSource_Type = 0
+	// cpu and memory of pods from metrics.k8s.io, like _base.cpu.sum (millicores) and _base.memory.max (bytes)
+	MetricSource_kubeMetrics MetricSource_Type = 1
+	// stats scraped from the prometheus endpoint of sidecars, the values of pods in a subset are summed
+	MetricSource_envoyStats MetricSource_Type = 2
+	// metrics read from a static file, for testing
+	MetricSource_static MetricSource_Type = 3
+	// stats of the limiter itself scraped from the prometheus endpoint of sidecars, the counters are the
+	// increments since last query, like _base.rate_limited, _base.over_limit and _base.limited_ratio (percent)
+	MetricSource_limiterStats MetricSource_Type = 4
+)
+
+var MetricSource_Type_name = map[int32]string{
+	0: "prometheus",
+	1: "kubeMetrics",
+	2: "envoyStats",
+	3: "static",
+	4: "limiterStats",
+}
+
+var MetricSource_Type_value = map[string]int32{
+	"prometheus":   0,
+	"kubeMetrics":  1,
+	"envoyStats":   2,
+	"static":       3,
+	"limiterStats": 4,
+}
+
+func (x MetricSource_Type) String() string {
+	return proto.EnumName(MetricSource_Type_name, int32(x))
+}
+
+func (MetricSource_Type) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_4827d40f7d98bcf0, []int{1, 0}
+}
+
 type Limiter struct {
 	Backend                Limiter_RateLimitBackend `protobuf:"varint,3,opt,name=backend,proto3,enum=slime.microservice.limiter.v1alpha2.Limiter_RateLimitBackend" json:"backend,omitempty"`
 	Refresh                *time.Duration           `protobuf:"bytes,4,opt,name=refresh,proto3,stdduration" json:"refresh,omitempty"`
 	DisableGlobalRateLimit bool                     `protobuf:"varint,5,opt,name=disableGlobalRateLimit,proto3" json:"disableGlobalRateLimit,omitempty"`
 	DisableAdaptive        bool                     `protobuf:"varint,6,opt,name=disableAdaptive,proto3" json:"disableAdaptive,omitempty"`
 	EnableServiceEntry     bool                     `protobuf:"varint,7,opt,name=enableServiceEntry,proto3" json:"enableServiceEntry,omitempty"`
-	XXX_NoUnkeyedLiteral   struct{}                 `json:"-"`
-	XXX_unrecognized       []byte                   `json:"-"`
-	XXX_sizecache          int32                    `json:"-"`
+	// sources of the metrics used by adaptive limits, they are combined if more than one is specified.
+	// default is prometheus
+	MetricSources []*MetricSource `protobuf:"bytes,8,rep,name=metricSources,proto3" json:"metricSources,omitempty"`
+	// SmartLimiterPolicies in this namespace are applied to all namespaces, default is istio-system
+	MeshPolicyNamespace string `protobuf:"bytes,9,opt,name=meshPolicyNamespace,proto3" json:"meshPolicyNamespace,omitempty"`
+	// disable deletes all the EnvoyFilters, global rate limit descriptors and SmartLimiters generated by limiter
+	// on start, and stops generating them, it is used for emergency rollback
+	Disable              bool     `protobuf:"varint,10,opt,name=disable,proto3" json:"disable,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *Limiter) Reset()         { *m = Limiter{} }
@@ -127,35 +175,418 @@ func (m *Limiter) GetEnableServiceEntry() bool {
 	return false
 }
 
+func (m *Limiter) GetMetricSources() []*MetricSource {
+	if m != nil {
+		return m.MetricSources
+	}
+	return nil
+}
+
+func (m *Limiter) GetMeshPolicyNamespace() string {
+	if m != nil {
+		return m.MeshPolicyNamespace
+	}
+	return ""
+}
+
+func (m *Limiter) GetDisable() bool {
+	if m != nil {
+		return m.Disable
+	}
+	return false
+}
+
+type MetricSource struct {
+	Type MetricSource_Type `protobuf:"varint,1,opt,name=type,proto3,enum=slime.microservice.limiter.v1alpha2.MetricSource_Type" json:"type,omitempty"`
+	// envoyStats, limiterStats: port of the prometheus endpoint of sidecar, default is 15090
+	EnvoyStatsPort uint32 `protobuf:"varint,2,opt,name=envoyStatsPort,proto3" json:"envoyStatsPort,omitempty"`
+	// envoyStats, limiterStats: path of the prometheus endpoint of sidecar, default is /stats/prometheus
+	EnvoyStatsPath string `protobuf:"bytes,3,opt,name=envoyStatsPath,proto3" json:"envoyStatsPath,omitempty"`
+	// envoyStats: the key is the metric name, the value is the stat with optional labels, like
+	// envoy_cluster_upstream_rq_total{cluster_name="inbound|9080||"}
+	Stats map[string]string `protobuf:"bytes,4,rep,name=stats,proto3" json:"stats,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// static: path of a yaml or json file, the key is namespace/name of the SmartLimiter or * for all, the value
+	// is the metrics, like {"default/reviews": {"_base.cpu.max": "100"}}
+	StaticFile string `protobuf:"bytes,5,opt,name=staticFile,proto3" json:"staticFile,omitempty"`
+	// timeout of a query, default is 5s
+	Timeout *time.Duration `protobuf:"bytes,6,opt,name=timeout,proto3,stdduration" json:"timeout,omitempty"`
+	// prometheus: the connection to prometheus, like the auth and tls
+	PrometheusConfig     *PrometheusConfig `protobuf:"bytes,7,opt,name=prometheusConfig,proto3" json:"prometheusConfig,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *MetricSource) Reset()         { *m = MetricSource{} }
+func (m *MetricSource) String() string { return proto.CompactTextString(m) }
+func (*MetricSource) ProtoMessage()    {}
+func (*MetricSource) Descriptor() ([]byte, []int) {
+	return fileDescriptor_4827d40f7d98bcf0, []int{1}
+}
+
+func (m *MetricSource) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MetricSource.Unmarshal(m, b)
+}
+
+func (m *MetricSource) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MetricSource.Marshal(b, m, deterministic)
+}
+
+func (m *MetricSource) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MetricSource.Merge(m, src)
+}
+
+func (m *MetricSource) XXX_Size() int {
+	return xxx_messageInfo_MetricSource.Size(m)
+}
+
+func (m *MetricSource) XXX_DiscardUnknown() {
+	xxx_messageInfo_MetricSource.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MetricSource proto.InternalMessageInfo
+
+func (m *MetricSource) GetType() MetricSource_Type {
+	if m != nil {
+		return m.Type
+	}
+	return MetricSource_prometheus
+}
+
+func (m *MetricSource) GetEnvoyStatsPort() uint32 {
+	if m != nil {
+		return m.EnvoyStatsPort
+	}
+	return 0
+}
+
+func (m *MetricSource) GetEnvoyStatsPath() string {
+	if m != nil {
+		return m.EnvoyStatsPath
+	}
+	return ""
+}
+
+func (m *MetricSource) GetStats() map[string]string {
+	if m != nil {
+		return m.Stats
+	}
+	return nil
+}
+
+func (m *MetricSource) GetStaticFile() string {
+	if m != nil {
+		return m.StaticFile
+	}
+	return ""
+}
+
+func (m *MetricSource) GetTimeout() *time.Duration {
+	if m != nil {
+		return m.Timeout
+	}
+	return nil
+}
+
+func (m *MetricSource) GetPrometheusConfig() *PrometheusConfig {
+	if m != nil {
+		return m.PrometheusConfig
+	}
+	return nil
+}
+
+type PrometheusConfig struct {
+	// address of prometheus, default is metric.prometheus.address of module config
+	Address string `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	// bearer token sent in the Authorization header, bearerTokenFile takes precedence
+	BearerToken string `protobuf:"bytes,2,opt,name=bearerToken,proto3" json:"bearerToken,omitempty"`
+	// file of the bearer token, it is read in every query so the rotated token is used
+	BearerTokenFile string     `protobuf:"bytes,3,opt,name=bearerTokenFile,proto3" json:"bearerTokenFile,omitempty"`
+	BasicAuth       *BasicAuth `protobuf:"bytes,4,opt,name=basicAuth,proto3" json:"basicAuth,omitempty"`
+	Tls             *TLSConfig `protobuf:"bytes,5,opt,name=tls,proto3" json:"tls,omitempty"`
+	// headers sent in every query, like X-Scope-OrgID of the tenant of thanos or cortex
+	Headers              map[string]string `protobuf:"bytes,6,rep,name=headers,proto3" json:"headers,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *PrometheusConfig) Reset()         { *m = PrometheusConfig{} }
+func (m *PrometheusConfig) String() string { return proto.CompactTextString(m) }
+func (*PrometheusConfig) ProtoMessage()    {}
+func (*PrometheusConfig) Descriptor() ([]byte, []int) {
+	return fileDescriptor_4827d40f7d98bcf0, []int{2}
+}
+
+func (m *PrometheusConfig) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PrometheusConfig.Unmarshal(m, b)
+}
+
+func (m *PrometheusConfig) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PrometheusConfig.Marshal(b, m, deterministic)
+}
+
+func (m *PrometheusConfig) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PrometheusConfig.Merge(m, src)
+}
+
+func (m *PrometheusConfig) XXX_Size() int {
+	return xxx_messageInfo_PrometheusConfig.Size(m)
+}
+
+func (m *PrometheusConfig) XXX_DiscardUnknown() {
+	xxx_messageInfo_PrometheusConfig.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PrometheusConfig proto.InternalMessageInfo
+
+func (m *PrometheusConfig) GetAddress() string {
+	if m != nil {
+		return m.Address
+	}
+	return ""
+}
+
+func (m *PrometheusConfig) GetBearerToken() string {
+	if m != nil {
+		return m.BearerToken
+	}
+	return ""
+}
+
+func (m *PrometheusConfig) GetBearerTokenFile() string {
+	if m != nil {
+		return m.BearerTokenFile
+	}
+	return ""
+}
+
+func (m *PrometheusConfig) GetBasicAuth() *BasicAuth {
+	if m != nil {
+		return m.BasicAuth
+	}
+	return nil
+}
+
+func (m *PrometheusConfig) GetTls() *TLSConfig {
+	if m != nil {
+		return m.Tls
+	}
+	return nil
+}
+
+func (m *PrometheusConfig) GetHeaders() map[string]string {
+	if m != nil {
+		return m.Headers
+	}
+	return nil
+}
+
+type BasicAuth struct {
+	Username string `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	Password string `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	// file of the password, it takes precedence over password
+	PasswordFile         string   `protobuf:"bytes,3,opt,name=passwordFile,proto3" json:"passwordFile,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BasicAuth) Reset()         { *m = BasicAuth{} }
+func (m *BasicAuth) String() string { return proto.CompactTextString(m) }
+func (*BasicAuth) ProtoMessage()    {}
+func (*BasicAuth) Descriptor() ([]byte, []int) {
+	return fileDescriptor_4827d40f7d98bcf0, []int{3}
+}
+
+func (m *BasicAuth) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BasicAuth.Unmarshal(m, b)
+}
+
+func (m *BasicAuth) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BasicAuth.Marshal(b, m, deterministic)
+}
+
+func (m *BasicAuth) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BasicAuth.Merge(m, src)
+}
+
+func (m *BasicAuth) XXX_Size() int {
+	return xxx_messageInfo_BasicAuth.Size(m)
+}
+
+func (m *BasicAuth) XXX_DiscardUnknown() {
+	xxx_messageInfo_BasicAuth.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BasicAuth proto.InternalMessageInfo
+
+func (m *BasicAuth) GetUsername() string {
+	if m != nil {
+		return m.Username
+	}
+	return ""
+}
+
+func (m *BasicAuth) GetPassword() string {
+	if m != nil {
+		return m.Password
+	}
+	return ""
+}
+
+func (m *BasicAuth) GetPasswordFile() string {
+	if m != nil {
+		return m.PasswordFile
+	}
+	return ""
+}
+
+type TLSConfig struct {
+	// ca to verify the certificate of prometheus, the system pool is used if not specified
+	CaFile string `protobuf:"bytes,1,opt,name=caFile,proto3" json:"caFile,omitempty"`
+	// client certificate and key
+	CertFile string `protobuf:"bytes,2,opt,name=certFile,proto3" json:"certFile,omitempty"`
+	KeyFile  string `protobuf:"bytes,3,opt,name=keyFile,proto3" json:"keyFile,omitempty"`
+	// server name to verify the certificate of prometheus
+	ServerName           string   `protobuf:"bytes,4,opt,name=serverName,proto3" json:"serverName,omitempty"`
+	InsecureSkipVerify   bool     `protobuf:"varint,5,opt,name=insecureSkipVerify,proto3" json:"insecureSkipVerify,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TLSConfig) Reset()         { *m = TLSConfig{} }
+func (m *TLSConfig) String() string { return proto.CompactTextString(m) }
+func (*TLSConfig) ProtoMessage()    {}
+func (*TLSConfig) Descriptor() ([]byte, []int) {
+	return fileDescriptor_4827d40f7d98bcf0, []int{4}
+}
+
+func (m *TLSConfig) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TLSConfig.Unmarshal(m, b)
+}
+
+func (m *TLSConfig) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TLSConfig.Marshal(b, m, deterministic)
+}
+
+func (m *TLSConfig) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TLSConfig.Merge(m, src)
+}
+
+func (m *TLSConfig) XXX_Size() int {
+	return xxx_messageInfo_TLSConfig.Size(m)
+}
+
+func (m *TLSConfig) XXX_DiscardUnknown() {
+	xxx_messageInfo_TLSConfig.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TLSConfig proto.InternalMessageInfo
+
+func (m *TLSConfig) GetCaFile() string {
+	if m != nil {
+		return m.CaFile
+	}
+	return ""
+}
+
+func (m *TLSConfig) GetCertFile() string {
+	if m != nil {
+		return m.CertFile
+	}
+	return ""
+}
+
+func (m *TLSConfig) GetKeyFile() string {
+	if m != nil {
+		return m.KeyFile
+	}
+	return ""
+}
+
+func (m *TLSConfig) GetServerName() string {
+	if m != nil {
+		return m.ServerName
+	}
+	return ""
+}
+
+func (m *TLSConfig) GetInsecureSkipVerify() bool {
+	if m != nil {
+		return m.InsecureSkipVerify
+	}
+	return false
+}
+
 func init() {
 	proto.RegisterEnum("slime.microservice.limiter.v1alpha2.Limiter_RateLimitBackend", Limiter_RateLimitBackend_name, Limiter_RateLimitBackend_value)
+	proto.RegisterEnum("slime.microservice.limiter.v1alpha2.MetricSource_Type", MetricSource_Type_name, MetricSource_Type_value)
 	proto.RegisterType((*Limiter)(nil), "slime.microservice.limiter.v1alpha2.Limiter")
+	proto.RegisterType((*MetricSource)(nil), "slime.microservice.limiter.v1alpha2.MetricSource")
+	proto.RegisterMapType((map[string]string)(nil), "slime.microservice.limiter.v1alpha2.MetricSource.StatsEntry")
+	proto.RegisterType((*PrometheusConfig)(nil), "slime.microservice.limiter.v1alpha2.PrometheusConfig")
+	proto.RegisterMapType((map[string]string)(nil), "slime.microservice.limiter.v1alpha2.PrometheusConfig.HeadersEntry")
+	proto.RegisterType((*BasicAuth)(nil), "slime.microservice.limiter.v1alpha2.BasicAuth")
+	proto.RegisterType((*TLSConfig)(nil), "slime.microservice.limiter.v1alpha2.TLSConfig")
 }
 
 func init() { proto.RegisterFile("limiter_module.proto", fileDescriptor_4827d40f7d98bcf0) }
 
 var fileDescriptor_4827d40f7d98bcf0 = []byte{
-	// 352 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x52, 0x41, 0x4b, 0xe3, 0x40,
-	0x14, 0xde, 0xec, 0x76, 0x9b, 0x65, 0x16, 0x76, 0xcb, 0xec, 0x62, 0x63, 0x05, 0x2d, 0xf5, 0x12,
-	0x90, 0x4e, 0xb0, 0x82, 0xe0, 0xc1, 0x83, 0xd5, 0xaa, 0x87, 0x9e, 0xe2, 0x41, 0xf0, 0x22, 0x93,
-	0xe4, 0x35, 0x1d, 0x9c, 0xe4, 0x85, 0xc9, 0x24, 0xd2, 0x7f, 0xe2, 0xbf, 0xf3, 0x77, 0x78, 0x13,
-	0x67, 0x92, 0x0a, 0x45, 0xc1, 0xdb, 0x9b, 0xf7, 0x7d, 0xdf, 0x7b, 0xdf, 0xfb, 0x18, 0xf2, 0x5f,
-	0x8a, 0x4c, 0x68, 0x50, 0xf7, 0x19, 0x26, 0x95, 0x04, 0x56, 0x28, 0xd4, 0x48, 0xf7, 0x4b, 0x29,
-	0x32, 0x60, 0x99, 0x88, 0x15, 0x96, 0xa0, 0x6a, 0x11, 0x03, 0x6b, 0x88, 0xac, 0x3e, 0xe4, 0xb2,
-	0x58, 0xf2, 0xc9, 0x60, 0x9c, 0x0a, 0xbd, 0xac, 0x22, 0x16, 0x63, 0x16, 0xa4, 0x98, 0x62, 0x60,
-	0xb4, 0x51, 0xb5, 0x30, 0x2f, 0xf3, 0x30, 0x95, 0x9d, 0x39, 0xd8, 0x4d, 0x11, 0x53, 0x09, 0xef,
-	0xac, 0xa4, 0x52, 0x5c, 0x0b, 0xcc, 0x2d, 0x3e, 0x7a, 0xf9, 0x4e, 0xdc, 0xb9, 0xdd, 0x41, 0x6f,
-	0x89, 0x1b, 0xf1, 0xf8, 0x01, 0xf2, 0xc4, 0xfb, 0x31, 0x74, 0xfc, 0x3f, 0x93, 0x53, 0xf6, 0x05,
-	0x47, 0xac, 0x91, 0xb3, 0x90, 0x6b, 0x30, 0xf5, 0xd4, 0x0e, 0x09, 0xdb, 0x69, 0xf4, 0x84, 0xb8,
-	0x0a, 0x16, 0x0a, 0xca, 0xa5, 0xd7, 0x19, 0x3a, 0xfe, 0xef, 0xc9, 0x36, 0xb3, 0xb6, 0x58, 0x6b,
-	0x8b, 0x5d, 0x34, 0xb6, 0xa6, 0x9d, 0xa7, 0xe7, 0x3d, 0x27, 0x6c, 0xf9, 0xf4, 0x98, 0x6c, 0x25,
-	0xa2, 0xe4, 0x91, 0x84, 0x2b, 0x89, 0x11, 0x97, 0xeb, 0x25, 0xde, 0xcf, 0xa1, 0xe3, 0xff, 0x0a,
-	0x3f, 0x41, 0xa9, 0x4f, 0xfe, 0x36, 0xc8, 0x59, 0xc2, 0x0b, 0x2d, 0x6a, 0xf0, 0xba, 0x46, 0xb0,
-	0xd9, 0xa6, 0x8c, 0x50, 0xc8, 0xdf, 0x3a, 0x37, 0xf6, 0xc0, 0x59, 0xae, 0xd5, 0xca, 0x73, 0x0d,
-	0xf9, 0x03, 0x64, 0x74, 0x4d, 0x7a, 0x9b, 0x97, 0xd2, 0x1d, 0xd2, 0xcf, 0x41, 0xcf, 0x78, 0x09,
-	0x73, 0x8c, 0xb9, 0xbc, 0x94, 0xf8, 0x78, 0x8e, 0xb9, 0x56, 0x28, 0x7b, 0xdf, 0x68, 0x9f, 0xfc,
-	0x83, 0xbc, 0xc6, 0x95, 0x81, 0xd6, 0xd2, 0x9e, 0x33, 0x1d, 0xdf, 0x1d, 0xd8, 0x7c, 0x05, 0x06,
-	0xa6, 0x08, 0xec, 0x77, 0x28, 0x83, 0x26, 0xe3, 0x80, 0x17, 0x22, 0x68, 0x73, 0x8e, 0xba, 0x26,
-	0xac, 0xa3, 0xd7, 0x00, 0x00, 0x00, 0xff, 0xff, 0x35, 0xe3, 0xc2, 0x81, 0x3d, 0x02, 0x00, 0x00,
+	// 842 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x55, 0xdd, 0x8e, 0xdb, 0x44,
+	0x14, 0xae, 0x37, 0xde, 0x64, 0x7d, 0xb2, 0xdd, 0x5a, 0xd3, 0xaa, 0x35, 0x41, 0x2a, 0x51, 0x90,
+	0x50, 0x24, 0x54, 0x87, 0x06, 0x51, 0x95, 0x0a, 0x24, 0x9a, 0xd2, 0x52, 0xa1, 0x05, 0xad, 0x9c,
+	0x85, 0x4a, 0x08, 0x09, 0x8d, 0xed, 0xb3, 0xc9, 0x28, 0xb6, 0xc7, 0x9a, 0x19, 0xa7, 0xca, 0x9b,
+	0x70, 0xc5, 0x05, 0x77, 0x3c, 0x0c, 0xcf, 0xc0, 0xab, 0x20, 0xcf, 0x8c, 0x93, 0xd4, 0x2c, 0x52,
+	0xb6, 0x77, 0x73, 0xfe, 0xbe, 0xf3, 0xcd, 0xf9, 0xce, 0xd8, 0x70, 0x2f, 0x63, 0x39, 0x53, 0x28,
+	0x7e, 0xcb, 0x79, 0x5a, 0x65, 0x18, 0x96, 0x82, 0x2b, 0x4e, 0x3e, 0x96, 0x19, 0xcb, 0x31, 0xcc,
+	0x59, 0x22, 0xb8, 0x44, 0xb1, 0x66, 0x09, 0x86, 0x36, 0x31, 0x5c, 0x3f, 0xa6, 0x59, 0xb9, 0xa4,
+	0xd3, 0xc1, 0xa3, 0x05, 0x53, 0xcb, 0x2a, 0x0e, 0x13, 0x9e, 0x4f, 0x16, 0x7c, 0xc1, 0x27, 0xba,
+	0x36, 0xae, 0xae, 0xb4, 0xa5, 0x0d, 0x7d, 0x32, 0x98, 0x83, 0x87, 0x0b, 0xce, 0x17, 0x19, 0xee,
+	0xb2, 0xd2, 0x4a, 0x50, 0xc5, 0x78, 0x61, 0xe2, 0xa3, 0x3f, 0x5c, 0xe8, 0x9d, 0x9b, 0x1e, 0xe4,
+	0x0d, 0xf4, 0x62, 0x9a, 0xac, 0xb0, 0x48, 0x83, 0xce, 0xd0, 0x19, 0x9f, 0x4d, 0xbf, 0x0e, 0x0f,
+	0x60, 0x14, 0xda, 0xf2, 0x30, 0xa2, 0x0a, 0xf5, 0x79, 0x66, 0x40, 0xa2, 0x06, 0x8d, 0x7c, 0x09,
+	0x3d, 0x81, 0x57, 0x02, 0xe5, 0x32, 0x70, 0x87, 0xce, 0xb8, 0x3f, 0xfd, 0x20, 0x34, 0xb4, 0xc2,
+	0x86, 0x56, 0xf8, 0xad, 0xa5, 0x35, 0x73, 0x7f, 0xff, 0xe7, 0x23, 0x27, 0x6a, 0xf2, 0xc9, 0x13,
+	0xb8, 0x9f, 0x32, 0x49, 0xe3, 0x0c, 0xbf, 0xcb, 0x78, 0x4c, 0xb3, 0x6d, 0x93, 0xe0, 0x78, 0xe8,
+	0x8c, 0x4f, 0xa2, 0xff, 0x89, 0x92, 0x31, 0xdc, 0xb1, 0x91, 0xe7, 0x29, 0x2d, 0x15, 0x5b, 0x63,
+	0xd0, 0xd5, 0x05, 0x6d, 0x37, 0x09, 0x81, 0x60, 0x51, 0x7b, 0xe6, 0xe6, 0x82, 0x2f, 0x0b, 0x25,
+	0x36, 0x41, 0x4f, 0x27, 0x5f, 0x13, 0x21, 0x6f, 0xe0, 0x76, 0x8e, 0x4a, 0xb0, 0x64, 0xce, 0x2b,
+	0x91, 0xa0, 0x0c, 0x4e, 0x86, 0x9d, 0x71, 0x7f, 0xfa, 0xf8, 0xa0, 0x59, 0xfd, 0xb0, 0x57, 0x19,
+	0xbd, 0x8b, 0x43, 0x3e, 0x83, 0xbb, 0x39, 0xca, 0xe5, 0x05, 0xcf, 0x58, 0xb2, 0xf9, 0x91, 0xe6,
+	0x28, 0x4b, 0x9a, 0x60, 0xe0, 0x0d, 0x9d, 0xb1, 0x17, 0x5d, 0x17, 0x22, 0x01, 0xf4, 0xec, 0x6d,
+	0x02, 0xd0, 0x7c, 0x1b, 0x73, 0xf4, 0x1a, 0xfc, 0xb6, 0x1c, 0xe4, 0x43, 0x78, 0x50, 0xa0, 0x7a,
+	0x49, 0x25, 0x9e, 0xf3, 0x84, 0x66, 0xaf, 0x32, 0xfe, 0xf6, 0x05, 0x2f, 0x94, 0xe0, 0x99, 0x7f,
+	0x8b, 0x3c, 0x80, 0xbb, 0x58, 0xac, 0xf9, 0x46, 0x87, 0xb6, 0xa5, 0xbe, 0x33, 0xfa, 0xdb, 0x85,
+	0xd3, 0x7d, 0xd6, 0xe4, 0x7b, 0x70, 0xd5, 0xa6, 0xc4, 0xc0, 0xd1, 0x2b, 0xf2, 0xe4, 0xc6, 0xd7,
+	0x0e, 0x2f, 0x37, 0x25, 0x46, 0x1a, 0x83, 0x7c, 0x02, 0x67, 0xba, 0xeb, 0x5c, 0x51, 0x25, 0x2f,
+	0xb8, 0x50, 0xc1, 0xd1, 0xd0, 0x19, 0xdf, 0x8e, 0x5a, 0xde, 0x56, 0x1e, 0x55, 0x4b, 0xbd, 0xa0,
+	0x5e, 0xd4, 0xf2, 0x92, 0x08, 0x8e, 0x65, 0x6d, 0x04, 0xae, 0xd6, 0xe4, 0xab, 0x9b, 0x93, 0xd3,
+	0x58, 0x5a, 0xe8, 0xc8, 0x40, 0x91, 0x87, 0x00, 0xf5, 0x81, 0x25, 0xaf, 0x58, 0x86, 0x7a, 0xeb,
+	0xbc, 0x68, 0xcf, 0x53, 0x2f, 0xb7, 0x62, 0x39, 0xf2, 0x4a, 0x05, 0xdd, 0x03, 0x97, 0xdb, 0xe6,
+	0x13, 0x0a, 0x7e, 0x29, 0x78, 0x8e, 0x6a, 0x89, 0x95, 0x7c, 0xc1, 0x8b, 0x2b, 0xb6, 0xd0, 0x8b,
+	0xd7, 0x9f, 0x7e, 0x71, 0x10, 0xf3, 0x8b, 0x56, 0x71, 0xf4, 0x1f, 0xb8, 0xc1, 0x53, 0x80, 0xdd,
+	0x95, 0x88, 0x0f, 0x9d, 0x15, 0x6e, 0xb4, 0x74, 0x5e, 0x54, 0x1f, 0xc9, 0x3d, 0x38, 0x5e, 0xd3,
+	0xac, 0x42, 0x3d, 0x78, 0x2f, 0x32, 0xc6, 0xb3, 0xa3, 0xa7, 0xce, 0xe8, 0x27, 0x70, 0x6b, 0xa5,
+	0xc8, 0x19, 0xc0, 0x0e, 0xd5, 0xbf, 0x45, 0xee, 0x40, 0x7f, 0x55, 0xc5, 0x68, 0xa6, 0x26, 0x7d,
+	0xa7, 0x4e, 0xd8, 0xc9, 0xe0, 0x1f, 0x11, 0x80, 0xae, 0x19, 0x8f, 0xdf, 0x21, 0x3e, 0x9c, 0x5a,
+	0xd6, 0x26, 0xea, 0x8e, 0xfe, 0xec, 0x80, 0xdf, 0xe6, 0x5d, 0x2f, 0x32, 0x4d, 0x53, 0x81, 0x52,
+	0x5a, 0x6e, 0x8d, 0x49, 0x86, 0xd0, 0x8f, 0x91, 0x0a, 0x14, 0x97, 0x7c, 0x85, 0x85, 0x65, 0xb9,
+	0xef, 0xaa, 0x5f, 0xfa, 0x9e, 0xa9, 0x45, 0x32, 0xcb, 0xd1, 0x76, 0x93, 0x73, 0xf0, 0x62, 0x2a,
+	0x59, 0xf2, 0xbc, 0x52, 0xcd, 0x87, 0x28, 0x3c, 0x68, 0xce, 0xb3, 0xa6, 0x2a, 0xda, 0x01, 0x90,
+	0x6f, 0xa0, 0xa3, 0x32, 0x19, 0x1c, 0xdf, 0x00, 0xe7, 0xf2, 0x7c, 0x6e, 0x85, 0xaa, 0x4b, 0xc9,
+	0xaf, 0xd0, 0x5b, 0x22, 0x4d, 0x51, 0xc8, 0xa0, 0xab, 0xf7, 0x75, 0xf6, 0x5e, 0xaa, 0x87, 0xaf,
+	0x0d, 0x88, 0xd9, 0xda, 0x06, 0x72, 0xf0, 0x0c, 0x4e, 0xf7, 0x03, 0x37, 0xd2, 0x7e, 0x01, 0xde,
+	0xf6, 0xce, 0x64, 0x00, 0x27, 0x95, 0x44, 0x51, 0xd0, 0x1c, 0x6d, 0xf5, 0xd6, 0xae, 0x63, 0x25,
+	0x95, 0xf2, 0x2d, 0x17, 0xa9, 0x45, 0xd9, 0xda, 0x64, 0x04, 0xa7, 0xcd, 0x79, 0x4f, 0x95, 0x77,
+	0x7c, 0xa3, 0xbf, 0x1c, 0xf0, 0xb6, 0x53, 0x21, 0xf7, 0xa1, 0x9b, 0x50, 0x9d, 0x6b, 0xfa, 0x58,
+	0xab, 0xee, 0x92, 0xa0, 0x50, 0x3a, 0x62, 0xbb, 0x34, 0x76, 0xbd, 0x3a, 0x2b, 0xdc, 0xec, 0x35,
+	0x68, 0x4c, 0xfd, 0x70, 0x51, 0xac, 0x51, 0xd4, 0x1f, 0xcc, 0xc0, 0xb5, 0x0f, 0x77, 0xeb, 0xa9,
+	0x3f, 0xfc, 0xac, 0x90, 0x98, 0x54, 0x02, 0xe7, 0x2b, 0x56, 0xfe, 0x8c, 0x82, 0x5d, 0x6d, 0xec,
+	0x6f, 0xe5, 0x9a, 0xc8, 0xec, 0xd1, 0x2f, 0x9f, 0x1a, 0x79, 0x18, 0x9f, 0xe8, 0xc3, 0xc4, 0xfc,
+	0xbd, 0xe5, 0xc4, 0x4a, 0x34, 0xa1, 0x25, 0x9b, 0x34, 0x32, 0xc5, 0x5d, 0xfd, 0xfc, 0x3f, 0xff,
+	0x77, 0x00, 0x96, 0xca, 0x88, 0xfe, 0xec, 0x07, 0x00, 0x00,
 }
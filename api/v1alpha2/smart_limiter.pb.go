@@ -23,427 +23,1597 @@ var (
 // proto package needs to be updated.
 const _ = proto.GoGoProtoPackageIsVersion3 // please upgrade the proto package
 
+type PrometheusHandler_Type int32
+
+const (
+	// the metric name is subset.name if $pod_name is in query, otherwise it is name
+	PrometheusHandler_Value PrometheusHandler_Type = 0
+	// every series is a metric, it is always queried
+	PrometheusHandler_Group PrometheusHandler_Type = 1
+)
+
+var PrometheusHandler_Type_name = map[int32]string{
+	0: "Value",
+	1: "Group",
+}
+
+var PrometheusHandler_Type_value = map[string]int32{
+	"Value": 0,
+	"Group": 1,
+}
+
+func (x PrometheusHandler_Type) String() string {
+	return proto.EnumName(PrometheusHandler_Type_name, int32(x))
+}
+
+func (PrometheusHandler_Type) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_452a0625a4f6276b, []int{5, 0}
+}
+
 type SmartLimiterSpec struct {
 	// subset rate-limit,the key is subset name.
 	Sets map[string]*SmartLimitDescriptors `protobuf:"bytes,1,rep,name=sets,proto3" json:"sets,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
 	// rls service
-	Rls                  string   `protobuf:"bytes,2,opt,name=rls,proto3" json:"rls,omitempty"`
+	Rls string `protobuf:"bytes,2,opt,name=rls,proto3" json:"rls,omitempty"`
+	// damping of the calculated quotas, the quotas are calculated again when the metric is refreshed
+	Damping *Damping `protobuf:"bytes,3,opt,name=damping,proto3" json:"damping,omitempty"`
+	// the metric is stale if it is not updated within metric_ttl, the fallback of descriptors referencing
+	// stale metrics is applied. zero disables staleness detection
+	MetricTtl *Duration `protobuf:"bytes,4,opt,name=metric_ttl,json=metricTtl,proto3" json:"metric_ttl,omitempty"`
+	// prometheus queries of this SmartLimiter, the key is the metric name. $namespace and $pod_name in query
+	// are replaced as the handlers in module config, which are overridden by the ones with the same name here.
+	// only the handlers referenced by condition, quota or feedback of descriptors are queried
+	PrometheusHandlers map[string]*PrometheusHandler `protobuf:"bytes,5,rep,name=prometheus_handlers,json=prometheusHandlers,proto3" json:"prometheus_handlers,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// the service limited, default is the service with the same name and namespace as the SmartLimiter
+	TargetRef *TargetRef `protobuf:"bytes,6,opt,name=target_ref,json=targetRef,proto3" json:"target_ref,omitempty"`
+	// select the workloads in the namespace of the SmartLimiter by labels, it takes precedence over target_ref.
+	// the workloads may be behind several services or none, and only the _base set is supported
+	WorkloadSelector *WorkloadSelector `protobuf:"bytes,7,opt,name=workload_selector,json=workloadSelector,proto3" json:"workload_selector,omitempty"`
+	// if several SmartLimiters have local limits on the same route of the same workloads, their descriptors
+	// are merged into the one with the largest priority, the ones with the same priority are ordered by name
+	Priority             int32    `protobuf:"varint,8,opt,name=priority,proto3" json:"priority,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SmartLimiterSpec) Reset()         { *m = SmartLimiterSpec{} }
+func (m *SmartLimiterSpec) String() string { return proto.CompactTextString(m) }
+func (*SmartLimiterSpec) ProtoMessage()    {}
+func (*SmartLimiterSpec) Descriptor() ([]byte, []int) {
+	return fileDescriptor_452a0625a4f6276b, []int{0}
+}
+
+func (m *SmartLimiterSpec) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SmartLimiterSpec.Unmarshal(m, b)
+}
+
+func (m *SmartLimiterSpec) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SmartLimiterSpec.Marshal(b, m, deterministic)
+}
+
+func (m *SmartLimiterSpec) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SmartLimiterSpec.Merge(m, src)
+}
+
+func (m *SmartLimiterSpec) XXX_Size() int {
+	return xxx_messageInfo_SmartLimiterSpec.Size(m)
+}
+
+func (m *SmartLimiterSpec) XXX_DiscardUnknown() {
+	xxx_messageInfo_SmartLimiterSpec.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SmartLimiterSpec proto.InternalMessageInfo
+
+func (m *SmartLimiterSpec) GetSets() map[string]*SmartLimitDescriptors {
+	if m != nil {
+		return m.Sets
+	}
+	return nil
+}
+
+func (m *SmartLimiterSpec) GetRls() string {
+	if m != nil {
+		return m.Rls
+	}
+	return ""
+}
+
+func (m *SmartLimiterSpec) GetDamping() *Damping {
+	if m != nil {
+		return m.Damping
+	}
+	return nil
+}
+
+func (m *SmartLimiterSpec) GetMetricTtl() *Duration {
+	if m != nil {
+		return m.MetricTtl
+	}
+	return nil
+}
+
+func (m *SmartLimiterSpec) GetPrometheusHandlers() map[string]*PrometheusHandler {
+	if m != nil {
+		return m.PrometheusHandlers
+	}
+	return nil
+}
+
+func (m *SmartLimiterSpec) GetTargetRef() *TargetRef {
+	if m != nil {
+		return m.TargetRef
+	}
+	return nil
+}
+
+func (m *SmartLimiterSpec) GetWorkloadSelector() *WorkloadSelector {
+	if m != nil {
+		return m.WorkloadSelector
+	}
+	return nil
+}
+
+func (m *SmartLimiterSpec) GetPriority() int32 {
+	if m != nil {
+		return m.Priority
+	}
+	return 0
+}
+
+// SmartLimiterPolicy is the default limit policy of services, it is expanded into a SmartLimiter targeting
+// each selected service in its namespace, or in all namespaces if it is in the mesh policy namespace of
+// module config. SmartLimiters created by users take precedence over namespace policies, and namespace
+// policies take precedence over mesh policies
+type SmartLimiterPolicySpec struct {
+	// labels of the services, all services are selected if empty
+	ServiceSelector map[string]string `protobuf:"bytes,1,rep,name=service_selector,json=serviceSelector,proto3" json:"service_selector,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// the policy with larger priority is applied if several policies of the same scope select a service,
+	// the ones with the same priority are ordered by name
+	Priority int32 `protobuf:"varint,2,opt,name=priority,proto3" json:"priority,omitempty"`
+	// mesh policy: the namespaces which the policy is not applied to
+	ExcludeNamespaces []string `protobuf:"bytes,3,rep,name=exclude_namespaces,json=excludeNamespaces,proto3" json:"exclude_namespaces,omitempty"`
+	// spec of the generated SmartLimiters, target_ref and workload_selector are ignored
+	Template             *SmartLimiterSpec `protobuf:"bytes,4,opt,name=template,proto3" json:"template,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *SmartLimiterPolicySpec) Reset()         { *m = SmartLimiterPolicySpec{} }
+func (m *SmartLimiterPolicySpec) String() string { return proto.CompactTextString(m) }
+func (*SmartLimiterPolicySpec) ProtoMessage()    {}
+func (*SmartLimiterPolicySpec) Descriptor() ([]byte, []int) {
+	return fileDescriptor_452a0625a4f6276b, []int{1}
+}
+
+func (m *SmartLimiterPolicySpec) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SmartLimiterPolicySpec.Unmarshal(m, b)
+}
+
+func (m *SmartLimiterPolicySpec) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SmartLimiterPolicySpec.Marshal(b, m, deterministic)
+}
+
+func (m *SmartLimiterPolicySpec) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SmartLimiterPolicySpec.Merge(m, src)
+}
+
+func (m *SmartLimiterPolicySpec) XXX_Size() int {
+	return xxx_messageInfo_SmartLimiterPolicySpec.Size(m)
+}
+
+func (m *SmartLimiterPolicySpec) XXX_DiscardUnknown() {
+	xxx_messageInfo_SmartLimiterPolicySpec.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SmartLimiterPolicySpec proto.InternalMessageInfo
+
+func (m *SmartLimiterPolicySpec) GetServiceSelector() map[string]string {
+	if m != nil {
+		return m.ServiceSelector
+	}
+	return nil
+}
+
+func (m *SmartLimiterPolicySpec) GetPriority() int32 {
+	if m != nil {
+		return m.Priority
+	}
+	return 0
+}
+
+func (m *SmartLimiterPolicySpec) GetExcludeNamespaces() []string {
+	if m != nil {
+		return m.ExcludeNamespaces
+	}
+	return nil
+}
+
+func (m *SmartLimiterPolicySpec) GetTemplate() *SmartLimiterSpec {
+	if m != nil {
+		return m.Template
+	}
+	return nil
+}
+
+type SmartLimiterPolicyStatus struct {
+	// the services which the policy is applied to, the key is namespace/name of the service,
+	// the value is the name of the generated SmartLimiter
+	Services map[string]string `protobuf:"bytes,1,rep,name=services,proto3" json:"services,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// the services which the policy is not applied to since the name of the SmartLimiter to generate is taken
+	// by a SmartLimiter created by user, the key is namespace/name of the service, the value is the reason
+	Collisions           map[string]string `protobuf:"bytes,2,rep,name=collisions,proto3" json:"collisions,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *SmartLimiterPolicyStatus) Reset()         { *m = SmartLimiterPolicyStatus{} }
+func (m *SmartLimiterPolicyStatus) String() string { return proto.CompactTextString(m) }
+func (*SmartLimiterPolicyStatus) ProtoMessage()    {}
+func (*SmartLimiterPolicyStatus) Descriptor() ([]byte, []int) {
+	return fileDescriptor_452a0625a4f6276b, []int{2}
+}
+
+func (m *SmartLimiterPolicyStatus) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SmartLimiterPolicyStatus.Unmarshal(m, b)
+}
+
+func (m *SmartLimiterPolicyStatus) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SmartLimiterPolicyStatus.Marshal(b, m, deterministic)
+}
+
+func (m *SmartLimiterPolicyStatus) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SmartLimiterPolicyStatus.Merge(m, src)
+}
+
+func (m *SmartLimiterPolicyStatus) XXX_Size() int {
+	return xxx_messageInfo_SmartLimiterPolicyStatus.Size(m)
+}
+
+func (m *SmartLimiterPolicyStatus) XXX_DiscardUnknown() {
+	xxx_messageInfo_SmartLimiterPolicyStatus.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SmartLimiterPolicyStatus proto.InternalMessageInfo
+
+func (m *SmartLimiterPolicyStatus) GetServices() map[string]string {
+	if m != nil {
+		return m.Services
+	}
+	return nil
+}
+
+func (m *SmartLimiterPolicyStatus) GetCollisions() map[string]string {
+	if m != nil {
+		return m.Collisions
+	}
+	return nil
+}
+
+type TargetRef struct {
+	// only Service is supported, default is Service
+	Kind string `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`
+	// name of the target in the namespace of the SmartLimiter
+	Name                 string   `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TargetRef) Reset()         { *m = TargetRef{} }
+func (m *TargetRef) String() string { return proto.CompactTextString(m) }
+func (*TargetRef) ProtoMessage()    {}
+func (*TargetRef) Descriptor() ([]byte, []int) {
+	return fileDescriptor_452a0625a4f6276b, []int{3}
+}
+
+func (m *TargetRef) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TargetRef.Unmarshal(m, b)
+}
+
+func (m *TargetRef) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TargetRef.Marshal(b, m, deterministic)
+}
+
+func (m *TargetRef) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TargetRef.Merge(m, src)
+}
+
+func (m *TargetRef) XXX_Size() int {
+	return xxx_messageInfo_TargetRef.Size(m)
+}
+
+func (m *TargetRef) XXX_DiscardUnknown() {
+	xxx_messageInfo_TargetRef.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TargetRef proto.InternalMessageInfo
+
+func (m *TargetRef) GetKind() string {
+	if m != nil {
+		return m.Kind
+	}
+	return ""
+}
+
+func (m *TargetRef) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type WorkloadSelector struct {
+	Labels               map[string]string `protobuf:"bytes,1,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *WorkloadSelector) Reset()         { *m = WorkloadSelector{} }
+func (m *WorkloadSelector) String() string { return proto.CompactTextString(m) }
+func (*WorkloadSelector) ProtoMessage()    {}
+func (*WorkloadSelector) Descriptor() ([]byte, []int) {
+	return fileDescriptor_452a0625a4f6276b, []int{4}
+}
+
+func (m *WorkloadSelector) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WorkloadSelector.Unmarshal(m, b)
+}
+
+func (m *WorkloadSelector) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WorkloadSelector.Marshal(b, m, deterministic)
+}
+
+func (m *WorkloadSelector) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WorkloadSelector.Merge(m, src)
+}
+
+func (m *WorkloadSelector) XXX_Size() int {
+	return xxx_messageInfo_WorkloadSelector.Size(m)
+}
+
+func (m *WorkloadSelector) XXX_DiscardUnknown() {
+	xxx_messageInfo_WorkloadSelector.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WorkloadSelector proto.InternalMessageInfo
+
+func (m *WorkloadSelector) GetLabels() map[string]string {
+	if m != nil {
+		return m.Labels
+	}
+	return nil
+}
+
+type PrometheusHandler struct {
+	Query                string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	Type                 PrometheusHandler_Type `protobuf:"varint,2,opt,name=type,proto3,enum=slime.microservice.limiter.v1alpha2.PrometheusHandler_Type" json:"type,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}               `json:"-"`
+	XXX_unrecognized     []byte                 `json:"-"`
+	XXX_sizecache        int32                  `json:"-"`
+}
+
+func (m *PrometheusHandler) Reset()         { *m = PrometheusHandler{} }
+func (m *PrometheusHandler) String() string { return proto.CompactTextString(m) }
+func (*PrometheusHandler) ProtoMessage()    {}
+func (*PrometheusHandler) Descriptor() ([]byte, []int) {
+	return fileDescriptor_452a0625a4f6276b, []int{5}
+}
+
+func (m *PrometheusHandler) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PrometheusHandler.Unmarshal(m, b)
+}
+
+func (m *PrometheusHandler) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PrometheusHandler.Marshal(b, m, deterministic)
+}
+
+func (m *PrometheusHandler) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PrometheusHandler.Merge(m, src)
+}
+
+func (m *PrometheusHandler) XXX_Size() int {
+	return xxx_messageInfo_PrometheusHandler.Size(m)
+}
+
+func (m *PrometheusHandler) XXX_DiscardUnknown() {
+	xxx_messageInfo_PrometheusHandler.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PrometheusHandler proto.InternalMessageInfo
+
+func (m *PrometheusHandler) GetQuery() string {
+	if m != nil {
+		return m.Query
+	}
+	return ""
+}
+
+func (m *PrometheusHandler) GetType() PrometheusHandler_Type {
+	if m != nil {
+		return m.Type
+	}
+	return PrometheusHandler_Value
+}
+
+// Damping makes the calculated quota move gradually, it is applied in the order of
+// ewma_alpha, hysteresis, max_step, min_quota and max_quota. zero value disables each of them
+type Damping struct {
+	// weight of the latest calculated quota in exponentially weighted moving average, in (0, 1]
+	EwmaAlpha float64 `protobuf:"fixed64,1,opt,name=ewma_alpha,json=ewmaAlpha,proto3" json:"ewma_alpha,omitempty"`
+	// keep the current quota if the relative change is not larger than hysteresis, e.g. 0.1 means 10%
+	Hysteresis float64 `protobuf:"fixed64,2,opt,name=hysteresis,proto3" json:"hysteresis,omitempty"`
+	// max relative change of quota per refresh, e.g. 0.2 means 20%, the quota can change by 1 at least
+	MaxStep float64 `protobuf:"fixed64,3,opt,name=max_step,json=maxStep,proto3" json:"max_step,omitempty"`
+	// lower bound of the quota
+	MinQuota int64 `protobuf:"varint,4,opt,name=min_quota,json=minQuota,proto3" json:"min_quota,omitempty"`
+	// upper bound of the quota
+	MaxQuota             int64    `protobuf:"varint,5,opt,name=max_quota,json=maxQuota,proto3" json:"max_quota,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Damping) Reset()         { *m = Damping{} }
+func (m *Damping) String() string { return proto.CompactTextString(m) }
+func (*Damping) ProtoMessage()    {}
+func (*Damping) Descriptor() ([]byte, []int) {
+	return fileDescriptor_452a0625a4f6276b, []int{6}
+}
+
+func (m *Damping) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Damping.Unmarshal(m, b)
+}
+
+func (m *Damping) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Damping.Marshal(b, m, deterministic)
+}
+
+func (m *Damping) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Damping.Merge(m, src)
+}
+
+func (m *Damping) XXX_Size() int {
+	return xxx_messageInfo_Damping.Size(m)
+}
+
+func (m *Damping) XXX_DiscardUnknown() {
+	xxx_messageInfo_Damping.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Damping proto.InternalMessageInfo
+
+func (m *Damping) GetEwmaAlpha() float64 {
+	if m != nil {
+		return m.EwmaAlpha
+	}
+	return 0
+}
+
+func (m *Damping) GetHysteresis() float64 {
+	if m != nil {
+		return m.Hysteresis
+	}
+	return 0
+}
+
+func (m *Damping) GetMaxStep() float64 {
+	if m != nil {
+		return m.MaxStep
+	}
+	return 0
+}
+
+func (m *Damping) GetMinQuota() int64 {
+	if m != nil {
+		return m.MinQuota
+	}
+	return 0
+}
+
+func (m *Damping) GetMaxQuota() int64 {
+	if m != nil {
+		return m.MaxQuota
+	}
+	return 0
+}
+
+type SmartLimiterStatus struct {
+	RatelimitStatus map[string]*SmartLimitDescriptors `protobuf:"bytes,1,rep,name=ratelimitStatus,proto3" json:"ratelimitStatus,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	MetricStatus    map[string]string                 `protobuf:"bytes,2,rep,name=metricStatus,proto3" json:"metricStatus,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// the generated descriptor value of the descriptors, the key is set/name, or set/#index of the descriptor
+	// in spec if the name of descriptor is not specified
+	DescriptorValues map[string]string `protobuf:"bytes,3,rep,name=descriptorValues,proto3" json:"descriptorValues,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// state of descriptors with feedback, the key is set/name, or set/#index of the descriptor in spec
+	FeedbackStatus map[string]*FeedbackStatus `protobuf:"bytes,4,rep,name=feedbackStatus,proto3" json:"feedbackStatus,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// unix timestamp of the latest update of each metric in metricStatus
+	MetricUpdateTime map[string]int64 `protobuf:"bytes,5,rep,name=metricUpdateTime,proto3" json:"metricUpdateTime,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	// descriptors referencing stale metrics, the key is set/name, or set/#index of the descriptor in spec,
+	// the value is the fallback policy applied
+	StaleDescriptors map[string]string `protobuf:"bytes,6,rep,name=staleDescriptors,proto3" json:"staleDescriptors,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// the routes whose local limits are merged with other SmartLimiters, the key is the route like
+	// inbound|http|9080/default, the value describes the SmartLimiters merged
+	Conflicts map[string]string `protobuf:"bytes,7,rep,name=conflicts,proto3" json:"conflicts,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// the reason why limiter runs in degraded mode, like the adaptive limits are disabled since the metric
+	// source is misconfigured, empty if limiter is healthy
+	Degraded string `protobuf:"bytes,8,opt,name=degraded,proto3" json:"degraded,omitempty"`
+	// descriptors which are not applied since they are invalid, e.g. the name is duplicate, the key is
+	// set/#index of the descriptor in spec, the value is the reason
+	InvalidDescriptors map[string]string `protobuf:"bytes,9,rep,name=invalidDescriptors,proto3" json:"invalidDescriptors,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// the prometheus handlers which fail to query in the latest query, the key is the name of handler,
+	// the value is the error, the other handlers are still queried
+	MetricErrors map[string]string `protobuf:"bytes,10,rep,name=metricErrors,proto3" json:"metricErrors,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// descriptors whose matchers have more than one way to match, which are rejected by the schema of crd but
+	// may be stored before, the key is set/#index of the descriptor in spec, the value tells which one is used
+	MatcherConflicts     map[string]string `protobuf:"bytes,11,rep,name=matcherConflicts,proto3" json:"matcherConflicts,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *SmartLimiterStatus) Reset()         { *m = SmartLimiterStatus{} }
+func (m *SmartLimiterStatus) String() string { return proto.CompactTextString(m) }
+func (*SmartLimiterStatus) ProtoMessage()    {}
+func (*SmartLimiterStatus) Descriptor() ([]byte, []int) {
+	return fileDescriptor_452a0625a4f6276b, []int{7}
+}
+
+func (m *SmartLimiterStatus) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SmartLimiterStatus.Unmarshal(m, b)
+}
+
+func (m *SmartLimiterStatus) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SmartLimiterStatus.Marshal(b, m, deterministic)
+}
+
+func (m *SmartLimiterStatus) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SmartLimiterStatus.Merge(m, src)
+}
+
+func (m *SmartLimiterStatus) XXX_Size() int {
+	return xxx_messageInfo_SmartLimiterStatus.Size(m)
+}
+
+func (m *SmartLimiterStatus) XXX_DiscardUnknown() {
+	xxx_messageInfo_SmartLimiterStatus.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SmartLimiterStatus proto.InternalMessageInfo
+
+func (m *SmartLimiterStatus) GetRatelimitStatus() map[string]*SmartLimitDescriptors {
+	if m != nil {
+		return m.RatelimitStatus
+	}
+	return nil
+}
+
+func (m *SmartLimiterStatus) GetMetricStatus() map[string]string {
+	if m != nil {
+		return m.MetricStatus
+	}
+	return nil
+}
+
+func (m *SmartLimiterStatus) GetDescriptorValues() map[string]string {
+	if m != nil {
+		return m.DescriptorValues
+	}
+	return nil
+}
+
+func (m *SmartLimiterStatus) GetFeedbackStatus() map[string]*FeedbackStatus {
+	if m != nil {
+		return m.FeedbackStatus
+	}
+	return nil
+}
+
+func (m *SmartLimiterStatus) GetMetricUpdateTime() map[string]int64 {
+	if m != nil {
+		return m.MetricUpdateTime
+	}
+	return nil
+}
+
+func (m *SmartLimiterStatus) GetStaleDescriptors() map[string]string {
+	if m != nil {
+		return m.StaleDescriptors
+	}
+	return nil
+}
+
+func (m *SmartLimiterStatus) GetConflicts() map[string]string {
+	if m != nil {
+		return m.Conflicts
+	}
+	return nil
+}
+
+func (m *SmartLimiterStatus) GetDegraded() string {
+	if m != nil {
+		return m.Degraded
+	}
+	return ""
+}
+
+func (m *SmartLimiterStatus) GetInvalidDescriptors() map[string]string {
+	if m != nil {
+		return m.InvalidDescriptors
+	}
+	return nil
+}
+
+func (m *SmartLimiterStatus) GetMetricErrors() map[string]string {
+	if m != nil {
+		return m.MetricErrors
+	}
+	return nil
+}
+
+func (m *SmartLimiterStatus) GetMatcherConflicts() map[string]string {
+	if m != nil {
+		return m.MatcherConflicts
+	}
+	return nil
+}
+
+type SmartLimitDescriptor struct {
+	Condition string                                `protobuf:"bytes,1,opt,name=condition,proto3" json:"condition,omitempty"`
+	Action    *SmartLimitDescriptor_Action          `protobuf:"bytes,2,opt,name=action,proto3" json:"action,omitempty"`
+	Match     []*SmartLimitDescriptor_HeaderMatcher `protobuf:"bytes,3,rep,name=match,proto3" json:"match,omitempty"`
+	Target    *SmartLimitDescriptor_Target          `protobuf:"bytes,4,opt,name=target,proto3" json:"target,omitempty"`
+	// custom_key and custom_value add a descriptor entry with the key custom_key and value custom_value,
+	// both of them must be specified. it is generated as a generic_key action with descriptor_key, and
+	// is also nested in the global rate limit descriptor.
+	CustomKey   string `protobuf:"bytes,5,opt,name=custom_key,json=customKey,proto3" json:"custom_key,omitempty"`
+	CustomValue string `protobuf:"bytes,6,opt,name=custom_value,json=customValue,proto3" json:"custom_value,omitempty"`
+	// match the request path, it is translated into a header matcher of :path
+	Path *SmartLimitDescriptor_PathMatcher `protobuf:"bytes,7,opt,name=path,proto3" json:"path,omitempty"`
+	// match the request method, like GET or POST, any of them is matched.
+	// it is translated into a header matcher of :method
+	Method []string `protobuf:"bytes,8,rep,name=method,proto3" json:"method,omitempty"`
+	// name of the descriptor, it should be unique in the SmartLimiter. if specified, the generated descriptor value
+	// is Service[svc.ns]-User[none]-Name[name], otherwise it is generated by the hash of match, path, method, target,
+	// custom_key, custom_value, the fill_interval and strategy of action. a stable descriptor value keeps the counters
+	// in rls when the descriptor is changed
+	Name string `protobuf:"bytes,9,opt,name=name,proto3" json:"name,omitempty"`
+	// adjust the quota iteratively to keep the metric under the target, the quota calculated from
+	// action.quota is used as the initial quota of aimd, or the base quota of pid
+	Feedback *Feedback `protobuf:"bytes,10,opt,name=feedback,proto3" json:"feedback,omitempty"`
+	// the policy if the metrics referenced by condition, quota or feedback are stale, default is keep
+	Fallback *Fallback `protobuf:"bytes,11,opt,name=fallback,proto3" json:"fallback,omitempty"`
+	// the quota of the first active schedule takes the place of action.quota, action.quota is used
+	// if none of them is active
+	Schedules            []*Schedule `protobuf:"bytes,12,rep,name=schedules,proto3" json:"schedules,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *SmartLimitDescriptor) Reset()         { *m = SmartLimitDescriptor{} }
+func (m *SmartLimitDescriptor) String() string { return proto.CompactTextString(m) }
+func (*SmartLimitDescriptor) ProtoMessage()    {}
+func (*SmartLimitDescriptor) Descriptor() ([]byte, []int) {
+	return fileDescriptor_452a0625a4f6276b, []int{8}
+}
+
+func (m *SmartLimitDescriptor) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SmartLimitDescriptor.Unmarshal(m, b)
+}
+
+func (m *SmartLimitDescriptor) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SmartLimitDescriptor.Marshal(b, m, deterministic)
+}
+
+func (m *SmartLimitDescriptor) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SmartLimitDescriptor.Merge(m, src)
+}
+
+func (m *SmartLimitDescriptor) XXX_Size() int {
+	return xxx_messageInfo_SmartLimitDescriptor.Size(m)
+}
+
+func (m *SmartLimitDescriptor) XXX_DiscardUnknown() {
+	xxx_messageInfo_SmartLimitDescriptor.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SmartLimitDescriptor proto.InternalMessageInfo
+
+func (m *SmartLimitDescriptor) GetCondition() string {
+	if m != nil {
+		return m.Condition
+	}
+	return ""
+}
+
+func (m *SmartLimitDescriptor) GetAction() *SmartLimitDescriptor_Action {
+	if m != nil {
+		return m.Action
+	}
+	return nil
+}
+
+func (m *SmartLimitDescriptor) GetMatch() []*SmartLimitDescriptor_HeaderMatcher {
+	if m != nil {
+		return m.Match
+	}
+	return nil
+}
+
+func (m *SmartLimitDescriptor) GetTarget() *SmartLimitDescriptor_Target {
+	if m != nil {
+		return m.Target
+	}
+	return nil
+}
+
+func (m *SmartLimitDescriptor) GetCustomKey() string {
+	if m != nil {
+		return m.CustomKey
+	}
+	return ""
+}
+
+func (m *SmartLimitDescriptor) GetCustomValue() string {
+	if m != nil {
+		return m.CustomValue
+	}
+	return ""
+}
+
+func (m *SmartLimitDescriptor) GetPath() *SmartLimitDescriptor_PathMatcher {
+	if m != nil {
+		return m.Path
+	}
+	return nil
+}
+
+func (m *SmartLimitDescriptor) GetMethod() []string {
+	if m != nil {
+		return m.Method
+	}
+	return nil
+}
+
+func (m *SmartLimitDescriptor) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *SmartLimitDescriptor) GetFeedback() *Feedback {
+	if m != nil {
+		return m.Feedback
+	}
+	return nil
+}
+
+func (m *SmartLimitDescriptor) GetFallback() *Fallback {
+	if m != nil {
+		return m.Fallback
+	}
+	return nil
+}
+
+func (m *SmartLimitDescriptor) GetSchedules() []*Schedule {
+	if m != nil {
+		return m.Schedules
+	}
+	return nil
+}
+
+type SmartLimitDescriptor_HeaderMatcher struct {
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Only one of the following should be specified, if none is specified, header match will
+	// be performed based on whether the header is absent.
+	//
+	// Types that are valid to be assigned to HeaderMatchSpecifier:
+	//	*SmartLimitDescriptor_HeaderMatcher_RegexMatch
+	//	*SmartLimitDescriptor_HeaderMatcher_ExactMatch
+	//	*SmartLimitDescriptor_HeaderMatcher_PrefixMatch
+	//	*SmartLimitDescriptor_HeaderMatcher_SuffixMatch
+	//	*SmartLimitDescriptor_HeaderMatcher_PresentMatch
+	//	*SmartLimitDescriptor_HeaderMatcher_RangeMatch
+	//	*SmartLimitDescriptor_HeaderMatcher_ContainsMatch
+	HeaderMatchSpecifier isSmartLimitDescriptor_HeaderMatcher_HeaderMatchSpecifier `protobuf_oneof:"header_match_specifier"`
+	// If specified, the match result will be inverted before checking. Defaults to false.
+	// * The regex ``\d{3}`` does not match the value *1234*, so it will match when inverted.
+	InvertMatch          bool     `protobuf:"varint,7,opt,name=invert_match,json=invertMatch,proto3" json:"invert_match,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SmartLimitDescriptor_HeaderMatcher) Reset()         { *m = SmartLimitDescriptor_HeaderMatcher{} }
+func (m *SmartLimitDescriptor_HeaderMatcher) String() string { return proto.CompactTextString(m) }
+func (*SmartLimitDescriptor_HeaderMatcher) ProtoMessage()    {}
+func (*SmartLimitDescriptor_HeaderMatcher) Descriptor() ([]byte, []int) {
+	return fileDescriptor_452a0625a4f6276b, []int{8, 0}
+}
+
+func (m *SmartLimitDescriptor_HeaderMatcher) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SmartLimitDescriptor_HeaderMatcher.Unmarshal(m, b)
+}
+
+func (m *SmartLimitDescriptor_HeaderMatcher) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SmartLimitDescriptor_HeaderMatcher.Marshal(b, m, deterministic)
+}
+
+func (m *SmartLimitDescriptor_HeaderMatcher) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SmartLimitDescriptor_HeaderMatcher.Merge(m, src)
+}
+
+func (m *SmartLimitDescriptor_HeaderMatcher) XXX_Size() int {
+	return xxx_messageInfo_SmartLimitDescriptor_HeaderMatcher.Size(m)
+}
+
+func (m *SmartLimitDescriptor_HeaderMatcher) XXX_DiscardUnknown() {
+	xxx_messageInfo_SmartLimitDescriptor_HeaderMatcher.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SmartLimitDescriptor_HeaderMatcher proto.InternalMessageInfo
+
+type isSmartLimitDescriptor_HeaderMatcher_HeaderMatchSpecifier interface {
+	isSmartLimitDescriptor_HeaderMatcher_HeaderMatchSpecifier()
+}
+
+type SmartLimitDescriptor_HeaderMatcher_RegexMatch struct {
+	RegexMatch string `protobuf:"bytes,2,opt,name=regex_match,json=regexMatch,proto3,oneof" json:"regex_match,omitempty"`
+}
+
+type SmartLimitDescriptor_HeaderMatcher_ExactMatch struct {
+	ExactMatch string `protobuf:"bytes,3,opt,name=exact_match,json=exactMatch,proto3,oneof" json:"exact_match,omitempty"`
+}
+
+type SmartLimitDescriptor_HeaderMatcher_PrefixMatch struct {
+	PrefixMatch string `protobuf:"bytes,4,opt,name=prefix_match,json=prefixMatch,proto3,oneof" json:"prefix_match,omitempty"`
+}
+
+type SmartLimitDescriptor_HeaderMatcher_SuffixMatch struct {
+	SuffixMatch string `protobuf:"bytes,5,opt,name=suffix_match,json=suffixMatch,proto3,oneof" json:"suffix_match,omitempty"`
+}
+
+type SmartLimitDescriptor_HeaderMatcher_PresentMatch struct {
+	PresentMatch bool `protobuf:"varint,6,opt,name=present_match,json=presentMatch,proto3,oneof" json:"present_match,omitempty"`
+}
+
+type SmartLimitDescriptor_HeaderMatcher_RangeMatch struct {
+	RangeMatch *SmartLimitDescriptor_Int64Range `protobuf:"bytes,9,opt,name=range_match,json=rangeMatch,proto3,oneof" json:"range_match,omitempty"`
+}
+
+type SmartLimitDescriptor_HeaderMatcher_ContainsMatch struct {
+	ContainsMatch string `protobuf:"bytes,10,opt,name=contains_match,json=containsMatch,proto3,oneof" json:"contains_match,omitempty"`
+}
+
+func (*SmartLimitDescriptor_HeaderMatcher_RegexMatch) isSmartLimitDescriptor_HeaderMatcher_HeaderMatchSpecifier() {
+}
+
+func (*SmartLimitDescriptor_HeaderMatcher_ExactMatch) isSmartLimitDescriptor_HeaderMatcher_HeaderMatchSpecifier() {
+}
+
+func (*SmartLimitDescriptor_HeaderMatcher_PrefixMatch) isSmartLimitDescriptor_HeaderMatcher_HeaderMatchSpecifier() {
+}
+
+func (*SmartLimitDescriptor_HeaderMatcher_SuffixMatch) isSmartLimitDescriptor_HeaderMatcher_HeaderMatchSpecifier() {
+}
+
+func (*SmartLimitDescriptor_HeaderMatcher_PresentMatch) isSmartLimitDescriptor_HeaderMatcher_HeaderMatchSpecifier() {
+}
+
+func (*SmartLimitDescriptor_HeaderMatcher_RangeMatch) isSmartLimitDescriptor_HeaderMatcher_HeaderMatchSpecifier() {
+}
+
+func (*SmartLimitDescriptor_HeaderMatcher_ContainsMatch) isSmartLimitDescriptor_HeaderMatcher_HeaderMatchSpecifier() {
+}
+
+func (m *SmartLimitDescriptor_HeaderMatcher) GetHeaderMatchSpecifier() isSmartLimitDescriptor_HeaderMatcher_HeaderMatchSpecifier {
+	if m != nil {
+		return m.HeaderMatchSpecifier
+	}
+	return nil
+}
+
+func (m *SmartLimitDescriptor_HeaderMatcher) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *SmartLimitDescriptor_HeaderMatcher) GetRegexMatch() string {
+	if x, ok := m.GetHeaderMatchSpecifier().(*SmartLimitDescriptor_HeaderMatcher_RegexMatch); ok {
+		return x.RegexMatch
+	}
+	return ""
+}
+
+func (m *SmartLimitDescriptor_HeaderMatcher) GetExactMatch() string {
+	if x, ok := m.GetHeaderMatchSpecifier().(*SmartLimitDescriptor_HeaderMatcher_ExactMatch); ok {
+		return x.ExactMatch
+	}
+	return ""
+}
+
+func (m *SmartLimitDescriptor_HeaderMatcher) GetPrefixMatch() string {
+	if x, ok := m.GetHeaderMatchSpecifier().(*SmartLimitDescriptor_HeaderMatcher_PrefixMatch); ok {
+		return x.PrefixMatch
+	}
+	return ""
+}
+
+func (m *SmartLimitDescriptor_HeaderMatcher) GetSuffixMatch() string {
+	if x, ok := m.GetHeaderMatchSpecifier().(*SmartLimitDescriptor_HeaderMatcher_SuffixMatch); ok {
+		return x.SuffixMatch
+	}
+	return ""
+}
+
+func (m *SmartLimitDescriptor_HeaderMatcher) GetPresentMatch() bool {
+	if x, ok := m.GetHeaderMatchSpecifier().(*SmartLimitDescriptor_HeaderMatcher_PresentMatch); ok {
+		return x.PresentMatch
+	}
+	return false
+}
+
+func (m *SmartLimitDescriptor_HeaderMatcher) GetRangeMatch() *SmartLimitDescriptor_Int64Range {
+	if x, ok := m.GetHeaderMatchSpecifier().(*SmartLimitDescriptor_HeaderMatcher_RangeMatch); ok {
+		return x.RangeMatch
+	}
+	return nil
+}
+
+func (m *SmartLimitDescriptor_HeaderMatcher) GetContainsMatch() string {
+	if x, ok := m.GetHeaderMatchSpecifier().(*SmartLimitDescriptor_HeaderMatcher_ContainsMatch); ok {
+		return x.ContainsMatch
+	}
+	return ""
+}
+
+func (m *SmartLimitDescriptor_HeaderMatcher) GetInvertMatch() bool {
+	if m != nil {
+		return m.InvertMatch
+	}
+	return false
+}
+
+// XXX_OneofWrappers is for the internal use of the proto package.
+func (*SmartLimitDescriptor_HeaderMatcher) XXX_OneofWrappers() []interface{} {
+	return []interface{}{
+		(*SmartLimitDescriptor_HeaderMatcher_RegexMatch)(nil),
+		(*SmartLimitDescriptor_HeaderMatcher_ExactMatch)(nil),
+		(*SmartLimitDescriptor_HeaderMatcher_PrefixMatch)(nil),
+		(*SmartLimitDescriptor_HeaderMatcher_SuffixMatch)(nil),
+		(*SmartLimitDescriptor_HeaderMatcher_PresentMatch)(nil),
+		(*SmartLimitDescriptor_HeaderMatcher_RangeMatch)(nil),
+		(*SmartLimitDescriptor_HeaderMatcher_ContainsMatch)(nil),
+	}
+}
+
+// Specifies the int64 start and end of the range using half-open interval semantics [start, end).
+type SmartLimitDescriptor_Int64Range struct {
+	// start of the range (inclusive)
+	Start int64 `protobuf:"varint,1,opt,name=start,proto3" json:"start,omitempty"`
+	// end of the range (exclusive)
+	End                  int64    `protobuf:"varint,2,opt,name=end,proto3" json:"end,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SmartLimitDescriptor_Int64Range) Reset()         { *m = SmartLimitDescriptor_Int64Range{} }
+func (m *SmartLimitDescriptor_Int64Range) String() string { return proto.CompactTextString(m) }
+func (*SmartLimitDescriptor_Int64Range) ProtoMessage()    {}
+func (*SmartLimitDescriptor_Int64Range) Descriptor() ([]byte, []int) {
+	return fileDescriptor_452a0625a4f6276b, []int{8, 1}
+}
+
+func (m *SmartLimitDescriptor_Int64Range) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SmartLimitDescriptor_Int64Range.Unmarshal(m, b)
+}
+
+func (m *SmartLimitDescriptor_Int64Range) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SmartLimitDescriptor_Int64Range.Marshal(b, m, deterministic)
+}
+
+func (m *SmartLimitDescriptor_Int64Range) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SmartLimitDescriptor_Int64Range.Merge(m, src)
+}
+
+func (m *SmartLimitDescriptor_Int64Range) XXX_Size() int {
+	return xxx_messageInfo_SmartLimitDescriptor_Int64Range.Size(m)
+}
+
+func (m *SmartLimitDescriptor_Int64Range) XXX_DiscardUnknown() {
+	xxx_messageInfo_SmartLimitDescriptor_Int64Range.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SmartLimitDescriptor_Int64Range proto.InternalMessageInfo
+
+func (m *SmartLimitDescriptor_Int64Range) GetStart() int64 {
+	if m != nil {
+		return m.Start
+	}
+	return 0
+}
+
+func (m *SmartLimitDescriptor_Int64Range) GetEnd() int64 {
+	if m != nil {
+		return m.End
+	}
+	return 0
+}
+
+type SmartLimitDescriptor_Action struct {
+	Quota                string    `protobuf:"bytes,1,opt,name=quota,proto3" json:"quota,omitempty"`
+	FillInterval         *Duration `protobuf:"bytes,2,opt,name=fill_interval,json=fillInterval,proto3" json:"fill_interval,omitempty"`
+	Strategy             string    `protobuf:"bytes,3,opt,name=strategy,proto3" json:"strategy,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *SmartLimitDescriptor_Action) Reset()         { *m = SmartLimitDescriptor_Action{} }
+func (m *SmartLimitDescriptor_Action) String() string { return proto.CompactTextString(m) }
+func (*SmartLimitDescriptor_Action) ProtoMessage()    {}
+func (*SmartLimitDescriptor_Action) Descriptor() ([]byte, []int) {
+	return fileDescriptor_452a0625a4f6276b, []int{8, 2}
+}
+
+func (m *SmartLimitDescriptor_Action) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SmartLimitDescriptor_Action.Unmarshal(m, b)
+}
+
+func (m *SmartLimitDescriptor_Action) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SmartLimitDescriptor_Action.Marshal(b, m, deterministic)
+}
+
+func (m *SmartLimitDescriptor_Action) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SmartLimitDescriptor_Action.Merge(m, src)
+}
+
+func (m *SmartLimitDescriptor_Action) XXX_Size() int {
+	return xxx_messageInfo_SmartLimitDescriptor_Action.Size(m)
+}
+
+func (m *SmartLimitDescriptor_Action) XXX_DiscardUnknown() {
+	xxx_messageInfo_SmartLimitDescriptor_Action.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SmartLimitDescriptor_Action proto.InternalMessageInfo
+
+func (m *SmartLimitDescriptor_Action) GetQuota() string {
+	if m != nil {
+		return m.Quota
+	}
+	return ""
+}
+
+func (m *SmartLimitDescriptor_Action) GetFillInterval() *Duration {
+	if m != nil {
+		return m.FillInterval
+	}
+	return nil
+}
+
+func (m *SmartLimitDescriptor_Action) GetStrategy() string {
+	if m != nil {
+		return m.Strategy
+	}
+	return ""
+}
+
+type SmartLimitDescriptor_Target struct {
+	Direction            string   `protobuf:"bytes,1,opt,name=direction,proto3" json:"direction,omitempty"`
+	Port                 int32    `protobuf:"varint,2,opt,name=port,proto3" json:"port,omitempty"`
+	Route                []string `protobuf:"bytes,3,rep,name=route,proto3" json:"route,omitempty"`
+	Host                 []string `protobuf:"bytes,4,rep,name=host,proto3" json:"host,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SmartLimitDescriptor_Target) Reset()         { *m = SmartLimitDescriptor_Target{} }
+func (m *SmartLimitDescriptor_Target) String() string { return proto.CompactTextString(m) }
+func (*SmartLimitDescriptor_Target) ProtoMessage()    {}
+func (*SmartLimitDescriptor_Target) Descriptor() ([]byte, []int) {
+	return fileDescriptor_452a0625a4f6276b, []int{8, 3}
+}
+
+func (m *SmartLimitDescriptor_Target) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SmartLimitDescriptor_Target.Unmarshal(m, b)
+}
+
+func (m *SmartLimitDescriptor_Target) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SmartLimitDescriptor_Target.Marshal(b, m, deterministic)
+}
+
+func (m *SmartLimitDescriptor_Target) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SmartLimitDescriptor_Target.Merge(m, src)
+}
+
+func (m *SmartLimitDescriptor_Target) XXX_Size() int {
+	return xxx_messageInfo_SmartLimitDescriptor_Target.Size(m)
+}
+
+func (m *SmartLimitDescriptor_Target) XXX_DiscardUnknown() {
+	xxx_messageInfo_SmartLimitDescriptor_Target.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SmartLimitDescriptor_Target proto.InternalMessageInfo
+
+func (m *SmartLimitDescriptor_Target) GetDirection() string {
+	if m != nil {
+		return m.Direction
+	}
+	return ""
+}
+
+func (m *SmartLimitDescriptor_Target) GetPort() int32 {
+	if m != nil {
+		return m.Port
+	}
+	return 0
+}
+
+func (m *SmartLimitDescriptor_Target) GetRoute() []string {
+	if m != nil {
+		return m.Route
+	}
+	return nil
+}
+
+func (m *SmartLimitDescriptor_Target) GetHost() []string {
+	if m != nil {
+		return m.Host
+	}
+	return nil
+}
+
+// PathMatcher matches the request path, the query string is not taken into account.
+// Only one of exact, prefix, regex and template should be specified.
+type SmartLimitDescriptor_PathMatcher struct {
+	// * The exact */orders* matches */orders* and */orders?id=1*, but not */orders/1*.
+	Exact string `protobuf:"bytes,1,opt,name=exact,proto3" json:"exact,omitempty"`
+	// * The prefix */orders* matches */orders/1* and */orders-list*.
+	Prefix string `protobuf:"bytes,2,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	// If specified, the path (without query string) must match the regex entirely.
+	Regex string `protobuf:"bytes,3,opt,name=regex,proto3" json:"regex,omitempty"`
+	// * The template */orders/{id}/items* matches */orders/1/items*, a segment can be
+	// a variable like *{id}* or a wildcard *, and the last segment can be ** which matches
+	// the rest of the path.
+	Template string `protobuf:"bytes,4,opt,name=template,proto3" json:"template,omitempty"`
+	// If specified, the match result will be inverted before checking. Defaults to false.
+	InvertMatch          bool     `protobuf:"varint,5,opt,name=invert_match,json=invertMatch,proto3" json:"invert_match,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *SmartLimiterSpec) Reset()         { *m = SmartLimiterSpec{} }
-func (m *SmartLimiterSpec) String() string { return proto.CompactTextString(m) }
-func (*SmartLimiterSpec) ProtoMessage()    {}
-func (*SmartLimiterSpec) Descriptor() ([]byte, []int) {
-	return fileDescriptor_452a0625a4f6276b, []int{0}
+func (m *SmartLimitDescriptor_PathMatcher) Reset()         { *m = SmartLimitDescriptor_PathMatcher{} }
+func (m *SmartLimitDescriptor_PathMatcher) String() string { return proto.CompactTextString(m) }
+func (*SmartLimitDescriptor_PathMatcher) ProtoMessage()    {}
+func (*SmartLimitDescriptor_PathMatcher) Descriptor() ([]byte, []int) {
+	return fileDescriptor_452a0625a4f6276b, []int{8, 4}
 }
 
-func (m *SmartLimiterSpec) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_SmartLimiterSpec.Unmarshal(m, b)
+func (m *SmartLimitDescriptor_PathMatcher) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SmartLimitDescriptor_PathMatcher.Unmarshal(m, b)
 }
 
-func (m *SmartLimiterSpec) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_SmartLimiterSpec.Marshal(b, m, deterministic)
+func (m *SmartLimitDescriptor_PathMatcher) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SmartLimitDescriptor_PathMatcher.Marshal(b, m, deterministic)
 }
 
-func (m *SmartLimiterSpec) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_SmartLimiterSpec.Merge(m, src)
+func (m *SmartLimitDescriptor_PathMatcher) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SmartLimitDescriptor_PathMatcher.Merge(m, src)
 }
 
-func (m *SmartLimiterSpec) XXX_Size() int {
-	return xxx_messageInfo_SmartLimiterSpec.Size(m)
+func (m *SmartLimitDescriptor_PathMatcher) XXX_Size() int {
+	return xxx_messageInfo_SmartLimitDescriptor_PathMatcher.Size(m)
 }
 
-func (m *SmartLimiterSpec) XXX_DiscardUnknown() {
-	xxx_messageInfo_SmartLimiterSpec.DiscardUnknown(m)
+func (m *SmartLimitDescriptor_PathMatcher) XXX_DiscardUnknown() {
+	xxx_messageInfo_SmartLimitDescriptor_PathMatcher.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_SmartLimiterSpec proto.InternalMessageInfo
+var xxx_messageInfo_SmartLimitDescriptor_PathMatcher proto.InternalMessageInfo
 
-func (m *SmartLimiterSpec) GetSets() map[string]*SmartLimitDescriptors {
+func (m *SmartLimitDescriptor_PathMatcher) GetExact() string {
 	if m != nil {
-		return m.Sets
+		return m.Exact
 	}
-	return nil
+	return ""
 }
 
-func (m *SmartLimiterSpec) GetRls() string {
+func (m *SmartLimitDescriptor_PathMatcher) GetPrefix() string {
 	if m != nil {
-		return m.Rls
+		return m.Prefix
 	}
 	return ""
 }
 
-type SmartLimiterStatus struct {
-	RatelimitStatus      map[string]*SmartLimitDescriptors `protobuf:"bytes,1,rep,name=ratelimitStatus,proto3" json:"ratelimitStatus,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
-	MetricStatus         map[string]string                 `protobuf:"bytes,2,rep,name=metricStatus,proto3" json:"metricStatus,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
-	XXX_NoUnkeyedLiteral struct{}                          `json:"-"`
-	XXX_unrecognized     []byte                            `json:"-"`
-	XXX_sizecache        int32                             `json:"-"`
-}
-
-func (m *SmartLimiterStatus) Reset()         { *m = SmartLimiterStatus{} }
-func (m *SmartLimiterStatus) String() string { return proto.CompactTextString(m) }
-func (*SmartLimiterStatus) ProtoMessage()    {}
-func (*SmartLimiterStatus) Descriptor() ([]byte, []int) {
-	return fileDescriptor_452a0625a4f6276b, []int{1}
-}
-
-func (m *SmartLimiterStatus) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_SmartLimiterStatus.Unmarshal(m, b)
-}
-
-func (m *SmartLimiterStatus) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_SmartLimiterStatus.Marshal(b, m, deterministic)
-}
-
-func (m *SmartLimiterStatus) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_SmartLimiterStatus.Merge(m, src)
-}
-
-func (m *SmartLimiterStatus) XXX_Size() int {
-	return xxx_messageInfo_SmartLimiterStatus.Size(m)
-}
-
-func (m *SmartLimiterStatus) XXX_DiscardUnknown() {
-	xxx_messageInfo_SmartLimiterStatus.DiscardUnknown(m)
+func (m *SmartLimitDescriptor_PathMatcher) GetRegex() string {
+	if m != nil {
+		return m.Regex
+	}
+	return ""
 }
 
-var xxx_messageInfo_SmartLimiterStatus proto.InternalMessageInfo
-
-func (m *SmartLimiterStatus) GetRatelimitStatus() map[string]*SmartLimitDescriptors {
+func (m *SmartLimitDescriptor_PathMatcher) GetTemplate() string {
 	if m != nil {
-		return m.RatelimitStatus
+		return m.Template
 	}
-	return nil
+	return ""
 }
 
-func (m *SmartLimiterStatus) GetMetricStatus() map[string]string {
+func (m *SmartLimitDescriptor_PathMatcher) GetInvertMatch() bool {
 	if m != nil {
-		return m.MetricStatus
+		return m.InvertMatch
 	}
-	return nil
+	return false
 }
 
-type SmartLimitDescriptor struct {
-	Condition            string                                `protobuf:"bytes,1,opt,name=condition,proto3" json:"condition,omitempty"`
-	Action               *SmartLimitDescriptor_Action          `protobuf:"bytes,2,opt,name=action,proto3" json:"action,omitempty"`
-	Match                []*SmartLimitDescriptor_HeaderMatcher `protobuf:"bytes,3,rep,name=match,proto3" json:"match,omitempty"`
-	Target               *SmartLimitDescriptor_Target          `protobuf:"bytes,4,opt,name=target,proto3" json:"target,omitempty"`
-	CustomKey            string                                `protobuf:"bytes,5,opt,name=custom_key,json=customKey,proto3" json:"custom_key,omitempty"`
-	CustomValue          string                                `protobuf:"bytes,6,opt,name=custom_value,json=customValue,proto3" json:"custom_value,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}                              `json:"-"`
-	XXX_unrecognized     []byte                                `json:"-"`
-	XXX_sizecache        int32                                 `json:"-"`
+// Schedule is a time window with its own quota, the window is either specified by cron and duration,
+// or by start and end. only one of them should be specified.
+type Schedule struct {
+	// standard cron expression with 5 fields: minute hour day-of-month month day-of-week, like "0 9 * * 1-5"
+	// the window starts at the time matching cron and lasts for duration
+	Cron     string    `protobuf:"bytes,1,opt,name=cron,proto3" json:"cron,omitempty"`
+	Duration *Duration `protobuf:"bytes,2,opt,name=duration,proto3" json:"duration,omitempty"`
+	// start and end of the window, like "09:00" which is daily, or "2022-11-11 00:00" which is absolute,
+	// RFC3339 is also supported. a daily window crosses midnight if end is not after start
+	Start string `protobuf:"bytes,3,opt,name=start,proto3" json:"start,omitempty"`
+	End   string `protobuf:"bytes,4,opt,name=end,proto3" json:"end,omitempty"`
+	// IANA timezone of cron, start and end, like Asia/Shanghai, default is UTC
+	Timezone string `protobuf:"bytes,5,opt,name=timezone,proto3" json:"timezone,omitempty"`
+	// quota in the window, it is calculated in the same way as action.quota
+	Quota                string   `protobuf:"bytes,6,opt,name=quota,proto3" json:"quota,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *SmartLimitDescriptor) Reset()         { *m = SmartLimitDescriptor{} }
-func (m *SmartLimitDescriptor) String() string { return proto.CompactTextString(m) }
-func (*SmartLimitDescriptor) ProtoMessage()    {}
-func (*SmartLimitDescriptor) Descriptor() ([]byte, []int) {
-	return fileDescriptor_452a0625a4f6276b, []int{2}
+func (m *Schedule) Reset()         { *m = Schedule{} }
+func (m *Schedule) String() string { return proto.CompactTextString(m) }
+func (*Schedule) ProtoMessage()    {}
+func (*Schedule) Descriptor() ([]byte, []int) {
+	return fileDescriptor_452a0625a4f6276b, []int{9}
 }
 
-func (m *SmartLimitDescriptor) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_SmartLimitDescriptor.Unmarshal(m, b)
+func (m *Schedule) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Schedule.Unmarshal(m, b)
 }
 
-func (m *SmartLimitDescriptor) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_SmartLimitDescriptor.Marshal(b, m, deterministic)
+func (m *Schedule) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Schedule.Marshal(b, m, deterministic)
 }
 
-func (m *SmartLimitDescriptor) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_SmartLimitDescriptor.Merge(m, src)
+func (m *Schedule) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Schedule.Merge(m, src)
 }
 
-func (m *SmartLimitDescriptor) XXX_Size() int {
-	return xxx_messageInfo_SmartLimitDescriptor.Size(m)
+func (m *Schedule) XXX_Size() int {
+	return xxx_messageInfo_Schedule.Size(m)
 }
 
-func (m *SmartLimitDescriptor) XXX_DiscardUnknown() {
-	xxx_messageInfo_SmartLimitDescriptor.DiscardUnknown(m)
+func (m *Schedule) XXX_DiscardUnknown() {
+	xxx_messageInfo_Schedule.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_SmartLimitDescriptor proto.InternalMessageInfo
+var xxx_messageInfo_Schedule proto.InternalMessageInfo
 
-func (m *SmartLimitDescriptor) GetCondition() string {
+func (m *Schedule) GetCron() string {
 	if m != nil {
-		return m.Condition
+		return m.Cron
 	}
 	return ""
 }
 
-func (m *SmartLimitDescriptor) GetAction() *SmartLimitDescriptor_Action {
+func (m *Schedule) GetDuration() *Duration {
 	if m != nil {
-		return m.Action
+		return m.Duration
 	}
 	return nil
 }
 
-func (m *SmartLimitDescriptor) GetMatch() []*SmartLimitDescriptor_HeaderMatcher {
+func (m *Schedule) GetStart() string {
 	if m != nil {
-		return m.Match
+		return m.Start
 	}
-	return nil
+	return ""
 }
 
-func (m *SmartLimitDescriptor) GetTarget() *SmartLimitDescriptor_Target {
+func (m *Schedule) GetEnd() string {
 	if m != nil {
-		return m.Target
+		return m.End
 	}
-	return nil
+	return ""
 }
 
-func (m *SmartLimitDescriptor) GetCustomKey() string {
+func (m *Schedule) GetTimezone() string {
 	if m != nil {
-		return m.CustomKey
+		return m.Timezone
 	}
 	return ""
 }
 
-func (m *SmartLimitDescriptor) GetCustomValue() string {
+func (m *Schedule) GetQuota() string {
 	if m != nil {
-		return m.CustomValue
+		return m.Quota
 	}
 	return ""
 }
 
-type SmartLimitDescriptor_HeaderMatcher struct {
-	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	// If specified, this regex string is a regular expression rule which implies the entire request
-	// header value must match the regex. The rule will not match if only a subsequence of the
-	// request header value matches the regex.
-	RegexMatch string `protobuf:"bytes,2,opt,name=regex_match,json=regexMatch,proto3" json:"regex_match,omitempty"`
-	// If specified, header match will be performed based on the value of the header.
-	ExactMatch string `protobuf:"bytes,3,opt,name=exact_match,json=exactMatch,proto3" json:"exact_match,omitempty"`
-	// * The prefix *abcd* matches the value *abcdxyz*, but not for *abcxyz*.
-	PrefixMatch string `protobuf:"bytes,4,opt,name=prefix_match,json=prefixMatch,proto3" json:"prefix_match,omitempty"`
-	// * The suffix *abcd* matches the value *xyzabcd*, but not for *xyzbcd*.
-	SuffixMatch string `protobuf:"bytes,5,opt,name=suffix_match,json=suffixMatch,proto3" json:"suffix_match,omitempty"`
-	// If specified as true, header match will be performed based on whether the header is in the
-	// request. If specified as false, header match will be performed based on whether the header is absent.
-	PresentMatch bool `protobuf:"varint,6,opt,name=present_match,json=presentMatch,proto3" json:"present_match,omitempty"`
-	// If specified, the match result will be inverted before checking. Defaults to false.
-	// * The regex ``\d{3}`` does not match the value *1234*, so it will match when inverted.
-	InvertMatch bool `protobuf:"varint,7,opt,name=invert_match,json=invertMatch,proto3" json:"invert_match,omitempty"`
-	// if specified, the exact match the value ""
-	IsExactMatchEmpty    bool     `protobuf:"varint,8,opt,name=is_exact_match_empty,json=isExactMatchEmpty,proto3" json:"is_exact_match_empty,omitempty"`
+type Fallback struct {
+	// keep: calculate the quota with the last metrics
+	// fixed: use the fixed quota
+	// disable: remove the limit
+	Policy string `protobuf:"bytes,1,opt,name=policy,proto3" json:"policy,omitempty"`
+	// the quota used if policy is fixed
+	Quota                int64    `protobuf:"varint,2,opt,name=quota,proto3" json:"quota,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *SmartLimitDescriptor_HeaderMatcher) Reset()         { *m = SmartLimitDescriptor_HeaderMatcher{} }
-func (m *SmartLimitDescriptor_HeaderMatcher) String() string { return proto.CompactTextString(m) }
-func (*SmartLimitDescriptor_HeaderMatcher) ProtoMessage()    {}
-func (*SmartLimitDescriptor_HeaderMatcher) Descriptor() ([]byte, []int) {
-	return fileDescriptor_452a0625a4f6276b, []int{2, 0}
+func (m *Fallback) Reset()         { *m = Fallback{} }
+func (m *Fallback) String() string { return proto.CompactTextString(m) }
+func (*Fallback) ProtoMessage()    {}
+func (*Fallback) Descriptor() ([]byte, []int) {
+	return fileDescriptor_452a0625a4f6276b, []int{10}
 }
 
-func (m *SmartLimitDescriptor_HeaderMatcher) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_SmartLimitDescriptor_HeaderMatcher.Unmarshal(m, b)
+func (m *Fallback) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Fallback.Unmarshal(m, b)
 }
 
-func (m *SmartLimitDescriptor_HeaderMatcher) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_SmartLimitDescriptor_HeaderMatcher.Marshal(b, m, deterministic)
+func (m *Fallback) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Fallback.Marshal(b, m, deterministic)
 }
 
-func (m *SmartLimitDescriptor_HeaderMatcher) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_SmartLimitDescriptor_HeaderMatcher.Merge(m, src)
+func (m *Fallback) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Fallback.Merge(m, src)
 }
 
-func (m *SmartLimitDescriptor_HeaderMatcher) XXX_Size() int {
-	return xxx_messageInfo_SmartLimitDescriptor_HeaderMatcher.Size(m)
+func (m *Fallback) XXX_Size() int {
+	return xxx_messageInfo_Fallback.Size(m)
 }
 
-func (m *SmartLimitDescriptor_HeaderMatcher) XXX_DiscardUnknown() {
-	xxx_messageInfo_SmartLimitDescriptor_HeaderMatcher.DiscardUnknown(m)
+func (m *Fallback) XXX_DiscardUnknown() {
+	xxx_messageInfo_Fallback.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_SmartLimitDescriptor_HeaderMatcher proto.InternalMessageInfo
+var xxx_messageInfo_Fallback proto.InternalMessageInfo
 
-func (m *SmartLimitDescriptor_HeaderMatcher) GetName() string {
+func (m *Fallback) GetPolicy() string {
 	if m != nil {
-		return m.Name
+		return m.Policy
 	}
 	return ""
 }
 
-func (m *SmartLimitDescriptor_HeaderMatcher) GetRegexMatch() string {
+func (m *Fallback) GetQuota() int64 {
 	if m != nil {
-		return m.RegexMatch
+		return m.Quota
 	}
-	return ""
+	return 0
 }
 
-func (m *SmartLimitDescriptor_HeaderMatcher) GetExactMatch() string {
+// Feedback adjusts the quota of a descriptor in closed loop each time the metric is refreshed
+type Feedback struct {
+	// key of the metric in status.metricStatus, like _base.rt99 or _base.cpu.max
+	Metric string `protobuf:"bytes,1,opt,name=metric,proto3" json:"metric,omitempty"`
+	// target of the metric, the quota is decreased if the metric is larger than target
+	Target float64 `protobuf:"fixed64,2,opt,name=target,proto3" json:"target,omitempty"`
+	// aimd or pid, default is aimd
+	Algorithm string `protobuf:"bytes,3,opt,name=algorithm,proto3" json:"algorithm,omitempty"`
+	// aimd: the quota is increased by increase if the metric is not larger than target, default is 1
+	Increase float64 `protobuf:"fixed64,4,opt,name=increase,proto3" json:"increase,omitempty"`
+	// aimd: the quota is multiplied by decrease if the metric is larger than target, default is 0.5
+	Decrease float64 `protobuf:"fixed64,5,opt,name=decrease,proto3" json:"decrease,omitempty"`
+	// pid: quota = base * (1 + kp * e + ki * sum(e) + kd * (e - last_e)), e = (target - metric) / target
+	Kp float64 `protobuf:"fixed64,6,opt,name=kp,proto3" json:"kp,omitempty"`
+	Ki float64 `protobuf:"fixed64,7,opt,name=ki,proto3" json:"ki,omitempty"`
+	Kd float64 `protobuf:"fixed64,8,opt,name=kd,proto3" json:"kd,omitempty"`
+	// lower bound of the quota, default is 1
+	MinQuota int64 `protobuf:"varint,9,opt,name=min_quota,json=minQuota,proto3" json:"min_quota,omitempty"`
+	// upper bound of the quota
+	MaxQuota             int64    `protobuf:"varint,10,opt,name=max_quota,json=maxQuota,proto3" json:"max_quota,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Feedback) Reset()         { *m = Feedback{} }
+func (m *Feedback) String() string { return proto.CompactTextString(m) }
+func (*Feedback) ProtoMessage()    {}
+func (*Feedback) Descriptor() ([]byte, []int) {
+	return fileDescriptor_452a0625a4f6276b, []int{11}
+}
+
+func (m *Feedback) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Feedback.Unmarshal(m, b)
+}
+
+func (m *Feedback) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Feedback.Marshal(b, m, deterministic)
+}
+
+func (m *Feedback) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Feedback.Merge(m, src)
+}
+
+func (m *Feedback) XXX_Size() int {
+	return xxx_messageInfo_Feedback.Size(m)
+}
+
+func (m *Feedback) XXX_DiscardUnknown() {
+	xxx_messageInfo_Feedback.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Feedback proto.InternalMessageInfo
+
+func (m *Feedback) GetMetric() string {
 	if m != nil {
-		return m.ExactMatch
+		return m.Metric
 	}
 	return ""
 }
 
-func (m *SmartLimitDescriptor_HeaderMatcher) GetPrefixMatch() string {
+func (m *Feedback) GetTarget() float64 {
 	if m != nil {
-		return m.PrefixMatch
+		return m.Target
 	}
-	return ""
+	return 0
 }
 
-func (m *SmartLimitDescriptor_HeaderMatcher) GetSuffixMatch() string {
+func (m *Feedback) GetAlgorithm() string {
 	if m != nil {
-		return m.SuffixMatch
+		return m.Algorithm
 	}
 	return ""
 }
 
-func (m *SmartLimitDescriptor_HeaderMatcher) GetPresentMatch() bool {
+func (m *Feedback) GetIncrease() float64 {
 	if m != nil {
-		return m.PresentMatch
+		return m.Increase
 	}
-	return false
+	return 0
 }
 
-func (m *SmartLimitDescriptor_HeaderMatcher) GetInvertMatch() bool {
+func (m *Feedback) GetDecrease() float64 {
 	if m != nil {
-		return m.InvertMatch
+		return m.Decrease
 	}
-	return false
+	return 0
 }
 
-func (m *SmartLimitDescriptor_HeaderMatcher) GetIsExactMatchEmpty() bool {
+func (m *Feedback) GetKp() float64 {
 	if m != nil {
-		return m.IsExactMatchEmpty
+		return m.Kp
 	}
-	return false
-}
-
-type SmartLimitDescriptor_Action struct {
-	Quota                string    `protobuf:"bytes,1,opt,name=quota,proto3" json:"quota,omitempty"`
-	FillInterval         *Duration `protobuf:"bytes,2,opt,name=fill_interval,json=fillInterval,proto3" json:"fill_interval,omitempty"`
-	Strategy             string    `protobuf:"bytes,3,opt,name=strategy,proto3" json:"strategy,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
-	XXX_unrecognized     []byte    `json:"-"`
-	XXX_sizecache        int32     `json:"-"`
-}
-
-func (m *SmartLimitDescriptor_Action) Reset()         { *m = SmartLimitDescriptor_Action{} }
-func (m *SmartLimitDescriptor_Action) String() string { return proto.CompactTextString(m) }
-func (*SmartLimitDescriptor_Action) ProtoMessage()    {}
-func (*SmartLimitDescriptor_Action) Descriptor() ([]byte, []int) {
-	return fileDescriptor_452a0625a4f6276b, []int{2, 1}
-}
-
-func (m *SmartLimitDescriptor_Action) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_SmartLimitDescriptor_Action.Unmarshal(m, b)
-}
-
-func (m *SmartLimitDescriptor_Action) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_SmartLimitDescriptor_Action.Marshal(b, m, deterministic)
-}
-
-func (m *SmartLimitDescriptor_Action) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_SmartLimitDescriptor_Action.Merge(m, src)
-}
-
-func (m *SmartLimitDescriptor_Action) XXX_Size() int {
-	return xxx_messageInfo_SmartLimitDescriptor_Action.Size(m)
+	return 0
 }
 
-func (m *SmartLimitDescriptor_Action) XXX_DiscardUnknown() {
-	xxx_messageInfo_SmartLimitDescriptor_Action.DiscardUnknown(m)
+func (m *Feedback) GetKi() float64 {
+	if m != nil {
+		return m.Ki
+	}
+	return 0
 }
 
-var xxx_messageInfo_SmartLimitDescriptor_Action proto.InternalMessageInfo
-
-func (m *SmartLimitDescriptor_Action) GetQuota() string {
+func (m *Feedback) GetKd() float64 {
 	if m != nil {
-		return m.Quota
+		return m.Kd
 	}
-	return ""
+	return 0
 }
 
-func (m *SmartLimitDescriptor_Action) GetFillInterval() *Duration {
+func (m *Feedback) GetMinQuota() int64 {
 	if m != nil {
-		return m.FillInterval
+		return m.MinQuota
 	}
-	return nil
+	return 0
 }
 
-func (m *SmartLimitDescriptor_Action) GetStrategy() string {
+func (m *Feedback) GetMaxQuota() int64 {
 	if m != nil {
-		return m.Strategy
+		return m.MaxQuota
 	}
-	return ""
+	return 0
 }
 
-type SmartLimitDescriptor_Target struct {
-	Direction            string   `protobuf:"bytes,1,opt,name=direction,proto3" json:"direction,omitempty"`
-	Port                 int32    `protobuf:"varint,2,opt,name=port,proto3" json:"port,omitempty"`
-	Route                []string `protobuf:"bytes,3,rep,name=route,proto3" json:"route,omitempty"`
-	Host                 []string `protobuf:"bytes,4,rep,name=host,proto3" json:"host,omitempty"`
+// FeedbackStatus records the state of feedback, so it continues after the limiter is restarted
+type FeedbackStatus struct {
+	Quota int64 `protobuf:"varint,1,opt,name=quota,proto3" json:"quota,omitempty"`
+	// the latest value of the metric
+	Metric    float64 `protobuf:"fixed64,2,opt,name=metric,proto3" json:"metric,omitempty"`
+	Integral  float64 `protobuf:"fixed64,3,opt,name=integral,proto3" json:"integral,omitempty"`
+	LastError float64 `protobuf:"fixed64,4,opt,name=last_error,json=lastError,proto3" json:"last_error,omitempty"`
+	// unix timestamp of the latest adjustment
+	UpdateTime int64 `protobuf:"varint,5,opt,name=update_time,json=updateTime,proto3" json:"update_time,omitempty"`
+	// unix timestamp in nanoseconds of the metric sample of the latest adjustment, the quota is adjusted
+	// only once per sample no matter how many times the SmartLimiter is refreshed
+	SampleTime           int64    `protobuf:"varint,6,opt,name=sample_time,json=sampleTime,proto3" json:"sample_time,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *SmartLimitDescriptor_Target) Reset()         { *m = SmartLimitDescriptor_Target{} }
-func (m *SmartLimitDescriptor_Target) String() string { return proto.CompactTextString(m) }
-func (*SmartLimitDescriptor_Target) ProtoMessage()    {}
-func (*SmartLimitDescriptor_Target) Descriptor() ([]byte, []int) {
-	return fileDescriptor_452a0625a4f6276b, []int{2, 2}
+func (m *FeedbackStatus) Reset()         { *m = FeedbackStatus{} }
+func (m *FeedbackStatus) String() string { return proto.CompactTextString(m) }
+func (*FeedbackStatus) ProtoMessage()    {}
+func (*FeedbackStatus) Descriptor() ([]byte, []int) {
+	return fileDescriptor_452a0625a4f6276b, []int{12}
 }
 
-func (m *SmartLimitDescriptor_Target) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_SmartLimitDescriptor_Target.Unmarshal(m, b)
+func (m *FeedbackStatus) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FeedbackStatus.Unmarshal(m, b)
 }
 
-func (m *SmartLimitDescriptor_Target) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_SmartLimitDescriptor_Target.Marshal(b, m, deterministic)
+func (m *FeedbackStatus) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FeedbackStatus.Marshal(b, m, deterministic)
 }
 
-func (m *SmartLimitDescriptor_Target) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_SmartLimitDescriptor_Target.Merge(m, src)
+func (m *FeedbackStatus) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FeedbackStatus.Merge(m, src)
 }
 
-func (m *SmartLimitDescriptor_Target) XXX_Size() int {
-	return xxx_messageInfo_SmartLimitDescriptor_Target.Size(m)
+func (m *FeedbackStatus) XXX_Size() int {
+	return xxx_messageInfo_FeedbackStatus.Size(m)
 }
 
-func (m *SmartLimitDescriptor_Target) XXX_DiscardUnknown() {
-	xxx_messageInfo_SmartLimitDescriptor_Target.DiscardUnknown(m)
+func (m *FeedbackStatus) XXX_DiscardUnknown() {
+	xxx_messageInfo_FeedbackStatus.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_SmartLimitDescriptor_Target proto.InternalMessageInfo
+var xxx_messageInfo_FeedbackStatus proto.InternalMessageInfo
 
-func (m *SmartLimitDescriptor_Target) GetDirection() string {
+func (m *FeedbackStatus) GetQuota() int64 {
 	if m != nil {
-		return m.Direction
+		return m.Quota
 	}
-	return ""
+	return 0
 }
 
-func (m *SmartLimitDescriptor_Target) GetPort() int32 {
+func (m *FeedbackStatus) GetMetric() float64 {
 	if m != nil {
-		return m.Port
+		return m.Metric
 	}
 	return 0
 }
 
-func (m *SmartLimitDescriptor_Target) GetRoute() []string {
+func (m *FeedbackStatus) GetIntegral() float64 {
 	if m != nil {
-		return m.Route
+		return m.Integral
 	}
-	return nil
+	return 0
 }
 
-func (m *SmartLimitDescriptor_Target) GetHost() []string {
+func (m *FeedbackStatus) GetLastError() float64 {
 	if m != nil {
-		return m.Host
+		return m.LastError
 	}
-	return nil
+	return 0
+}
+
+func (m *FeedbackStatus) GetUpdateTime() int64 {
+	if m != nil {
+		return m.UpdateTime
+	}
+	return 0
+}
+
+func (m *FeedbackStatus) GetSampleTime() int64 {
+	if m != nil {
+		return m.SampleTime
+	}
+	return 0
 }
 
 type SmartLimitDescriptors struct {
@@ -458,7 +1628,7 @@ func (m *SmartLimitDescriptors) Reset()         { *m = SmartLimitDescriptors{} }
 func (m *SmartLimitDescriptors) String() string { return proto.CompactTextString(m) }
 func (*SmartLimitDescriptors) ProtoMessage()    {}
 func (*SmartLimitDescriptors) Descriptor() ([]byte, []int) {
-	return fileDescriptor_452a0625a4f6276b, []int{3}
+	return fileDescriptor_452a0625a4f6276b, []int{13}
 }
 
 func (m *SmartLimitDescriptors) XXX_Unmarshal(b []byte) error {
@@ -511,7 +1681,7 @@ func (m *Duration) Reset()         { *m = Duration{} }
 func (m *Duration) String() string { return proto.CompactTextString(m) }
 func (*Duration) ProtoMessage()    {}
 func (*Duration) Descriptor() ([]byte, []int) {
-	return fileDescriptor_452a0625a4f6276b, []int{4}
+	return fileDescriptor_452a0625a4f6276b, []int{14}
 }
 
 func (m *Duration) XXX_Unmarshal(b []byte) error {
@@ -551,15 +1721,41 @@ func (m *Duration) GetNanos() int32 {
 }
 
 func init() {
+	proto.RegisterEnum("slime.microservice.limiter.v1alpha2.PrometheusHandler_Type", PrometheusHandler_Type_name, PrometheusHandler_Type_value)
 	proto.RegisterType((*SmartLimiterSpec)(nil), "slime.microservice.limiter.v1alpha2.SmartLimiterSpec")
+	proto.RegisterMapType((map[string]*PrometheusHandler)(nil), "slime.microservice.limiter.v1alpha2.SmartLimiterSpec.PrometheusHandlersEntry")
 	proto.RegisterMapType((map[string]*SmartLimitDescriptors)(nil), "slime.microservice.limiter.v1alpha2.SmartLimiterSpec.SetsEntry")
+	proto.RegisterType((*SmartLimiterPolicySpec)(nil), "slime.microservice.limiter.v1alpha2.SmartLimiterPolicySpec")
+	proto.RegisterMapType((map[string]string)(nil), "slime.microservice.limiter.v1alpha2.SmartLimiterPolicySpec.ServiceSelectorEntry")
+	proto.RegisterType((*SmartLimiterPolicyStatus)(nil), "slime.microservice.limiter.v1alpha2.SmartLimiterPolicyStatus")
+	proto.RegisterMapType((map[string]string)(nil), "slime.microservice.limiter.v1alpha2.SmartLimiterPolicyStatus.CollisionsEntry")
+	proto.RegisterMapType((map[string]string)(nil), "slime.microservice.limiter.v1alpha2.SmartLimiterPolicyStatus.ServicesEntry")
+	proto.RegisterType((*TargetRef)(nil), "slime.microservice.limiter.v1alpha2.TargetRef")
+	proto.RegisterType((*WorkloadSelector)(nil), "slime.microservice.limiter.v1alpha2.WorkloadSelector")
+	proto.RegisterMapType((map[string]string)(nil), "slime.microservice.limiter.v1alpha2.WorkloadSelector.LabelsEntry")
+	proto.RegisterType((*PrometheusHandler)(nil), "slime.microservice.limiter.v1alpha2.PrometheusHandler")
+	proto.RegisterType((*Damping)(nil), "slime.microservice.limiter.v1alpha2.Damping")
 	proto.RegisterType((*SmartLimiterStatus)(nil), "slime.microservice.limiter.v1alpha2.SmartLimiterStatus")
+	proto.RegisterMapType((map[string]string)(nil), "slime.microservice.limiter.v1alpha2.SmartLimiterStatus.ConflictsEntry")
+	proto.RegisterMapType((map[string]string)(nil), "slime.microservice.limiter.v1alpha2.SmartLimiterStatus.DescriptorValuesEntry")
+	proto.RegisterMapType((map[string]*FeedbackStatus)(nil), "slime.microservice.limiter.v1alpha2.SmartLimiterStatus.FeedbackStatusEntry")
+	proto.RegisterMapType((map[string]string)(nil), "slime.microservice.limiter.v1alpha2.SmartLimiterStatus.InvalidDescriptorsEntry")
+	proto.RegisterMapType((map[string]string)(nil), "slime.microservice.limiter.v1alpha2.SmartLimiterStatus.MatcherConflictsEntry")
+	proto.RegisterMapType((map[string]string)(nil), "slime.microservice.limiter.v1alpha2.SmartLimiterStatus.MetricErrorsEntry")
 	proto.RegisterMapType((map[string]string)(nil), "slime.microservice.limiter.v1alpha2.SmartLimiterStatus.MetricStatusEntry")
+	proto.RegisterMapType((map[string]int64)(nil), "slime.microservice.limiter.v1alpha2.SmartLimiterStatus.MetricUpdateTimeEntry")
 	proto.RegisterMapType((map[string]*SmartLimitDescriptors)(nil), "slime.microservice.limiter.v1alpha2.SmartLimiterStatus.RatelimitStatusEntry")
+	proto.RegisterMapType((map[string]string)(nil), "slime.microservice.limiter.v1alpha2.SmartLimiterStatus.StaleDescriptorsEntry")
 	proto.RegisterType((*SmartLimitDescriptor)(nil), "slime.microservice.limiter.v1alpha2.SmartLimitDescriptor")
 	proto.RegisterType((*SmartLimitDescriptor_HeaderMatcher)(nil), "slime.microservice.limiter.v1alpha2.SmartLimitDescriptor.HeaderMatcher")
+	proto.RegisterType((*SmartLimitDescriptor_Int64Range)(nil), "slime.microservice.limiter.v1alpha2.SmartLimitDescriptor.Int64Range")
 	proto.RegisterType((*SmartLimitDescriptor_Action)(nil), "slime.microservice.limiter.v1alpha2.SmartLimitDescriptor.Action")
 	proto.RegisterType((*SmartLimitDescriptor_Target)(nil), "slime.microservice.limiter.v1alpha2.SmartLimitDescriptor.Target")
+	proto.RegisterType((*SmartLimitDescriptor_PathMatcher)(nil), "slime.microservice.limiter.v1alpha2.SmartLimitDescriptor.PathMatcher")
+	proto.RegisterType((*Schedule)(nil), "slime.microservice.limiter.v1alpha2.Schedule")
+	proto.RegisterType((*Fallback)(nil), "slime.microservice.limiter.v1alpha2.Fallback")
+	proto.RegisterType((*Feedback)(nil), "slime.microservice.limiter.v1alpha2.Feedback")
+	proto.RegisterType((*FeedbackStatus)(nil), "slime.microservice.limiter.v1alpha2.FeedbackStatus")
 	proto.RegisterType((*SmartLimitDescriptors)(nil), "slime.microservice.limiter.v1alpha2.SmartLimitDescriptors")
 	proto.RegisterType((*Duration)(nil), "slime.microservice.limiter.v1alpha2.Duration")
 }
@@ -567,50 +1763,128 @@ func init() {
 func init() { proto.RegisterFile("smart_limiter.proto", fileDescriptor_452a0625a4f6276b) }
 
 var fileDescriptor_452a0625a4f6276b = []byte{
-	// 707 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x55, 0xdd, 0x4e, 0x13, 0x4d,
-	0x18, 0x4e, 0x7f, 0x69, 0xdf, 0x96, 0x7c, 0x30, 0x5f, 0x4d, 0x9a, 0x8d, 0x46, 0x28, 0x27, 0x9c,
-	0xb0, 0x0d, 0x70, 0xa2, 0x9c, 0xa0, 0x06, 0xa2, 0x44, 0x48, 0xcc, 0xd6, 0x18, 0x35, 0x31, 0x9b,
-	0x71, 0xfb, 0x02, 0x13, 0x76, 0x77, 0xd6, 0x99, 0xd9, 0x86, 0x9e, 0x78, 0x09, 0x5e, 0x85, 0x89,
-	0x37, 0xe1, 0x35, 0x79, 0x0d, 0x66, 0x7e, 0x76, 0x69, 0xb1, 0x07, 0x50, 0x13, 0x4f, 0x9a, 0x99,
-	0x67, 0x9e, 0x79, 0xde, 0xe7, 0xfd, 0xd9, 0x29, 0xfc, 0x2f, 0x13, 0x2a, 0x54, 0x18, 0xb3, 0x84,
-	0x29, 0x14, 0x7e, 0x26, 0xb8, 0xe2, 0x64, 0x4b, 0xc6, 0x2c, 0x41, 0x3f, 0x61, 0x91, 0xe0, 0x12,
-	0xc5, 0x84, 0x45, 0xe8, 0x17, 0x8c, 0xc9, 0x2e, 0x8d, 0xb3, 0x4b, 0xba, 0x37, 0xf8, 0x55, 0x81,
-	0xb5, 0x91, 0xbe, 0x7c, 0x6a, 0x4f, 0x46, 0x19, 0x46, 0x64, 0x04, 0x75, 0x89, 0x4a, 0xf6, 0x2b,
-	0x1b, 0xb5, 0xed, 0xce, 0xde, 0xa1, 0x7f, 0x07, 0x21, 0xff, 0xb6, 0x88, 0x3f, 0x42, 0x25, 0x8f,
-	0x53, 0x25, 0xa6, 0x81, 0x11, 0x23, 0x6b, 0x50, 0x13, 0xb1, 0xec, 0x57, 0x37, 0x2a, 0xdb, 0xed,
-	0x40, 0x2f, 0x3d, 0x09, 0xed, 0x92, 0xa4, 0x8f, 0xaf, 0x70, 0xda, 0xaf, 0xd8, 0xe3, 0x2b, 0x9c,
-	0x92, 0x37, 0xd0, 0x98, 0xd0, 0x38, 0x47, 0x73, 0xa5, 0xb3, 0x77, 0x70, 0x4f, 0x1b, 0x47, 0x28,
-	0x23, 0xc1, 0x32, 0xc5, 0x85, 0x0c, 0xac, 0xd0, 0x41, 0xf5, 0x49, 0x65, 0xf0, 0xb3, 0x06, 0x64,
-	0xce, 0xab, 0xa2, 0x2a, 0x97, 0x64, 0x02, 0xff, 0x09, 0xaa, 0xd0, 0xe8, 0x59, 0xc8, 0x65, 0x7f,
-	0x7a, 0xff, 0xec, 0xcd, 0x75, 0x3f, 0x98, 0x97, 0xb3, 0xa5, 0xb8, 0x1d, 0x84, 0x24, 0xd0, 0x4d,
-	0x50, 0x09, 0x16, 0xb9, 0xa0, 0x55, 0x13, 0xf4, 0x64, 0xd9, 0xa0, 0x67, 0x33, 0x5a, 0x36, 0xe2,
-	0x9c, 0xbc, 0xf7, 0x15, 0x7a, 0x8b, 0x7c, 0xfd, 0xab, 0xea, 0x7b, 0x87, 0xb0, 0xfe, 0x87, 0xc5,
-	0x05, 0xc1, 0x7b, 0xb3, 0xc1, 0xdb, 0xb3, 0xed, 0xfb, 0xb1, 0x02, 0xbd, 0x45, 0x51, 0xc8, 0x43,
-	0x68, 0x47, 0x3c, 0x1d, 0x33, 0xc5, 0x78, 0xea, 0xa4, 0x6e, 0x00, 0xf2, 0x1e, 0x9a, 0x34, 0x32,
-	0x47, 0x36, 0x9d, 0x67, 0x4b, 0xa7, 0xe3, 0x3f, 0x37, 0x3a, 0x81, 0xd3, 0x23, 0x9f, 0xa0, 0x91,
-	0x50, 0x15, 0x5d, 0xf6, 0x6b, 0xa6, 0x73, 0x2f, 0x97, 0x17, 0x7e, 0x85, 0x74, 0x8c, 0xe2, 0x4c,
-	0x8b, 0xa1, 0x08, 0xac, 0xaa, 0x36, 0xae, 0xa8, 0xb8, 0x40, 0xd5, 0xaf, 0xff, 0xad, 0xf1, 0xb7,
-	0x46, 0x27, 0x70, 0x7a, 0xe4, 0x11, 0x40, 0x94, 0x4b, 0xc5, 0x93, 0x50, 0x17, 0xbf, 0xe1, 0x2a,
-	0x66, 0x90, 0xd7, 0x38, 0x25, 0x9b, 0xd0, 0x75, 0xc7, 0xb6, 0x13, 0x4d, 0x43, 0xe8, 0x58, 0xec,
-	0x9d, 0x86, 0xbc, 0xef, 0x55, 0x58, 0x9d, 0x33, 0x4d, 0x08, 0xd4, 0x53, 0x9a, 0xa0, 0xab, 0xbf,
-	0x59, 0x93, 0xc7, 0xd0, 0x11, 0x78, 0x81, 0xd7, 0xa1, 0x2d, 0x93, 0xed, 0x28, 0x18, 0xc8, 0x5c,
-	0xd3, 0x04, 0xbc, 0xa6, 0x91, 0x0a, 0x8b, 0x3a, 0x1a, 0x82, 0x81, 0x2c, 0x61, 0x13, 0xba, 0x99,
-	0xc0, 0x73, 0x56, 0x48, 0xd4, 0xad, 0x15, 0x8b, 0x95, 0x14, 0x99, 0x9f, 0xdf, 0x50, 0x6c, 0x3a,
-	0x1d, 0x8b, 0x59, 0xca, 0x16, 0xac, 0x66, 0x02, 0x25, 0xa6, 0x45, 0x20, 0x9d, 0x51, 0x2b, 0xe8,
-	0x3a, 0xb0, 0xd4, 0x61, 0xe9, 0x04, 0x45, 0xc1, 0x59, 0x31, 0x9c, 0x8e, 0xc5, 0x2c, 0x65, 0x08,
-	0x3d, 0x26, 0xc3, 0x19, 0xc7, 0x21, 0x26, 0x99, 0x9a, 0xf6, 0x5b, 0x86, 0xba, 0xce, 0xe4, 0x71,
-	0xe9, 0xfc, 0x58, 0x1f, 0x78, 0xdf, 0x2a, 0xd0, 0xb4, 0x43, 0xa3, 0xe7, 0xfa, 0x4b, 0xce, 0x15,
-	0x75, 0x05, 0xb2, 0x1b, 0x12, 0xc0, 0xea, 0x39, 0x8b, 0xe3, 0x90, 0xa5, 0x0a, 0xc5, 0x84, 0xc6,
-	0x6e, 0x46, 0x77, 0xee, 0xd4, 0xea, 0xa3, 0x5c, 0x50, 0x33, 0x90, 0x5d, 0xad, 0x71, 0xe2, 0x24,
-	0x88, 0x07, 0x2d, 0xa9, 0xf4, 0x63, 0x73, 0x31, 0x75, 0x15, 0x2d, 0xf7, 0xde, 0x18, 0x9a, 0x76,
-	0x16, 0xf4, 0x47, 0x33, 0x66, 0x02, 0xa3, 0xd9, 0x8f, 0xa6, 0x04, 0x74, 0x37, 0x33, 0x2e, 0x94,
-	0xb1, 0xd3, 0x08, 0xcc, 0x5a, 0x67, 0x20, 0x78, 0xae, 0xd0, 0x8c, 0x7b, 0x3b, 0xb0, 0x1b, 0xcd,
-	0xbc, 0xe4, 0x52, 0xcf, 0xa8, 0x06, 0xcd, 0x7a, 0x20, 0xe0, 0xc1, 0xc2, 0xe7, 0x80, 0x7c, 0x00,
-	0x18, 0x97, 0x5b, 0xf7, 0xca, 0x3e, 0x5d, 0x7a, 0xac, 0x83, 0x19, 0xb1, 0xc1, 0x01, 0xb4, 0x8a,
-	0x7a, 0x90, 0x3e, 0xac, 0x48, 0xd4, 0x2f, 0x80, 0x34, 0x99, 0xd5, 0x82, 0x62, 0xab, 0x73, 0x48,
-	0x69, 0xca, 0xa5, 0x4b, 0xcc, 0x6e, 0x5e, 0xec, 0x7f, 0xdc, 0xb5, 0x1e, 0x18, 0x1f, 0x9a, 0x85,
-	0xfd, 0xdd, 0x49, 0xf8, 0x38, 0x8f, 0x51, 0x0e, 0x9d, 0x9b, 0x21, 0xcd, 0xd8, 0xb0, 0x70, 0xf4,
-	0xb9, 0x69, 0xfe, 0x6a, 0xf7, 0x7f, 0x07, 0x00, 0x00, 0xff, 0xff, 0xf9, 0x52, 0x72, 0xe3, 0x81,
-	0x07, 0x00, 0x00,
+	// 1958 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x59, 0x5b, 0x93, 0x1b, 0x47,
+	0x15, 0xf6, 0xac, 0x2e, 0xab, 0x39, 0x5a, 0xaf, 0xe5, 0xf6, 0xc6, 0x19, 0x44, 0x20, 0x1b, 0xa5,
+	0x28, 0xf6, 0x01, 0xcb, 0x95, 0x75, 0x48, 0x25, 0x0e, 0x54, 0xf0, 0x35, 0x5e, 0xbc, 0x26, 0x9b,
+	0x91, 0xb9, 0x98, 0x2a, 0x6a, 0xaa, 0x3d, 0x73, 0x56, 0xea, 0xda, 0xb9, 0xa5, 0xbb, 0xb5, 0x5e,
+	0x41, 0x11, 0xfe, 0x01, 0xc5, 0x23, 0x2f, 0xbc, 0xf3, 0x1f, 0x78, 0xa4, 0xf8, 0x03, 0xfc, 0x03,
+	0xfe, 0x00, 0xaf, 0x54, 0xc1, 0x0b, 0xd5, 0x97, 0x19, 0xcd, 0x48, 0x0a, 0xac, 0x64, 0x8a, 0x17,
+	0xd5, 0x9c, 0xd3, 0xdd, 0xdf, 0xb9, 0xf4, 0x39, 0xa7, 0x4f, 0xb7, 0xe0, 0x86, 0x48, 0x28, 0x97,
+	0x41, 0xcc, 0x12, 0x26, 0x91, 0x0f, 0x73, 0x9e, 0xc9, 0x8c, 0xbc, 0x2b, 0x62, 0x96, 0xe0, 0x30,
+	0x61, 0x21, 0xcf, 0x04, 0xf2, 0x73, 0x16, 0xe2, 0xb0, 0x98, 0x71, 0xfe, 0x1e, 0x8d, 0xf3, 0x09,
+	0x3d, 0x1c, 0xfc, 0xb5, 0x0d, 0xbd, 0x91, 0x5a, 0x7c, 0x6c, 0x46, 0x46, 0x39, 0x86, 0x64, 0x04,
+	0x4d, 0x81, 0x52, 0x78, 0xce, 0x7e, 0xe3, 0xa0, 0x7b, 0xf8, 0xc9, 0xf0, 0x12, 0x40, 0xc3, 0x45,
+	0x90, 0xe1, 0x08, 0xa5, 0x78, 0x94, 0x4a, 0x3e, 0xf3, 0x35, 0x18, 0xe9, 0x41, 0x83, 0xc7, 0xc2,
+	0xdb, 0xda, 0x77, 0x0e, 0x5c, 0x5f, 0x7d, 0x92, 0xc7, 0xb0, 0x1d, 0xd1, 0x24, 0x67, 0xe9, 0xd8,
+	0x6b, 0xec, 0x3b, 0x07, 0xdd, 0xc3, 0xef, 0x5c, 0x4a, 0xd2, 0x43, 0xb3, 0xc6, 0x2f, 0x16, 0x93,
+	0x63, 0x80, 0x04, 0x25, 0x67, 0x61, 0x20, 0x65, 0xec, 0x35, 0x35, 0xd4, 0xad, 0xcb, 0x41, 0x4d,
+	0x39, 0x95, 0x2c, 0x4b, 0x7d, 0xd7, 0x00, 0x3c, 0x97, 0x31, 0xf9, 0x12, 0x6e, 0xe4, 0x3c, 0x4b,
+	0x50, 0x4e, 0x70, 0x2a, 0x82, 0x09, 0x4d, 0xa3, 0x18, 0xb9, 0xf0, 0x5a, 0xda, 0x17, 0xcf, 0x36,
+	0xf3, 0xc5, 0x49, 0x09, 0xf8, 0xc4, 0xe2, 0x19, 0xcf, 0x90, 0x7c, 0x69, 0x80, 0x3c, 0x03, 0x90,
+	0x94, 0x8f, 0x51, 0x06, 0x1c, 0x4f, 0xbd, 0xb6, 0xb6, 0x66, 0x78, 0x29, 0xb1, 0xcf, 0xf5, 0x32,
+	0x1f, 0x4f, 0x7d, 0x57, 0x16, 0x9f, 0xe4, 0x25, 0x5c, 0x7f, 0x95, 0xf1, 0xb3, 0x38, 0xa3, 0x51,
+	0x20, 0x30, 0xc6, 0x50, 0x66, 0xdc, 0xdb, 0xd6, 0xa8, 0xdf, 0xbd, 0x14, 0xea, 0x4f, 0xed, 0xea,
+	0x91, 0x5d, 0xec, 0xf7, 0x5e, 0x2d, 0x70, 0x48, 0x1f, 0x3a, 0x39, 0x67, 0x19, 0x67, 0x72, 0xe6,
+	0x75, 0xf6, 0x9d, 0x83, 0x96, 0x5f, 0xd2, 0x7d, 0x01, 0x6e, 0x19, 0x09, 0x2a, 0x06, 0xce, 0x70,
+	0xe6, 0x39, 0x26, 0x06, 0xce, 0x70, 0x46, 0x4e, 0xa0, 0x75, 0x4e, 0xe3, 0x29, 0xea, 0xb8, 0xe8,
+	0x1e, 0xde, 0x5d, 0xd3, 0xbf, 0x0f, 0x51, 0x84, 0x9c, 0xe5, 0x32, 0xe3, 0xc2, 0x37, 0x40, 0x77,
+	0xb7, 0x3e, 0x74, 0xfa, 0xbf, 0x86, 0x37, 0xbf, 0xc2, 0xe5, 0x2b, 0x54, 0x38, 0xae, 0xab, 0xf0,
+	0xc1, 0xa5, 0x54, 0x58, 0x82, 0xaf, 0x88, 0x1f, 0xfc, 0x63, 0x0b, 0x6e, 0x56, 0x63, 0xe0, 0x24,
+	0x8b, 0x59, 0x38, 0xd3, 0xa9, 0xf5, 0x2b, 0xe8, 0x59, 0xcc, 0xf9, 0x6e, 0x98, 0x34, 0x3b, 0x59,
+	0x3b, 0xb4, 0xe6, 0xb0, 0xc3, 0x91, 0x99, 0x5f, 0xec, 0x88, 0x89, 0xae, 0x6b, 0xa2, 0xce, 0xad,
+	0xed, 0xd3, 0x56, 0x7d, 0x9f, 0xc8, 0x2d, 0x20, 0x78, 0x11, 0xc6, 0xd3, 0x08, 0x83, 0x94, 0x26,
+	0x28, 0x72, 0x1a, 0xa2, 0xf0, 0x1a, 0xfb, 0x8d, 0x03, 0xd7, 0xbf, 0x6e, 0x47, 0x7e, 0x54, 0x0e,
+	0x90, 0xcf, 0xa1, 0x23, 0x31, 0xc9, 0x63, 0x2a, 0xd1, 0x6b, 0xae, 0x11, 0x4d, 0x8b, 0xa9, 0xe1,
+	0x97, 0x30, 0xfd, 0xfb, 0xb0, 0xb7, 0xca, 0x8c, 0x15, 0x3b, 0xb6, 0x57, 0xdd, 0x31, 0xb7, 0xea,
+	0xf9, 0xbf, 0x6f, 0x81, 0xb7, 0xc2, 0x45, 0x92, 0xca, 0xa9, 0x20, 0x63, 0xe8, 0x58, 0xbd, 0x8a,
+	0xd2, 0xf6, 0x74, 0x53, 0x9f, 0x6b, 0xc0, 0xc2, 0xeb, 0x36, 0x99, 0x4b, 0x70, 0x92, 0x00, 0x84,
+	0x59, 0x1c, 0x33, 0xc1, 0xb2, 0x54, 0x55, 0xbc, 0xcd, 0x2a, 0x47, 0x4d, 0xd4, 0x83, 0x12, 0xcf,
+	0x08, 0xab, 0x08, 0xe8, 0x7f, 0x0c, 0x57, 0x6b, 0x9a, 0xac, 0xe3, 0xb1, 0xfe, 0xf7, 0xe1, 0xda,
+	0x02, 0xf6, 0x5a, 0x0e, 0xbf, 0x03, 0x6e, 0x59, 0x76, 0x08, 0x81, 0xe6, 0x19, 0x4b, 0x23, 0xbb,
+	0x52, 0x7f, 0x2b, 0x9e, 0x8a, 0x27, 0xbb, 0x52, 0x7f, 0x0f, 0xfe, 0xe8, 0x40, 0x6f, 0xb1, 0xac,
+	0x90, 0x17, 0xd0, 0x8e, 0xe9, 0x4b, 0x8c, 0x8b, 0xbd, 0xb9, 0xb7, 0x51, 0x75, 0x1a, 0x1e, 0x6b,
+	0x0c, 0xe3, 0x24, 0x0b, 0xd8, 0xff, 0x08, 0xba, 0x15, 0xf6, 0x5a, 0xf6, 0xfd, 0xde, 0x81, 0xeb,
+	0x4b, 0xb9, 0xae, 0xe6, 0x7f, 0x31, 0x45, 0x5e, 0x60, 0x18, 0x82, 0x7c, 0x06, 0x4d, 0x39, 0xcb,
+	0x0d, 0xc8, 0xee, 0xe1, 0xc7, 0x9b, 0xd5, 0x91, 0xe1, 0xf3, 0x59, 0x8e, 0xbe, 0x06, 0x1a, 0xbc,
+	0x05, 0x4d, 0x45, 0x11, 0x17, 0x5a, 0x3f, 0x51, 0x1a, 0xf5, 0xae, 0xa8, 0xcf, 0x4f, 0x79, 0x36,
+	0xcd, 0x7b, 0xce, 0xe0, 0x0f, 0x0e, 0x6c, 0xdb, 0xb3, 0x90, 0x7c, 0x03, 0x00, 0x5f, 0x25, 0x34,
+	0xd0, 0xa8, 0x5a, 0x2b, 0xc7, 0x77, 0x15, 0xe7, 0x9e, 0x62, 0x90, 0x6f, 0x02, 0x4c, 0x66, 0x42,
+	0x22, 0x47, 0xc1, 0xcc, 0x11, 0xec, 0xf8, 0x15, 0x0e, 0xf9, 0x1a, 0x74, 0x12, 0x7a, 0x11, 0x08,
+	0x89, 0xb9, 0x3e, 0x8a, 0x1d, 0x7f, 0x3b, 0xa1, 0x17, 0x23, 0x89, 0x39, 0xf9, 0x3a, 0xb8, 0x09,
+	0x4b, 0x83, 0x2f, 0xa6, 0x99, 0xa4, 0x3a, 0xd3, 0x1b, 0x7e, 0x27, 0x61, 0xe9, 0xe7, 0x8a, 0xd6,
+	0x83, 0xf4, 0xc2, 0x0e, 0xb6, 0xec, 0x20, 0xbd, 0xd0, 0x83, 0x83, 0xbf, 0x5d, 0x03, 0x52, 0x4b,
+	0x77, 0x93, 0x85, 0xe7, 0x70, 0x8d, 0x53, 0x89, 0xda, 0x13, 0x86, 0x65, 0x37, 0xfc, 0x78, 0xfd,
+	0x02, 0x62, 0x72, 0xc3, 0xaf, 0xc3, 0xd9, 0xe2, 0xb7, 0x20, 0x84, 0x24, 0xb0, 0x63, 0x0e, 0x79,
+	0x2b, 0xd4, 0xa4, 0xe5, 0xd1, 0xa6, 0x42, 0x9f, 0x55, 0xb0, 0x8c, 0xc4, 0x1a, 0x3c, 0x99, 0x41,
+	0x2f, 0x2a, 0x0f, 0x27, 0xbd, 0x7b, 0xa6, 0x9a, 0x6e, 0xd4, 0x43, 0x18, 0x91, 0x0f, 0x17, 0xf0,
+	0x8c, 0xd8, 0x25, 0x31, 0x44, 0xc0, 0xee, 0x29, 0x62, 0xf4, 0x92, 0x86, 0x67, 0xd6, 0xd6, 0xe6,
+	0x86, 0xd5, 0xce, 0x0a, 0x7e, 0x5c, 0x43, 0x33, 0x62, 0x17, 0x44, 0x28, 0x7b, 0x8d, 0xfd, 0x3f,
+	0xce, 0x23, 0x2a, 0xf1, 0x39, 0x4b, 0x70, 0xf3, 0x9e, 0xa9, 0xea, 0xe2, 0x39, 0x9e, 0xb5, 0x77,
+	0x51, 0x8c, 0x12, 0x2d, 0x24, 0x8d, 0xb1, 0xd2, 0x0c, 0x78, 0xed, 0xd7, 0x13, 0x3d, 0x5a, 0xc0,
+	0xb3, 0xa2, 0x17, 0xc5, 0x90, 0x08, 0xdc, 0x30, 0x4b, 0x4f, 0x63, 0x16, 0x4a, 0xe1, 0x6d, 0x6b,
+	0x99, 0x8f, 0x37, 0x95, 0xf9, 0xa0, 0x00, 0x32, 0xc2, 0xe6, 0xc0, 0xea, 0xdc, 0x8e, 0x70, 0xcc,
+	0x69, 0x84, 0x91, 0xee, 0xaf, 0x5c, 0xbf, 0xa4, 0xc9, 0x6f, 0x80, 0xb0, 0xf4, 0x9c, 0xc6, 0x2c,
+	0xaa, 0x9a, 0xef, 0x6a, 0x55, 0x3e, 0xdb, 0x54, 0x95, 0xa3, 0x25, 0x44, 0xdb, 0xaf, 0x2e, 0x8b,
+	0x9a, 0xe7, 0xd5, 0x23, 0xce, 0x95, 0x68, 0xf8, 0x5f, 0xe4, 0x95, 0xc1, 0xaa, 0xe5, 0x95, 0x61,
+	0xe9, 0x38, 0xa3, 0x32, 0x9c, 0x20, 0x2f, 0xfd, 0xe5, 0x75, 0x5f, 0x33, 0xce, 0x16, 0xf0, 0x8a,
+	0x38, 0x5b, 0x60, 0xf7, 0xbf, 0x84, 0xbd, 0x55, 0xa5, 0xe6, 0xff, 0xd6, 0xd5, 0x7e, 0x02, 0xd7,
+	0x97, 0xaa, 0xce, 0x5a, 0x67, 0xfd, 0x03, 0x78, 0x63, 0x65, 0x0d, 0x59, 0x0b, 0xe4, 0x1c, 0x6e,
+	0xac, 0xa8, 0x07, 0x2b, 0x20, 0x8e, 0xea, 0x4e, 0xb8, 0x73, 0x29, 0x27, 0xd4, 0xa1, 0x17, 0x94,
+	0x5f, 0x59, 0x10, 0xfe, 0x9b, 0xf2, 0x8d, 0x05, 0x90, 0x95, 0xa9, 0xbd, 0x96, 0x07, 0xbe, 0x07,
+	0xbb, 0xf5, 0x58, 0x59, 0x6b, 0xf5, 0x23, 0x78, 0xf3, 0x2b, 0xd2, 0x6b, 0x2d, 0x98, 0x32, 0x18,
+	0x2a, 0xa9, 0xb2, 0x6e, 0x30, 0xac, 0x0c, 0xfc, 0xb5, 0xda, 0xa3, 0x7f, 0xed, 0xc0, 0xde, 0xaa,
+	0xb8, 0x25, 0x6f, 0xe9, 0xc2, 0x18, 0x31, 0x75, 0xbb, 0xb6, 0x50, 0x73, 0x06, 0xf9, 0x19, 0xb4,
+	0x69, 0xa8, 0x87, 0x4c, 0x6c, 0xfc, 0x60, 0xe3, 0x04, 0x19, 0xde, 0xd3, 0x38, 0xbe, 0xc5, 0x23,
+	0xbf, 0x80, 0x96, 0xce, 0x5b, 0x7b, 0xd6, 0x7e, 0xba, 0x39, 0xf0, 0x13, 0xa4, 0x11, 0x72, 0xeb,
+	0x22, 0xdf, 0xa0, 0x2a, 0xc5, 0xcd, 0xd5, 0xda, 0x6b, 0xbe, 0xae, 0xe2, 0xb6, 0x6d, 0xb6, 0x78,
+	0xaa, 0x83, 0x0b, 0xa7, 0x42, 0x66, 0x49, 0xa0, 0x9c, 0xdf, 0xb2, 0x1e, 0xd3, 0x9c, 0xa7, 0x38,
+	0x23, 0xef, 0xc0, 0x8e, 0x1d, 0x36, 0x3b, 0xd1, 0xd6, 0x13, 0xba, 0x86, 0xa7, 0x53, 0x99, 0xbc,
+	0x80, 0x66, 0x4e, 0xe5, 0xc4, 0x5e, 0xee, 0x1f, 0x6d, 0xae, 0xd9, 0x09, 0x95, 0x93, 0xc2, 0x6e,
+	0x0d, 0x49, 0x6e, 0x42, 0x5b, 0x75, 0xa9, 0x99, 0x3a, 0x7e, 0xd4, 0x85, 0xd0, 0x52, 0x65, 0x73,
+	0xef, 0xce, 0x9b, 0x7b, 0x72, 0x04, 0x9d, 0xa2, 0x35, 0xf0, 0x60, 0x8d, 0xb7, 0x98, 0x22, 0xf3,
+	0xfd, 0x72, 0xb9, 0x86, 0xa2, 0x71, 0xac, 0xa1, 0xba, 0xeb, 0x40, 0xd9, 0x45, 0x7e, 0xb9, 0x9c,
+	0x3c, 0x05, 0x57, 0x84, 0x13, 0x8c, 0xa6, 0x31, 0x0a, 0x6f, 0x67, 0xbf, 0x71, 0x69, 0xac, 0x91,
+	0x5d, 0xe5, 0xcf, 0xd7, 0xf7, 0xff, 0xd2, 0x80, 0xab, 0xb5, 0xf0, 0x28, 0x1d, 0xe1, 0x54, 0x1c,
+	0xf1, 0x0e, 0x74, 0x39, 0x8e, 0xf1, 0x22, 0x30, 0x01, 0xa9, 0x73, 0xe7, 0xc9, 0x15, 0x1f, 0x34,
+	0x53, 0x2f, 0x54, 0x53, 0xf0, 0x82, 0x86, 0x32, 0x28, 0x62, 0xd6, 0x4e, 0xd1, 0x4c, 0x33, 0xe5,
+	0x5d, 0xd8, 0xc9, 0x39, 0x9e, 0xb2, 0x02, 0xa6, 0x69, 0xe7, 0x74, 0x0d, 0xb7, 0x9c, 0x24, 0xa6,
+	0xa7, 0xf3, 0x49, 0xad, 0x62, 0x92, 0xe1, 0x9a, 0x49, 0xdf, 0x82, 0xab, 0x39, 0x47, 0x81, 0x69,
+	0x21, 0x4e, 0xc5, 0x50, 0xe7, 0xc9, 0x15, 0x7f, 0xc7, 0xb2, 0xcd, 0xb4, 0x31, 0x74, 0x39, 0x4d,
+	0xc7, 0x68, 0x27, 0xb9, 0xda, 0xef, 0x0f, 0x37, 0x8f, 0xa6, 0xa3, 0x54, 0x7e, 0xf0, 0xbe, 0xaf,
+	0x10, 0xb5, 0xf1, 0xea, 0xc3, 0x08, 0xfa, 0x36, 0xec, 0x86, 0x59, 0x2a, 0x29, 0x4b, 0x85, 0x95,
+	0x05, 0x56, 0xed, 0xab, 0x05, 0xbf, 0xf0, 0xd2, 0x0e, 0x4b, 0xcf, 0x91, 0x17, 0x7a, 0xab, 0x00,
+	0xef, 0xf8, 0x5d, 0xc3, 0xd3, 0x53, 0xee, 0x7b, 0x70, 0x73, 0xa2, 0x37, 0xc4, 0x4c, 0x09, 0x44,
+	0x8e, 0x21, 0x3b, 0x65, 0xc8, 0x7f, 0xd8, 0xec, 0x74, 0x7a, 0xae, 0xbf, 0xc7, 0x44, 0x50, 0xf1,
+	0x74, 0x80, 0x49, 0x2e, 0x67, 0xfd, 0xf7, 0x01, 0xe6, 0xda, 0xa9, 0x2a, 0x27, 0x24, 0xe5, 0x52,
+	0x6f, 0x62, 0xc3, 0x37, 0x84, 0xaa, 0x86, 0x98, 0x46, 0xf6, 0x24, 0x51, 0x9f, 0xfd, 0xdf, 0x3a,
+	0xd0, 0x36, 0x55, 0xc7, 0xdc, 0x03, 0xd5, 0xdd, 0xa7, 0xbc, 0x07, 0xaa, 0x5b, 0x91, 0x0f, 0x57,
+	0x4f, 0x59, 0x1c, 0x07, 0x2c, 0x95, 0xc8, 0xcf, 0x69, 0x6c, 0x8b, 0xdc, 0x9a, 0x4f, 0x92, 0x3b,
+	0x0a, 0xe3, 0xc8, 0x42, 0xa8, 0x16, 0x50, 0x48, 0x4e, 0x25, 0x8e, 0x67, 0x26, 0x4c, 0xfc, 0x92,
+	0xee, 0x47, 0xd0, 0x36, 0xc5, 0x44, 0x55, 0xdd, 0x88, 0x71, 0x0c, 0xab, 0x55, 0xb7, 0x64, 0xa8,
+	0x20, 0xcd, 0x33, 0x2e, 0xed, 0xd3, 0x8f, 0xfe, 0x56, 0x16, 0xf0, 0x6c, 0x2a, 0xd1, 0xbe, 0xf4,
+	0x18, 0x42, 0xcd, 0x9c, 0x64, 0x42, 0xea, 0x7b, 0x83, 0xeb, 0xeb, 0xef, 0xfe, 0xef, 0x1c, 0xe8,
+	0x56, 0x2a, 0x83, 0x5a, 0xa9, 0x3d, 0x5a, 0xd8, 0xae, 0x09, 0x55, 0x29, 0x4c, 0x60, 0xda, 0xb3,
+	0xc2, 0x52, 0x5a, 0x8e, 0x8a, 0x7b, 0xab, 0xbc, 0x21, 0x94, 0x55, 0xb5, 0x57, 0x24, 0x77, 0xfe,
+	0x1c, 0xb4, 0xb4, 0xeb, 0xad, 0xa5, 0x5d, 0x1f, 0xfc, 0xd9, 0x81, 0x4e, 0x91, 0x9f, 0x4a, 0xe7,
+	0x90, 0x97, 0x66, 0xeb, 0x6f, 0x55, 0x40, 0x22, 0xeb, 0xcf, 0xcd, 0x36, 0xa1, 0x5c, 0x3e, 0x8f,
+	0x0e, 0x6b, 0x40, 0x2d, 0x3a, 0x8c, 0xee, 0xea, 0x53, 0x9b, 0xc4, 0x12, 0xfc, 0x65, 0x96, 0xa2,
+	0xad, 0xe2, 0x25, 0x3d, 0x0f, 0x97, 0x76, 0x25, 0x5c, 0x06, 0x1f, 0x42, 0xa7, 0x28, 0x58, 0xda,
+	0x7d, 0xfa, 0xd9, 0xc7, 0x9a, 0x61, 0xa9, 0xf9, 0x4a, 0xdb, 0xd1, 0x98, 0x95, 0xff, 0x74, 0xa0,
+	0x53, 0x94, 0x4d, 0x5b, 0xa3, 0x39, 0x0b, 0x8b, 0xa5, 0x86, 0x52, 0x7c, 0x7b, 0x64, 0x99, 0x7b,
+	0x7f, 0x5b, 0x96, 0xb1, 0x42, 0xe3, 0x71, 0xc6, 0x99, 0x9c, 0x24, 0xd6, 0xa8, 0x39, 0x43, 0x99,
+	0xc1, 0xd2, 0x90, 0x23, 0x15, 0x66, 0x67, 0x1c, 0xbf, 0xa4, 0xcd, 0x75, 0xc4, 0x8e, 0xb5, 0xcc,
+	0x58, 0x41, 0x93, 0x5d, 0xd8, 0x3a, 0xcb, 0xb5, 0x7d, 0x8e, 0xbf, 0x75, 0x96, 0x6b, 0x9a, 0x79,
+	0xdb, 0x96, 0x66, 0x9a, 0x36, 0x97, 0x18, 0x45, 0x47, 0xf5, 0xe7, 0x05, 0xf7, 0x3f, 0x3d, 0x2f,
+	0xc0, 0xc2, 0xf3, 0xc2, 0x9f, 0x1c, 0xd8, 0xad, 0x77, 0x8b, 0xf5, 0x74, 0x2c, 0xbc, 0x54, 0x71,
+	0x8c, 0x75, 0x80, 0xa1, 0x8c, 0x89, 0x52, 0xdd, 0xa3, 0x62, 0xfb, 0xe8, 0x51, 0xd2, 0xea, 0x34,
+	0x8e, 0xa9, 0x90, 0x01, 0x72, 0x9e, 0x71, 0xeb, 0x00, 0x57, 0x71, 0x74, 0xb7, 0x45, 0xde, 0x86,
+	0xee, 0x54, 0x77, 0xa1, 0x81, 0x34, 0xf7, 0x5c, 0x25, 0x0e, 0xa6, 0xf3, 0x2b, 0xe9, 0xdb, 0xd0,
+	0x15, 0x34, 0xc9, 0x63, 0x3b, 0xa1, 0x6d, 0x26, 0x18, 0x96, 0x9a, 0x30, 0xe0, 0xf0, 0xc6, 0xca,
+	0x7e, 0x9f, 0xbc, 0x00, 0x98, 0x5f, 0xe8, 0xed, 0xcb, 0xc8, 0x47, 0x1b, 0x57, 0x5f, 0xbf, 0x02,
+	0x36, 0xb8, 0x0b, 0x9d, 0x22, 0xb0, 0x89, 0x07, 0xdb, 0x02, 0x55, 0x43, 0x26, 0xac, 0xb3, 0x0a,
+	0x52, 0x39, 0x31, 0xa5, 0x69, 0x26, 0x6c, 0x99, 0x30, 0xc4, 0xfd, 0x3b, 0x3f, 0x7f, 0xcf, 0xe8,
+	0xc0, 0xb2, 0xdb, 0xfa, 0xc3, 0xfc, 0xde, 0x4a, 0x32, 0x7d, 0x24, 0xde, 0xb6, 0xda, 0xdc, 0xa6,
+	0x39, 0xbb, 0x5d, 0x68, 0xf4, 0xb2, 0xad, 0xff, 0x88, 0xba, 0xf3, 0xef, 0x01, 0x00, 0xd1, 0xe7,
+	0x33, 0xb1, 0x9f, 0x1a, 0x00, 0x00,
 }
@@ -0,0 +1,196 @@
+package v1alpha2
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// The oneof fields are generated as interfaces, which can neither be handled by controller-gen
+// nor by encoding/json used by the k8s client, so the deepcopy and json functions of the messages
+// containing oneof are implemented here.
+
+// DeepCopyInto copies the receiver, writing into out. in must be non-nil.
+func (in *SmartLimitDescriptor_HeaderMatcher) DeepCopyInto(out *SmartLimitDescriptor_HeaderMatcher) {
+	*out = *in
+	switch specifier := in.HeaderMatchSpecifier.(type) {
+	case *SmartLimitDescriptor_HeaderMatcher_RegexMatch:
+		out.HeaderMatchSpecifier = specifier.DeepCopy()
+	case *SmartLimitDescriptor_HeaderMatcher_ExactMatch:
+		out.HeaderMatchSpecifier = specifier.DeepCopy()
+	case *SmartLimitDescriptor_HeaderMatcher_PrefixMatch:
+		out.HeaderMatchSpecifier = specifier.DeepCopy()
+	case *SmartLimitDescriptor_HeaderMatcher_SuffixMatch:
+		out.HeaderMatchSpecifier = specifier.DeepCopy()
+	case *SmartLimitDescriptor_HeaderMatcher_PresentMatch:
+		out.HeaderMatchSpecifier = specifier.DeepCopy()
+	case *SmartLimitDescriptor_HeaderMatcher_RangeMatch:
+		out.HeaderMatchSpecifier = specifier.DeepCopy()
+	case *SmartLimitDescriptor_HeaderMatcher_ContainsMatch:
+		out.HeaderMatchSpecifier = specifier.DeepCopy()
+	}
+	out.XXX_NoUnkeyedLiteral = in.XXX_NoUnkeyedLiteral
+	if in.XXX_unrecognized != nil {
+		in, out := &in.XXX_unrecognized, &out.XXX_unrecognized
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy copies the receiver, creating a new SmartLimitDescriptor_HeaderMatcher.
+func (in *SmartLimitDescriptor_HeaderMatcher) DeepCopy() *SmartLimitDescriptor_HeaderMatcher {
+	if in == nil {
+		return nil
+	}
+	out := new(SmartLimitDescriptor_HeaderMatcher)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// headerMatcherJSON is the json format of SmartLimitDescriptor_HeaderMatcher,
+// the fields of header_match_specifier are flattened as before.
+type headerMatcherJSON struct {
+	Name          string                           `json:"name,omitempty"`
+	RegexMatch    *string                          `json:"regex_match,omitempty"`
+	ExactMatch    *string                          `json:"exact_match,omitempty"`
+	PrefixMatch   *string                          `json:"prefix_match,omitempty"`
+	SuffixMatch   *string                          `json:"suffix_match,omitempty"`
+	PresentMatch  *bool                            `json:"present_match,omitempty"`
+	RangeMatch    *SmartLimitDescriptor_Int64Range `json:"range_match,omitempty"`
+	ContainsMatch *string                          `json:"contains_match,omitempty"`
+	InvertMatch   bool                             `json:"invert_match,omitempty"`
+
+	// Deprecated: use exact_match with an empty string instead, it is kept to be
+	// compatible with the existing objects.
+	IsExactMatchEmpty bool `json:"is_exact_match_empty,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler
+func (in *SmartLimitDescriptor_HeaderMatcher) MarshalJSON() ([]byte, error) {
+	out := headerMatcherJSON{
+		Name:        in.Name,
+		InvertMatch: in.InvertMatch,
+	}
+	switch specifier := in.HeaderMatchSpecifier.(type) {
+	case *SmartLimitDescriptor_HeaderMatcher_RegexMatch:
+		out.RegexMatch = &specifier.RegexMatch
+	case *SmartLimitDescriptor_HeaderMatcher_ExactMatch:
+		out.ExactMatch = &specifier.ExactMatch
+	case *SmartLimitDescriptor_HeaderMatcher_PrefixMatch:
+		out.PrefixMatch = &specifier.PrefixMatch
+	case *SmartLimitDescriptor_HeaderMatcher_SuffixMatch:
+		out.SuffixMatch = &specifier.SuffixMatch
+	case *SmartLimitDescriptor_HeaderMatcher_PresentMatch:
+		out.PresentMatch = &specifier.PresentMatch
+	case *SmartLimitDescriptor_HeaderMatcher_RangeMatch:
+		out.RangeMatch = specifier.RangeMatch
+	case *SmartLimitDescriptor_HeaderMatcher_ContainsMatch:
+		out.ContainsMatch = &specifier.ContainsMatch
+	}
+	return json.Marshal(out)
+}
+
+// specifiers returns the names and values of the specifiers set, in the order of precedence
+func (raw *headerMatcherJSON) specifiers() ([]string, []isSmartLimitDescriptor_HeaderMatcher_HeaderMatchSpecifier) {
+	var names []string
+	var specifiers []isSmartLimitDescriptor_HeaderMatcher_HeaderMatchSpecifier
+	add := func(name string, specifier isSmartLimitDescriptor_HeaderMatcher_HeaderMatchSpecifier) {
+		names = append(names, name)
+		specifiers = append(specifiers, specifier)
+	}
+	if raw.RegexMatch != nil && *raw.RegexMatch != "" {
+		add("regex_match", &SmartLimitDescriptor_HeaderMatcher_RegexMatch{RegexMatch: *raw.RegexMatch})
+	}
+	if raw.ExactMatch != nil {
+		add("exact_match", &SmartLimitDescriptor_HeaderMatcher_ExactMatch{ExactMatch: *raw.ExactMatch})
+	}
+	// the legacy empty exact match is the same as an empty exact match
+	if raw.IsExactMatchEmpty && (raw.ExactMatch == nil || *raw.ExactMatch != "") {
+		add("is_exact_match_empty", &SmartLimitDescriptor_HeaderMatcher_ExactMatch{})
+	}
+	if raw.PrefixMatch != nil && *raw.PrefixMatch != "" {
+		add("prefix_match", &SmartLimitDescriptor_HeaderMatcher_PrefixMatch{PrefixMatch: *raw.PrefixMatch})
+	}
+	if raw.SuffixMatch != nil && *raw.SuffixMatch != "" {
+		add("suffix_match", &SmartLimitDescriptor_HeaderMatcher_SuffixMatch{SuffixMatch: *raw.SuffixMatch})
+	}
+	if raw.ContainsMatch != nil && *raw.ContainsMatch != "" {
+		add("contains_match", &SmartLimitDescriptor_HeaderMatcher_ContainsMatch{ContainsMatch: *raw.ContainsMatch})
+	}
+	if raw.RangeMatch != nil {
+		add("range_match", &SmartLimitDescriptor_HeaderMatcher_RangeMatch{RangeMatch: raw.RangeMatch})
+	}
+	if raw.PresentMatch != nil {
+		add("present_match", &SmartLimitDescriptor_HeaderMatcher_PresentMatch{PresentMatch: *raw.PresentMatch})
+	}
+	return names, specifiers
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+// If more than one specifier is set, the first one in the order of
+// regex, exact, prefix, suffix, contains, range and present is used.
+// Such matchers are rejected by the schema of crd, but the stored ones are still
+// decoded, and they are reported by SmartLimiter.HeaderConflicts.
+func (in *SmartLimitDescriptor_HeaderMatcher) UnmarshalJSON(b []byte) error {
+	raw := headerMatcherJSON{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	*in = SmartLimitDescriptor_HeaderMatcher{
+		Name:        raw.Name,
+		InvertMatch: raw.InvertMatch,
+	}
+	if _, specifiers := raw.specifiers(); len(specifiers) > 0 {
+		in.HeaderMatchSpecifier = specifiers[0]
+	}
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, the header matchers with more than one specifier
+// are recorded in HeaderConflicts, the key is set/#index of the descriptor in spec
+func (in *SmartLimiter) UnmarshalJSON(b []byte) error {
+	type smartLimiter SmartLimiter
+	if err := json.Unmarshal(b, (*smartLimiter)(in)); err != nil {
+		return err
+	}
+	raw := struct {
+		Spec struct {
+			Sets map[string]*struct {
+				Descriptor []*struct {
+					Match []*headerMatcherJSON `json:"match"`
+				} `json:"descriptor"`
+			} `json:"sets"`
+		} `json:"spec"`
+	}{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	in.HeaderConflicts = nil
+	for set, descriptors := range raw.Spec.Sets {
+		if descriptors == nil {
+			continue
+		}
+		for i, descriptor := range descriptors.Descriptor {
+			if descriptor == nil {
+				continue
+			}
+			var conflicts []string
+			for _, match := range descriptor.Match {
+				if match == nil {
+					continue
+				}
+				if names, _ := match.specifiers(); len(names) > 1 {
+					conflicts = append(conflicts, fmt.Sprintf("header matcher %s has more than one specifier %v, %s is used",
+						match.Name, names, names[0]))
+				}
+			}
+			if len(conflicts) > 0 {
+				if in.HeaderConflicts == nil {
+					in.HeaderConflicts = make(map[string]string)
+				}
+				in.HeaderConflicts[fmt.Sprintf("%s/#%d", set, i)] = strings.Join(conflicts, "; ")
+			}
+		}
+	}
+	return nil
+}
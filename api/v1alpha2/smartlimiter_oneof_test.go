@@ -0,0 +1,164 @@
+package v1alpha2
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestHeaderMatcherRoundTrip(t *testing.T) {
+	cases := []*SmartLimitDescriptor_HeaderMatcher{
+		{Name: "regex", HeaderMatchSpecifier: &SmartLimitDescriptor_HeaderMatcher_RegexMatch{RegexMatch: "a.*"}},
+		{Name: "exact", HeaderMatchSpecifier: &SmartLimitDescriptor_HeaderMatcher_ExactMatch{ExactMatch: "a"}},
+		{Name: "exact empty", HeaderMatchSpecifier: &SmartLimitDescriptor_HeaderMatcher_ExactMatch{}},
+		{Name: "prefix", HeaderMatchSpecifier: &SmartLimitDescriptor_HeaderMatcher_PrefixMatch{PrefixMatch: "a"}},
+		{Name: "suffix", HeaderMatchSpecifier: &SmartLimitDescriptor_HeaderMatcher_SuffixMatch{SuffixMatch: "a"}},
+		{Name: "present", HeaderMatchSpecifier: &SmartLimitDescriptor_HeaderMatcher_PresentMatch{PresentMatch: true}},
+		{Name: "not present", HeaderMatchSpecifier: &SmartLimitDescriptor_HeaderMatcher_PresentMatch{}},
+		{Name: "range", HeaderMatchSpecifier: &SmartLimitDescriptor_HeaderMatcher_RangeMatch{
+			RangeMatch: &SmartLimitDescriptor_Int64Range{Start: 1, End: 10}}},
+		{Name: "contains", HeaderMatchSpecifier: &SmartLimitDescriptor_HeaderMatcher_ContainsMatch{ContainsMatch: "a"}},
+		{Name: "invert", InvertMatch: true, HeaderMatchSpecifier: &SmartLimitDescriptor_HeaderMatcher_ExactMatch{ExactMatch: "a"}},
+		{Name: "none"},
+	}
+	for _, c := range cases {
+		b, err := json.Marshal(c)
+		if err != nil {
+			t.Fatalf("%s: marshal err, %v", c.Name, err)
+		}
+		got := &SmartLimitDescriptor_HeaderMatcher{}
+		if err := json.Unmarshal(b, got); err != nil {
+			t.Fatalf("%s: unmarshal %s err, %v", c.Name, b, err)
+		}
+		if !reflect.DeepEqual(got, c) {
+			t.Errorf("%s: got %+v from %s, want %+v", c.Name, got, b, c)
+		}
+
+		copied := c.DeepCopy()
+		if !reflect.DeepEqual(copied, c) {
+			t.Errorf("%s: got copy %+v, want %+v", c.Name, copied, c)
+		}
+	}
+}
+
+func TestHeaderMatcherDeepCopyIsIndependent(t *testing.T) {
+	in := &SmartLimitDescriptor_HeaderMatcher{Name: "range", HeaderMatchSpecifier: &SmartLimitDescriptor_HeaderMatcher_RangeMatch{
+		RangeMatch: &SmartLimitDescriptor_Int64Range{Start: 1, End: 10}}}
+	out := in.DeepCopy()
+	out.GetRangeMatch().End = 20
+	if in.GetRangeMatch().End != 10 {
+		t.Errorf("the range of the copy shares memory with the original")
+	}
+
+	in = &SmartLimitDescriptor_HeaderMatcher{Name: "exact", HeaderMatchSpecifier: &SmartLimitDescriptor_HeaderMatcher_ExactMatch{ExactMatch: "a"}}
+	out = in.DeepCopy()
+	out.HeaderMatchSpecifier.(*SmartLimitDescriptor_HeaderMatcher_ExactMatch).ExactMatch = "b"
+	if in.GetExactMatch() != "a" {
+		t.Errorf("the specifier of the copy shares memory with the original")
+	}
+}
+
+func TestHeaderMatcherUnmarshal(t *testing.T) {
+	cases := []struct {
+		name    string
+		json    string
+		want    *SmartLimitDescriptor_HeaderMatcher
+		wantErr bool
+	}{
+		{
+			name: "legacy empty exact match",
+			json: `{"name":"a","is_exact_match_empty":true}`,
+			want: &SmartLimitDescriptor_HeaderMatcher{Name: "a", HeaderMatchSpecifier: &SmartLimitDescriptor_HeaderMatcher_ExactMatch{}},
+		},
+		{
+			name: "legacy empty exact match with empty exact match",
+			json: `{"name":"a","exact_match":"","is_exact_match_empty":true}`,
+			want: &SmartLimitDescriptor_HeaderMatcher{Name: "a", HeaderMatchSpecifier: &SmartLimitDescriptor_HeaderMatcher_ExactMatch{}},
+		},
+		{
+			name: "empty regex is not set",
+			json: `{"name":"a","regex_match":"","prefix_match":"b"}`,
+			want: &SmartLimitDescriptor_HeaderMatcher{Name: "a", HeaderMatchSpecifier: &SmartLimitDescriptor_HeaderMatcher_PrefixMatch{PrefixMatch: "b"}},
+		},
+		// more than one specifier is decoded by precedence, see TestSmartLimiterHeaderConflicts
+		{
+			name: "exact and prefix",
+			json: `{"name":"a","exact_match":"b","prefix_match":"c"}`,
+			want: &SmartLimitDescriptor_HeaderMatcher{Name: "a", HeaderMatchSpecifier: &SmartLimitDescriptor_HeaderMatcher_ExactMatch{ExactMatch: "b"}},
+		},
+		{
+			name: "legacy empty exact match and present",
+			json: `{"name":"a","is_exact_match_empty":true,"present_match":true}`,
+			want: &SmartLimitDescriptor_HeaderMatcher{Name: "a", HeaderMatchSpecifier: &SmartLimitDescriptor_HeaderMatcher_ExactMatch{}},
+		},
+		{
+			name: "legacy empty exact match and exact match",
+			json: `{"name":"a","exact_match":"b","is_exact_match_empty":true}`,
+			want: &SmartLimitDescriptor_HeaderMatcher{Name: "a", HeaderMatchSpecifier: &SmartLimitDescriptor_HeaderMatcher_ExactMatch{ExactMatch: "b"}},
+		},
+		{
+			name: "regex and exact",
+			json: `{"name":"a","regex_match":"b.*","exact_match":"b"}`,
+			want: &SmartLimitDescriptor_HeaderMatcher{Name: "a", HeaderMatchSpecifier: &SmartLimitDescriptor_HeaderMatcher_RegexMatch{RegexMatch: "b.*"}},
+		},
+		{
+			name: "range and contains",
+			json: `{"name":"a","range_match":{"start":1,"end":2},"contains_match":"b"}`,
+			want: &SmartLimitDescriptor_HeaderMatcher{Name: "a", HeaderMatchSpecifier: &SmartLimitDescriptor_HeaderMatcher_ContainsMatch{ContainsMatch: "b"}},
+		},
+		{
+			name:    "malformed",
+			json:    `{"name":1}`,
+			wantErr: true,
+		},
+	}
+	for _, c := range cases {
+		got := &SmartLimitDescriptor_HeaderMatcher{}
+		err := json.Unmarshal([]byte(c.json), got)
+		if (err != nil) != c.wantErr {
+			t.Errorf("%s: got err %v, want err %v", c.name, err, c.wantErr)
+			continue
+		}
+		if !c.wantErr && !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got %+v, want %+v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestSmartLimiterHeaderConflicts(t *testing.T) {
+	b := []byte(`{
+		"metadata": {"name": "reviews", "namespace": "default"},
+		"spec": {"sets": {
+			"_base": {"descriptor": [
+				{"match": [{"name": "a", "exact_match": "b"}]},
+				{"match": [{"name": "a", "exact_match": "b"}, {"name": "c", "regex_match": "d.*", "exact_match": "d"}]}
+			]},
+			"v1": {"descriptor": [{"match": [{"name": "e", "is_exact_match_empty": true, "present_match": true}]}]}
+		}}
+	}`)
+	sl := &SmartLimiter{}
+	if err := json.Unmarshal(b, sl); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"_base/#1": "header matcher c has more than one specifier [regex_match exact_match], regex_match is used",
+		"v1/#0":    "header matcher e has more than one specifier [is_exact_match_empty present_match], is_exact_match_empty is used",
+	}
+	if !reflect.DeepEqual(sl.HeaderConflicts, want) {
+		t.Errorf("got %v, want %v", sl.HeaderConflicts, want)
+	}
+	if sl.Name != "reviews" || sl.Spec.Sets["_base"].Descriptor_[1].Match[1].GetRegexMatch() != "d.*" {
+		t.Errorf("smartlimiter is not decoded, %+v", sl)
+	}
+	if copied := sl.DeepCopy(); !reflect.DeepEqual(copied.HeaderConflicts, want) {
+		t.Errorf("got copy %v, want %v", copied.HeaderConflicts, want)
+	}
+
+	// the conflicts are not kept if the object is decoded again
+	if err := json.Unmarshal([]byte(`{"metadata": {"name": "reviews"}}`), sl); err != nil {
+		t.Fatal(err)
+	}
+	if sl.HeaderConflicts != nil {
+		t.Errorf("got %v, want no conflicts", sl.HeaderConflicts)
+	}
+}
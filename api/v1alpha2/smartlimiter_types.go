@@ -29,6 +29,10 @@ type SmartLimiter struct {
 
 	Spec   SmartLimiterSpec   `json:"spec,omitempty"`
 	Status SmartLimiterStatus `json:"status,omitempty"`
+
+	// HeaderConflicts records the descriptors whose header matchers have more than one specifier when decoded,
+	// the key is set/#index of the descriptor, see SmartLimitDescriptor_HeaderMatcher.UnmarshalJSON
+	HeaderConflicts map[string]string `json:"-"`
 }
 
 // +kubebuilder:object:root=true
@@ -40,6 +44,27 @@ type SmartLimiterList struct {
 	Items           []SmartLimiter `json:"items"`
 }
 
+// +kubebuilder:object:root=true
+
+// SmartLimiterPolicy is the Schema for the smartlimiterpolicies API
+type SmartLimiterPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SmartLimiterPolicySpec   `json:"spec,omitempty"`
+	Status SmartLimiterPolicyStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// SmartLimiterPolicyList contains a list of SmartLimiterPolicy
+type SmartLimiterPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SmartLimiterPolicy `json:"items"`
+}
+
 func init() {
 	SchemeBuilder.Register(&SmartLimiter{}, &SmartLimiterList{})
+	SchemeBuilder.Register(&SmartLimiterPolicy{}, &SmartLimiterPolicyList{})
 }
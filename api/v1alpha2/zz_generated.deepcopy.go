@@ -11,6 +11,48 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BasicAuth) DeepCopyInto(out *BasicAuth) {
+	*out = *in
+	out.XXX_NoUnkeyedLiteral = in.XXX_NoUnkeyedLiteral
+	if in.XXX_unrecognized != nil {
+		in, out := &in.XXX_unrecognized, &out.XXX_unrecognized
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BasicAuth.
+func (in *BasicAuth) DeepCopy() *BasicAuth {
+	if in == nil {
+		return nil
+	}
+	out := new(BasicAuth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Damping) DeepCopyInto(out *Damping) {
+	*out = *in
+	out.XXX_NoUnkeyedLiteral = in.XXX_NoUnkeyedLiteral
+	if in.XXX_unrecognized != nil {
+		in, out := &in.XXX_unrecognized, &out.XXX_unrecognized
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Damping.
+func (in *Damping) DeepCopy() *Damping {
+	if in == nil {
+		return nil
+	}
+	out := new(Damping)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Duration) DeepCopyInto(out *Duration) {
 	*out = *in
@@ -32,6 +74,69 @@ func (in *Duration) DeepCopy() *Duration {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Fallback) DeepCopyInto(out *Fallback) {
+	*out = *in
+	out.XXX_NoUnkeyedLiteral = in.XXX_NoUnkeyedLiteral
+	if in.XXX_unrecognized != nil {
+		in, out := &in.XXX_unrecognized, &out.XXX_unrecognized
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Fallback.
+func (in *Fallback) DeepCopy() *Fallback {
+	if in == nil {
+		return nil
+	}
+	out := new(Fallback)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Feedback) DeepCopyInto(out *Feedback) {
+	*out = *in
+	out.XXX_NoUnkeyedLiteral = in.XXX_NoUnkeyedLiteral
+	if in.XXX_unrecognized != nil {
+		in, out := &in.XXX_unrecognized, &out.XXX_unrecognized
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Feedback.
+func (in *Feedback) DeepCopy() *Feedback {
+	if in == nil {
+		return nil
+	}
+	out := new(Feedback)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FeedbackStatus) DeepCopyInto(out *FeedbackStatus) {
+	*out = *in
+	out.XXX_NoUnkeyedLiteral = in.XXX_NoUnkeyedLiteral
+	if in.XXX_unrecognized != nil {
+		in, out := &in.XXX_unrecognized, &out.XXX_unrecognized
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FeedbackStatus.
+func (in *FeedbackStatus) DeepCopy() *FeedbackStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FeedbackStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Limiter) DeepCopyInto(out *Limiter) {
 	*out = *in
@@ -40,6 +145,17 @@ func (in *Limiter) DeepCopyInto(out *Limiter) {
 		*out = new(timex.Duration)
 		**out = **in
 	}
+	if in.MetricSources != nil {
+		in, out := &in.MetricSources, &out.MetricSources
+		*out = make([]*MetricSource, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(MetricSource)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
 	out.XXX_NoUnkeyedLiteral = in.XXX_NoUnkeyedLiteral
 	if in.XXX_unrecognized != nil {
 		in, out := &in.XXX_unrecognized, &out.XXX_unrecognized
@@ -58,6 +174,129 @@ func (in *Limiter) DeepCopy() *Limiter {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricSource) DeepCopyInto(out *MetricSource) {
+	*out = *in
+	if in.Stats != nil {
+		in, out := &in.Stats, &out.Stats
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(timex.Duration)
+		**out = **in
+	}
+	if in.PrometheusConfig != nil {
+		in, out := &in.PrometheusConfig, &out.PrometheusConfig
+		*out = new(PrometheusConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	out.XXX_NoUnkeyedLiteral = in.XXX_NoUnkeyedLiteral
+	if in.XXX_unrecognized != nil {
+		in, out := &in.XXX_unrecognized, &out.XXX_unrecognized
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricSource.
+func (in *MetricSource) DeepCopy() *MetricSource {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PrometheusConfig) DeepCopyInto(out *PrometheusConfig) {
+	*out = *in
+	if in.BasicAuth != nil {
+		in, out := &in.BasicAuth, &out.BasicAuth
+		*out = new(BasicAuth)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Tls != nil {
+		in, out := &in.Tls, &out.Tls
+		*out = new(TLSConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	out.XXX_NoUnkeyedLiteral = in.XXX_NoUnkeyedLiteral
+	if in.XXX_unrecognized != nil {
+		in, out := &in.XXX_unrecognized, &out.XXX_unrecognized
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrometheusConfig.
+func (in *PrometheusConfig) DeepCopy() *PrometheusConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(PrometheusConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PrometheusHandler) DeepCopyInto(out *PrometheusHandler) {
+	*out = *in
+	out.XXX_NoUnkeyedLiteral = in.XXX_NoUnkeyedLiteral
+	if in.XXX_unrecognized != nil {
+		in, out := &in.XXX_unrecognized, &out.XXX_unrecognized
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrometheusHandler.
+func (in *PrometheusHandler) DeepCopy() *PrometheusHandler {
+	if in == nil {
+		return nil
+	}
+	out := new(PrometheusHandler)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Schedule) DeepCopyInto(out *Schedule) {
+	*out = *in
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(Duration)
+		(*in).DeepCopyInto(*out)
+	}
+	out.XXX_NoUnkeyedLiteral = in.XXX_NoUnkeyedLiteral
+	if in.XXX_unrecognized != nil {
+		in, out := &in.XXX_unrecognized, &out.XXX_unrecognized
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Schedule.
+func (in *Schedule) DeepCopy() *Schedule {
+	if in == nil {
+		return nil
+	}
+	out := new(Schedule)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SmartLimitDescriptor) DeepCopyInto(out *SmartLimitDescriptor) {
 	*out = *in
@@ -82,6 +321,37 @@ func (in *SmartLimitDescriptor) DeepCopyInto(out *SmartLimitDescriptor) {
 		*out = new(SmartLimitDescriptor_Target)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Path != nil {
+		in, out := &in.Path, &out.Path
+		*out = new(SmartLimitDescriptor_PathMatcher)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Method != nil {
+		in, out := &in.Method, &out.Method
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Feedback != nil {
+		in, out := &in.Feedback, &out.Feedback
+		*out = new(Feedback)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Fallback != nil {
+		in, out := &in.Fallback, &out.Fallback
+		*out = new(Fallback)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Schedules != nil {
+		in, out := &in.Schedules, &out.Schedules
+		*out = make([]*Schedule, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(Schedule)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
 	out.XXX_NoUnkeyedLiteral = in.XXX_NoUnkeyedLiteral
 	if in.XXX_unrecognized != nil {
 		in, out := &in.XXX_unrecognized, &out.XXX_unrecognized
@@ -90,24 +360,155 @@ func (in *SmartLimitDescriptor) DeepCopyInto(out *SmartLimitDescriptor) {
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SmartLimitDescriptor.
-func (in *SmartLimitDescriptor) DeepCopy() *SmartLimitDescriptor {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SmartLimitDescriptor.
+func (in *SmartLimitDescriptor) DeepCopy() *SmartLimitDescriptor {
+	if in == nil {
+		return nil
+	}
+	out := new(SmartLimitDescriptor)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SmartLimitDescriptor_Action) DeepCopyInto(out *SmartLimitDescriptor_Action) {
+	*out = *in
+	if in.FillInterval != nil {
+		in, out := &in.FillInterval, &out.FillInterval
+		*out = new(Duration)
+		(*in).DeepCopyInto(*out)
+	}
+	out.XXX_NoUnkeyedLiteral = in.XXX_NoUnkeyedLiteral
+	if in.XXX_unrecognized != nil {
+		in, out := &in.XXX_unrecognized, &out.XXX_unrecognized
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SmartLimitDescriptor_Action.
+func (in *SmartLimitDescriptor_Action) DeepCopy() *SmartLimitDescriptor_Action {
+	if in == nil {
+		return nil
+	}
+	out := new(SmartLimitDescriptor_Action)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SmartLimitDescriptor_HeaderMatcher_ContainsMatch) DeepCopyInto(out *SmartLimitDescriptor_HeaderMatcher_ContainsMatch) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SmartLimitDescriptor_HeaderMatcher_ContainsMatch.
+func (in *SmartLimitDescriptor_HeaderMatcher_ContainsMatch) DeepCopy() *SmartLimitDescriptor_HeaderMatcher_ContainsMatch {
+	if in == nil {
+		return nil
+	}
+	out := new(SmartLimitDescriptor_HeaderMatcher_ContainsMatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SmartLimitDescriptor_HeaderMatcher_ExactMatch) DeepCopyInto(out *SmartLimitDescriptor_HeaderMatcher_ExactMatch) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SmartLimitDescriptor_HeaderMatcher_ExactMatch.
+func (in *SmartLimitDescriptor_HeaderMatcher_ExactMatch) DeepCopy() *SmartLimitDescriptor_HeaderMatcher_ExactMatch {
+	if in == nil {
+		return nil
+	}
+	out := new(SmartLimitDescriptor_HeaderMatcher_ExactMatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SmartLimitDescriptor_HeaderMatcher_PrefixMatch) DeepCopyInto(out *SmartLimitDescriptor_HeaderMatcher_PrefixMatch) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SmartLimitDescriptor_HeaderMatcher_PrefixMatch.
+func (in *SmartLimitDescriptor_HeaderMatcher_PrefixMatch) DeepCopy() *SmartLimitDescriptor_HeaderMatcher_PrefixMatch {
+	if in == nil {
+		return nil
+	}
+	out := new(SmartLimitDescriptor_HeaderMatcher_PrefixMatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SmartLimitDescriptor_HeaderMatcher_PresentMatch) DeepCopyInto(out *SmartLimitDescriptor_HeaderMatcher_PresentMatch) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SmartLimitDescriptor_HeaderMatcher_PresentMatch.
+func (in *SmartLimitDescriptor_HeaderMatcher_PresentMatch) DeepCopy() *SmartLimitDescriptor_HeaderMatcher_PresentMatch {
+	if in == nil {
+		return nil
+	}
+	out := new(SmartLimitDescriptor_HeaderMatcher_PresentMatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SmartLimitDescriptor_HeaderMatcher_RangeMatch) DeepCopyInto(out *SmartLimitDescriptor_HeaderMatcher_RangeMatch) {
+	*out = *in
+	if in.RangeMatch != nil {
+		in, out := &in.RangeMatch, &out.RangeMatch
+		*out = new(SmartLimitDescriptor_Int64Range)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SmartLimitDescriptor_HeaderMatcher_RangeMatch.
+func (in *SmartLimitDescriptor_HeaderMatcher_RangeMatch) DeepCopy() *SmartLimitDescriptor_HeaderMatcher_RangeMatch {
+	if in == nil {
+		return nil
+	}
+	out := new(SmartLimitDescriptor_HeaderMatcher_RangeMatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SmartLimitDescriptor_HeaderMatcher_RegexMatch) DeepCopyInto(out *SmartLimitDescriptor_HeaderMatcher_RegexMatch) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SmartLimitDescriptor_HeaderMatcher_RegexMatch.
+func (in *SmartLimitDescriptor_HeaderMatcher_RegexMatch) DeepCopy() *SmartLimitDescriptor_HeaderMatcher_RegexMatch {
 	if in == nil {
 		return nil
 	}
-	out := new(SmartLimitDescriptor)
+	out := new(SmartLimitDescriptor_HeaderMatcher_RegexMatch)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *SmartLimitDescriptor_Action) DeepCopyInto(out *SmartLimitDescriptor_Action) {
+func (in *SmartLimitDescriptor_HeaderMatcher_SuffixMatch) DeepCopyInto(out *SmartLimitDescriptor_HeaderMatcher_SuffixMatch) {
 	*out = *in
-	if in.FillInterval != nil {
-		in, out := &in.FillInterval, &out.FillInterval
-		*out = new(Duration)
-		(*in).DeepCopyInto(*out)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SmartLimitDescriptor_HeaderMatcher_SuffixMatch.
+func (in *SmartLimitDescriptor_HeaderMatcher_SuffixMatch) DeepCopy() *SmartLimitDescriptor_HeaderMatcher_SuffixMatch {
+	if in == nil {
+		return nil
 	}
+	out := new(SmartLimitDescriptor_HeaderMatcher_SuffixMatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SmartLimitDescriptor_Int64Range) DeepCopyInto(out *SmartLimitDescriptor_Int64Range) {
+	*out = *in
 	out.XXX_NoUnkeyedLiteral = in.XXX_NoUnkeyedLiteral
 	if in.XXX_unrecognized != nil {
 		in, out := &in.XXX_unrecognized, &out.XXX_unrecognized
@@ -116,18 +517,18 @@ func (in *SmartLimitDescriptor_Action) DeepCopyInto(out *SmartLimitDescriptor_Ac
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SmartLimitDescriptor_Action.
-func (in *SmartLimitDescriptor_Action) DeepCopy() *SmartLimitDescriptor_Action {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SmartLimitDescriptor_Int64Range.
+func (in *SmartLimitDescriptor_Int64Range) DeepCopy() *SmartLimitDescriptor_Int64Range {
 	if in == nil {
 		return nil
 	}
-	out := new(SmartLimitDescriptor_Action)
+	out := new(SmartLimitDescriptor_Int64Range)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *SmartLimitDescriptor_HeaderMatcher) DeepCopyInto(out *SmartLimitDescriptor_HeaderMatcher) {
+func (in *SmartLimitDescriptor_PathMatcher) DeepCopyInto(out *SmartLimitDescriptor_PathMatcher) {
 	*out = *in
 	out.XXX_NoUnkeyedLiteral = in.XXX_NoUnkeyedLiteral
 	if in.XXX_unrecognized != nil {
@@ -137,12 +538,12 @@ func (in *SmartLimitDescriptor_HeaderMatcher) DeepCopyInto(out *SmartLimitDescri
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SmartLimitDescriptor_HeaderMatcher.
-func (in *SmartLimitDescriptor_HeaderMatcher) DeepCopy() *SmartLimitDescriptor_HeaderMatcher {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SmartLimitDescriptor_PathMatcher.
+func (in *SmartLimitDescriptor_PathMatcher) DeepCopy() *SmartLimitDescriptor_PathMatcher {
 	if in == nil {
 		return nil
 	}
-	out := new(SmartLimitDescriptor_HeaderMatcher)
+	out := new(SmartLimitDescriptor_PathMatcher)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -150,6 +551,16 @@ func (in *SmartLimitDescriptor_HeaderMatcher) DeepCopy() *SmartLimitDescriptor_H
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SmartLimitDescriptor_Target) DeepCopyInto(out *SmartLimitDescriptor_Target) {
 	*out = *in
+	if in.Route != nil {
+		in, out := &in.Route, &out.Route
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Host != nil {
+		in, out := &in.Host, &out.Host
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	out.XXX_NoUnkeyedLiteral = in.XXX_NoUnkeyedLiteral
 	if in.XXX_unrecognized != nil {
 		in, out := &in.XXX_unrecognized, &out.XXX_unrecognized
@@ -207,6 +618,13 @@ func (in *SmartLimiter) DeepCopyInto(out *SmartLimiter) {
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
+	if in.HeaderConflicts != nil {
+		in, out := &in.HeaderConflicts, &out.HeaderConflicts
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SmartLimiter.
@@ -259,6 +677,138 @@ func (in *SmartLimiterList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SmartLimiterPolicy) DeepCopyInto(out *SmartLimiterPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SmartLimiterPolicy.
+func (in *SmartLimiterPolicy) DeepCopy() *SmartLimiterPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(SmartLimiterPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SmartLimiterPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SmartLimiterPolicyList) DeepCopyInto(out *SmartLimiterPolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SmartLimiterPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SmartLimiterPolicyList.
+func (in *SmartLimiterPolicyList) DeepCopy() *SmartLimiterPolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(SmartLimiterPolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SmartLimiterPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SmartLimiterPolicySpec) DeepCopyInto(out *SmartLimiterPolicySpec) {
+	*out = *in
+	if in.ServiceSelector != nil {
+		in, out := &in.ServiceSelector, &out.ServiceSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ExcludeNamespaces != nil {
+		in, out := &in.ExcludeNamespaces, &out.ExcludeNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Template != nil {
+		in, out := &in.Template, &out.Template
+		*out = new(SmartLimiterSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	out.XXX_NoUnkeyedLiteral = in.XXX_NoUnkeyedLiteral
+	if in.XXX_unrecognized != nil {
+		in, out := &in.XXX_unrecognized, &out.XXX_unrecognized
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SmartLimiterPolicySpec.
+func (in *SmartLimiterPolicySpec) DeepCopy() *SmartLimiterPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SmartLimiterPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SmartLimiterPolicyStatus) DeepCopyInto(out *SmartLimiterPolicyStatus) {
+	*out = *in
+	if in.Services != nil {
+		in, out := &in.Services, &out.Services
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Collisions != nil {
+		in, out := &in.Collisions, &out.Collisions
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	out.XXX_NoUnkeyedLiteral = in.XXX_NoUnkeyedLiteral
+	if in.XXX_unrecognized != nil {
+		in, out := &in.XXX_unrecognized, &out.XXX_unrecognized
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SmartLimiterPolicyStatus.
+func (in *SmartLimiterPolicyStatus) DeepCopy() *SmartLimiterPolicyStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SmartLimiterPolicyStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SmartLimiterSpec) DeepCopyInto(out *SmartLimiterSpec) {
 	*out = *in
@@ -270,13 +820,50 @@ func (in *SmartLimiterSpec) DeepCopyInto(out *SmartLimiterSpec) {
 			if val == nil {
 				(*out)[key] = nil
 			} else {
-				in, out := &val, &outVal
+				inVal := (*in)[key]
+				in, out := &inVal, &outVal
 				*out = new(SmartLimitDescriptors)
 				(*in).DeepCopyInto(*out)
 			}
 			(*out)[key] = outVal
 		}
 	}
+	if in.Damping != nil {
+		in, out := &in.Damping, &out.Damping
+		*out = new(Damping)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MetricTtl != nil {
+		in, out := &in.MetricTtl, &out.MetricTtl
+		*out = new(Duration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PrometheusHandlers != nil {
+		in, out := &in.PrometheusHandlers, &out.PrometheusHandlers
+		*out = make(map[string]*PrometheusHandler, len(*in))
+		for key, val := range *in {
+			var outVal *PrometheusHandler
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				inVal := (*in)[key]
+				in, out := &inVal, &outVal
+				*out = new(PrometheusHandler)
+				(*in).DeepCopyInto(*out)
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.TargetRef != nil {
+		in, out := &in.TargetRef, &out.TargetRef
+		*out = new(TargetRef)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.WorkloadSelector != nil {
+		in, out := &in.WorkloadSelector, &out.WorkloadSelector
+		*out = new(WorkloadSelector)
+		(*in).DeepCopyInto(*out)
+	}
 	out.XXX_NoUnkeyedLiteral = in.XXX_NoUnkeyedLiteral
 	if in.XXX_unrecognized != nil {
 		in, out := &in.XXX_unrecognized, &out.XXX_unrecognized
@@ -306,7 +893,8 @@ func (in *SmartLimiterStatus) DeepCopyInto(out *SmartLimiterStatus) {
 			if val == nil {
 				(*out)[key] = nil
 			} else {
-				in, out := &val, &outVal
+				inVal := (*in)[key]
+				in, out := &inVal, &outVal
 				*out = new(SmartLimitDescriptors)
 				(*in).DeepCopyInto(*out)
 			}
@@ -320,6 +908,71 @@ func (in *SmartLimiterStatus) DeepCopyInto(out *SmartLimiterStatus) {
 			(*out)[key] = val
 		}
 	}
+	if in.DescriptorValues != nil {
+		in, out := &in.DescriptorValues, &out.DescriptorValues
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.FeedbackStatus != nil {
+		in, out := &in.FeedbackStatus, &out.FeedbackStatus
+		*out = make(map[string]*FeedbackStatus, len(*in))
+		for key, val := range *in {
+			var outVal *FeedbackStatus
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				inVal := (*in)[key]
+				in, out := &inVal, &outVal
+				*out = new(FeedbackStatus)
+				(*in).DeepCopyInto(*out)
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.MetricUpdateTime != nil {
+		in, out := &in.MetricUpdateTime, &out.MetricUpdateTime
+		*out = make(map[string]int64, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.StaleDescriptors != nil {
+		in, out := &in.StaleDescriptors, &out.StaleDescriptors
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Conflicts != nil {
+		in, out := &in.Conflicts, &out.Conflicts
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.InvalidDescriptors != nil {
+		in, out := &in.InvalidDescriptors, &out.InvalidDescriptors
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.MetricErrors != nil {
+		in, out := &in.MetricErrors, &out.MetricErrors
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.MatcherConflicts != nil {
+		in, out := &in.MatcherConflicts, &out.MatcherConflicts
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	out.XXX_NoUnkeyedLiteral = in.XXX_NoUnkeyedLiteral
 	if in.XXX_unrecognized != nil {
 		in, out := &in.XXX_unrecognized, &out.XXX_unrecognized
@@ -337,3 +990,73 @@ func (in *SmartLimiterStatus) DeepCopy() *SmartLimiterStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TLSConfig) DeepCopyInto(out *TLSConfig) {
+	*out = *in
+	out.XXX_NoUnkeyedLiteral = in.XXX_NoUnkeyedLiteral
+	if in.XXX_unrecognized != nil {
+		in, out := &in.XXX_unrecognized, &out.XXX_unrecognized
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TLSConfig.
+func (in *TLSConfig) DeepCopy() *TLSConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(TLSConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TargetRef) DeepCopyInto(out *TargetRef) {
+	*out = *in
+	out.XXX_NoUnkeyedLiteral = in.XXX_NoUnkeyedLiteral
+	if in.XXX_unrecognized != nil {
+		in, out := &in.XXX_unrecognized, &out.XXX_unrecognized
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TargetRef.
+func (in *TargetRef) DeepCopy() *TargetRef {
+	if in == nil {
+		return nil
+	}
+	out := new(TargetRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadSelector) DeepCopyInto(out *WorkloadSelector) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	out.XXX_NoUnkeyedLiteral = in.XXX_NoUnkeyedLiteral
+	if in.XXX_unrecognized != nil {
+		in, out := &in.XXX_unrecognized, &out.XXX_unrecognized
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadSelector.
+func (in *WorkloadSelector) DeepCopy() *WorkloadSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadSelector)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,23 @@
+package v1alpha2
+
+import (
+	"reflect"
+	"testing"
+)
+
+// the deepcopy is regenerated by controller-gen whenever the proto changes, path and method must be copied deeply
+func TestDescriptorDeepCopyPathMethod(t *testing.T) {
+	in := &SmartLimitDescriptor{
+		Path:   &SmartLimitDescriptor_PathMatcher{Template: "/orders/{id}", InvertMatch: true},
+		Method: []string{"GET", "POST"},
+	}
+	out := in.DeepCopy()
+	if !reflect.DeepEqual(in, out) {
+		t.Fatalf("got %+v, want %+v", out, in)
+	}
+	out.Path.Template = "/items"
+	out.Method[0] = "PUT"
+	if in.Path.Template != "/orders/{id}" || in.Method[0] != "GET" {
+		t.Errorf("deepcopy shares path or method with the original, %+v", in)
+	}
+}
@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"slime.io/slime/modules/limiter/model"
+)
+
+// +kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
+
+// generateBuiltinVars returns the built-in variables which can be used in templates, like
+// _base.ready_pod, _base.total_pod, _base.hpa_min, _base.hpa_max, _base.hpa_desired, _base.cpu_request,
+// _base.cpu_limit, node_count, time.hour, time.minute and time.weekday
+func generateBuiltinVars(kc *kubeCache, pods []v1.Pod, subsetsPods map[string][]string, ready map[string]struct{}, loc types.NamespacedName) map[string]string {
+	vars := make(map[string]string)
+	podMap := make(map[string]*v1.Pod, len(pods))
+	for i := range pods {
+		podMap[pods[i].Name] = &pods[i]
+	}
+
+	hpas := kc.hpaReplicas(loc.Namespace)
+	for subset, names := range subsetsPods {
+		var cpuRequest, cpuLimit int64
+		workloads := make(map[string]struct{})
+		for _, name := range names {
+			pod, ok := podMap[name]
+			if !ok {
+				continue
+			}
+			for _, c := range pod.Spec.Containers {
+				cpuRequest += c.Resources.Requests.Cpu().MilliValue()
+				cpuLimit += c.Resources.Limits.Cpu().MilliValue()
+			}
+			if workload := podWorkload(pod); workload != "" {
+				workloads[workload] = struct{}{}
+			}
+		}
+		vars[subset+"."+model.VarReadyPod] = strconv.Itoa(countReadyPods(names, ready))
+		vars[subset+"."+model.VarTotalPod] = strconv.Itoa(len(names))
+		vars[subset+"."+model.VarCpuRequest] = strconv.FormatInt(cpuRequest, 10)
+		vars[subset+"."+model.VarCpuLimit] = strconv.FormatInt(cpuLimit, 10)
+
+		var hpaMin, hpaMax, hpaDesired int32
+		found := false
+		for workload := range workloads {
+			if replicas, ok := hpas[workload]; ok {
+				found = true
+				hpaMin += replicas[0]
+				hpaMax += replicas[1]
+				hpaDesired += replicas[2]
+			}
+		}
+		if found {
+			vars[subset+"."+model.VarHpaMin] = strconv.Itoa(int(hpaMin))
+			vars[subset+"."+model.VarHpaMax] = strconv.Itoa(int(hpaMax))
+			vars[subset+"."+model.VarHpaDesired] = strconv.Itoa(int(hpaDesired))
+		}
+	}
+
+	vars[model.VarNodeCount] = strconv.Itoa(kc.nodeCount())
+
+	now := time.Now()
+	vars[model.VarTimeHour] = strconv.Itoa(now.Hour())
+	vars[model.VarTimeMinute] = strconv.Itoa(now.Minute())
+	vars[model.VarTimeWeekday] = strconv.Itoa(int(now.Weekday()))
+	return vars
+}
+
+func isPodReady(pod *v1.Pod) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == v1.PodReady {
+			return cond.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// podWorkload returns kind/name of the workload which the hpa scales, the deployment is
+// derived from the name of replicaset and the pod-template-hash label
+func podWorkload(pod *v1.Pod) string {
+	for _, owner := range pod.OwnerReferences {
+		if owner.Controller == nil || !*owner.Controller {
+			continue
+		}
+		switch owner.Kind {
+		case "ReplicaSet":
+			if hash := pod.Labels["pod-template-hash"]; hash != "" {
+				return "Deployment/" + strings.TrimSuffix(owner.Name, "-"+hash)
+			}
+			return "ReplicaSet/" + owner.Name
+		default:
+			return owner.Kind + "/" + owner.Name
+		}
+	}
+	return ""
+}
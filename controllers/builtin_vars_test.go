@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"strconv"
+	"testing"
+
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	toolscache "k8s.io/client-go/tools/cache"
+	"slime.io/slime/modules/limiter/model"
+)
+
+func TestPodWorkload(t *testing.T) {
+	controller, notController := true, false
+	pod := func(labels map[string]string, refs ...metav1.OwnerReference) *v1.Pod {
+		return &v1.Pod{ObjectMeta: metav1.ObjectMeta{Labels: labels, OwnerReferences: refs}}
+	}
+	owner := func(kind, name string, isController *bool) metav1.OwnerReference {
+		return metav1.OwnerReference{Kind: kind, Name: name, Controller: isController}
+	}
+	hash := map[string]string{"pod-template-hash": "7d9f8"}
+	cases := []struct {
+		name string
+		pod  *v1.Pod
+		want string
+	}{
+		{"deployment", pod(hash, owner("ReplicaSet", "reviews-v1-7d9f8", &controller)), "Deployment/reviews-v1"},
+		{"replicaset without hash", pod(nil, owner("ReplicaSet", "reviews-v1", &controller)), "ReplicaSet/reviews-v1"},
+		{"statefulset", pod(nil, owner("StatefulSet", "redis", &controller)), "StatefulSet/redis"},
+		{"not controller", pod(hash, owner("ReplicaSet", "reviews-v1-7d9f8", &notController)), ""},
+		{"controller is nil", pod(hash, owner("ReplicaSet", "reviews-v1-7d9f8", nil)), ""},
+		{"no owner", pod(nil), ""},
+	}
+	for _, c := range cases {
+		if got := podWorkload(c.pod); got != c.want {
+			t.Errorf("%s: got %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestIsPodReady(t *testing.T) {
+	cases := []struct {
+		name       string
+		conditions []v1.PodCondition
+		want       bool
+	}{
+		{"ready", []v1.PodCondition{{Type: v1.PodScheduled, Status: v1.ConditionTrue}, {Type: v1.PodReady, Status: v1.ConditionTrue}}, true},
+		{"not ready", []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionFalse}}, false},
+		{"no ready condition", []v1.PodCondition{{Type: v1.PodScheduled, Status: v1.ConditionTrue}}, false},
+	}
+	for _, c := range cases {
+		if got := isPodReady(&v1.Pod{Status: v1.PodStatus{Conditions: c.conditions}}); got != c.want {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestGenerateBuiltinVars(t *testing.T) {
+	kc, _ := newTestKubeCache()
+	nodes := toolscache.NewIndexer(toolscache.MetaNamespaceKeyFunc, toolscache.Indexers{})
+	for _, name := range []string{"n1", "n2", "n3"} {
+		if err := nodes.Add(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	kc.nodes = nodes
+	two := int32(2)
+	kc.onHpa(&autoscalingv1.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "reviews-v1"},
+		Spec: autoscalingv1.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv1.CrossVersionObjectReference{Kind: "Deployment", Name: "reviews-v1"},
+			MinReplicas:    &two,
+			MaxReplicas:    10,
+		},
+		Status: autoscalingv1.HorizontalPodAutoscalerStatus{DesiredReplicas: 3},
+	}, false)
+
+	controller := true
+	pod := func(name, workload, hash, cpuRequest, cpuLimit string) v1.Pod {
+		container := v1.Container{Resources: v1.ResourceRequirements{
+			Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse(cpuRequest)},
+			Limits:   v1.ResourceList{v1.ResourceCPU: resource.MustParse(cpuLimit)},
+		}}
+		return v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"pod-template-hash": hash},
+				OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: workload + "-" + hash, Controller: &controller}}},
+			Spec: v1.PodSpec{Containers: []v1.Container{container, container}},
+		}
+	}
+	pods := []v1.Pod{
+		pod("v1-a", "reviews-v1", "abc", "100m", "1"),
+		pod("v1-b", "reviews-v1", "abc", "100m", "1"),
+		pod("v2-a", "reviews-v2", "def", "250m", "500m"),
+	}
+	subsetsPods := map[string][]string{
+		"_base": {"v1-a", "v1-b", "v2-a"},
+		"v1":    {"v1-a", "v1-b"},
+		"v2":    {"v2-a", "v2-gone"},
+	}
+	ready := map[string]struct{}{"v1-a": {}, "v2-a": {}}
+
+	vars := generateBuiltinVars(kc, pods, subsetsPods, ready, types.NamespacedName{Namespace: "default", Name: "reviews"})
+	cases := []struct {
+		name string
+		want string
+	}{
+		{"_base." + model.VarReadyPod, "2"},
+		{"_base." + model.VarTotalPod, "3"},
+		// two containers in every pod
+		{"_base." + model.VarCpuRequest, "900"},
+		{"_base." + model.VarCpuLimit, "5000"},
+		{"_base." + model.VarHpaMin, "2"},
+		{"_base." + model.VarHpaMax, "10"},
+		{"_base." + model.VarHpaDesired, "3"},
+		{"v1." + model.VarReadyPod, "1"},
+		{"v1." + model.VarCpuRequest, "400"},
+		{"v1." + model.VarHpaDesired, "3"},
+		// the pods not found are counted, but have no resources
+		{"v2." + model.VarTotalPod, "2"},
+		{"v2." + model.VarCpuRequest, "500"},
+		{"v2." + model.VarCpuLimit, "1000"},
+		// no hpa scales reviews-v2
+		{"v2." + model.VarHpaMin, ""},
+		{model.VarNodeCount, "3"},
+	}
+	for _, c := range cases {
+		if got := vars[c.name]; got != c.want {
+			t.Errorf("%s: got %q, want %q", c.name, got, c.want)
+		}
+	}
+	for name, max := range map[string]int{model.VarTimeHour: 23, model.VarTimeMinute: 59, model.VarTimeWeekday: 6} {
+		if v, err := strconv.Atoi(vars[name]); err != nil || v < 0 || v > max {
+			t.Errorf("%s: got %q, want in [0, %d]", name, vars[name], max)
+		}
+	}
+}
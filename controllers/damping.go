@@ -0,0 +1,156 @@
+package controllers
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	microservicev1alpha2 "slime.io/slime/modules/limiter/api/v1alpha2"
+)
+
+// quotaState records the damping state of a descriptor between refreshes
+type quotaState struct {
+	smoothed float64
+	quota    int
+	// the calculated quota and the time of metric sample of the latest step
+	input  int
+	sample time.Time
+}
+
+// quotaStates records the states of descriptors in a SmartLimiter between refreshes, the key is set/name or set/#index
+type quotaStates struct {
+	sync.Mutex
+	damping  map[string]*quotaState
+	feedback map[string]*microservicev1alpha2.FeedbackStatus
+	// the key is the metric name
+	metricTime map[string]time.Time
+	// descriptors referencing stale metrics in the latest refresh, the value is the fallback policy
+	stale map[string]string
+	// descriptors ignored in the latest refresh, the key is set/#index and the value is the reason
+	invalid map[string]string
+	// prometheus handlers failed in the latest query, the value is the error
+	queryErrors map[string]string
+	// local limits merged with other SmartLimiters in the latest refresh
+	merge *routeMerge
+	// the calculated local descriptors of the routes merged into other SmartLimiters and the owners of the routes
+	mergedInto  map[string][]*microservicev1alpha2.SmartLimitDescriptor
+	mergedOwner map[string]types.NamespacedName
+	// the EnvoyFilters generated before the ownership labels are added are collected
+	legacyCollected bool
+	// serializes the refreshes triggered by metrics, schedules and the merged SmartLimiters
+	refreshing sync.Mutex
+}
+
+// getQuotaStates returns the quotaStates of the SmartLimiter, and creates it if not exist
+func (r *SmartLimiterReconciler) getQuotaStates(loc types.NamespacedName) *quotaStates {
+	i := r.quotaStates.Upsert(loc.Namespace+"/"+loc.Name, nil, func(exist bool, valueInMap interface{}, newValue interface{}) interface{} {
+		if exist {
+			return valueInMap
+		}
+		return &quotaStates{
+			damping:    make(map[string]*quotaState),
+			feedback:   make(map[string]*microservicev1alpha2.FeedbackStatus),
+			metricTime: make(map[string]time.Time),
+			stale:      make(map[string]string),
+		}
+	})
+	return i.(*quotaStates)
+}
+
+func descriptorKey(set string, index int, descriptor *microservicev1alpha2.SmartLimitDescriptor) string {
+	if descriptor.Name != "" {
+		return fmt.Sprintf("%s/%s", set, descriptor.Name)
+	}
+	return descriptorIndexKey(set, index)
+}
+
+func descriptorIndexKey(set string, index int) string {
+	return fmt.Sprintf("%s/#%d", set, index)
+}
+
+// dampQuota applies the damping of SmartLimiter to the calculated quota, and returns the quota to be applied.
+// the damping steps only if there is a new metric sample or the calculated quota is changed, e.g. by the spec or
+// schedules, so it does not depend on how many times the SmartLimiter is refreshed
+func (r *SmartLimiterReconciler) dampQuota(loc types.NamespacedName, key string, quota int, damping *microservicev1alpha2.Damping, sample time.Time) int {
+	if damping == nil {
+		return quota
+	}
+	qs := r.getQuotaStates(loc)
+	qs.Lock()
+	defer qs.Unlock()
+
+	state, ok := qs.damping[key]
+	if !ok {
+		state = &quotaState{smoothed: float64(quota), quota: clampQuota(quota, damping), input: quota, sample: sample}
+		qs.damping[key] = state
+		return state.quota
+	}
+	if quota == state.input && sample.Equal(state.sample) {
+		return clampQuota(state.quota, damping)
+	}
+	state.quota = calculateDampedQuota(state, quota, damping)
+	state.input, state.sample = quota, sample
+	return state.quota
+}
+
+// validateDamping rejects the damping which can not be applied
+func validateDamping(damping *microservicev1alpha2.Damping) error {
+	if damping == nil {
+		return nil
+	}
+	switch {
+	case damping.EwmaAlpha < 0 || damping.EwmaAlpha > 1:
+		return fmt.Errorf("invalid damping, ewma_alpha %v is not in (0, 1]", damping.EwmaAlpha)
+	case damping.Hysteresis < 0:
+		return fmt.Errorf("invalid damping, hysteresis %v is negative", damping.Hysteresis)
+	case damping.MaxStep < 0:
+		return fmt.Errorf("invalid damping, max_step %v is negative", damping.MaxStep)
+	case damping.MinQuota < 0 || damping.MaxQuota < 0:
+		return fmt.Errorf("invalid damping, min_quota %d or max_quota %d is negative", damping.MinQuota, damping.MaxQuota)
+	case damping.MaxQuota > 0 && damping.MinQuota > damping.MaxQuota:
+		return fmt.Errorf("invalid damping, min_quota %d is larger than max_quota %d", damping.MinQuota, damping.MaxQuota)
+	case damping.Hysteresis > 0 && damping.MaxStep > 0 && damping.MaxStep <= damping.Hysteresis:
+		return fmt.Errorf("invalid damping, max_step %v should be larger than hysteresis %v", damping.MaxStep, damping.Hysteresis)
+	}
+	return nil
+}
+
+func calculateDampedQuota(state *quotaState, quota int, damping *microservicev1alpha2.Damping) int {
+	// ewma
+	if alpha := damping.EwmaAlpha; alpha > 0 {
+		state.smoothed = alpha*float64(quota) + (1-alpha)*state.smoothed
+	} else {
+		state.smoothed = float64(quota)
+	}
+	last := float64(state.quota)
+	target := state.smoothed
+	delta := target - last
+
+	// hysteresis
+	if damping.Hysteresis > 0 && math.Abs(delta) <= damping.Hysteresis*math.Abs(last) {
+		return clampQuota(state.quota, damping)
+	}
+
+	// max step
+	if damping.MaxStep > 0 {
+		maxDelta := math.Max(1, damping.MaxStep*math.Abs(last))
+		if delta > maxDelta {
+			target = last + maxDelta
+		} else if delta < -maxDelta {
+			target = last - maxDelta
+		}
+	}
+	return clampQuota(int(math.Round(target)), damping)
+}
+
+func clampQuota(quota int, damping *microservicev1alpha2.Damping) int {
+	if damping.MinQuota > 0 && int64(quota) < damping.MinQuota {
+		quota = int(damping.MinQuota)
+	}
+	if damping.MaxQuota > 0 && int64(quota) > damping.MaxQuota {
+		quota = int(damping.MaxQuota)
+	}
+	return quota
+}
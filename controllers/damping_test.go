@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	cmap "github.com/orcaman/concurrent-map"
+	"k8s.io/apimachinery/pkg/types"
+	microservicev1alpha2 "slime.io/slime/modules/limiter/api/v1alpha2"
+)
+
+func TestCalculateDampedQuota(t *testing.T) {
+	cases := []struct {
+		name         string
+		last         int
+		quota        int
+		damping      *microservicev1alpha2.Damping
+		want         int
+		wantSmoothed float64
+	}{
+		{"no damping", 100, 200, &microservicev1alpha2.Damping{}, 200, 200},
+		{"ewma", 100, 200, &microservicev1alpha2.Damping{EwmaAlpha: 0.5}, 150, 150},
+		{"ewma alpha 1", 100, 200, &microservicev1alpha2.Damping{EwmaAlpha: 1}, 200, 200},
+		{"within hysteresis", 100, 105, &microservicev1alpha2.Damping{Hysteresis: 0.1}, 100, 105},
+		{"beyond hysteresis", 100, 120, &microservicev1alpha2.Damping{Hysteresis: 0.1}, 120, 120},
+		{"max step up", 100, 200, &microservicev1alpha2.Damping{MaxStep: 0.2}, 120, 200},
+		{"max step down", 100, 10, &microservicev1alpha2.Damping{MaxStep: 0.2}, 80, 10},
+		{"max step at least 1", 2, 10, &microservicev1alpha2.Damping{MaxStep: 0.1}, 3, 10},
+		{"clamp min", 100, 10, &microservicev1alpha2.Damping{MinQuota: 50}, 50, 10},
+		{"clamp max", 100, 300, &microservicev1alpha2.Damping{MaxQuota: 150}, 150, 300},
+		{"ewma and max step", 100, 300, &microservicev1alpha2.Damping{EwmaAlpha: 0.5, MaxStep: 0.5}, 150, 200},
+	}
+	for _, c := range cases {
+		state := &quotaState{smoothed: float64(c.last), quota: c.last}
+		if got := calculateDampedQuota(state, c.quota, c.damping); got != c.want {
+			t.Errorf("%s: got %d, want %d", c.name, got, c.want)
+		}
+		if state.smoothed != c.wantSmoothed {
+			t.Errorf("%s: got smoothed %v, want %v", c.name, state.smoothed, c.wantSmoothed)
+		}
+	}
+}
+
+func TestDampQuotaStepsOncePerSample(t *testing.T) {
+	r := &SmartLimiterReconciler{quotaStates: cmap.New()}
+	loc := types.NamespacedName{Namespace: "default", Name: "reviews"}
+	damping := &microservicev1alpha2.Damping{MaxStep: 0.5}
+	t0 := time.Unix(1000, 0)
+
+	steps := []struct {
+		quota  int
+		sample time.Time
+		want   int
+	}{
+		{100, t0, 100},
+		{400, t0.Add(time.Second), 150},
+		// refreshed by events without a new sample
+		{400, t0.Add(time.Second), 150},
+		{400, t0.Add(time.Second), 150},
+		{400, t0.Add(2 * time.Second), 225},
+		// the calculated quota is changed by the spec without a new sample
+		{100, t0.Add(2 * time.Second), 113},
+	}
+	for i, s := range steps {
+		if got := r.dampQuota(loc, "_base/#0", s.quota, damping, s.sample); got != s.want {
+			t.Errorf("step %d: got %d, want %d", i, got, s.want)
+		}
+	}
+
+	if got := r.dampQuota(loc, "_base/#1", 100, nil, t0); got != 100 {
+		t.Errorf("got %d without damping, want 100", got)
+	}
+}
+
+func TestValidateDamping(t *testing.T) {
+	cases := []struct {
+		damping *microservicev1alpha2.Damping
+		valid   bool
+	}{
+		{nil, true},
+		{&microservicev1alpha2.Damping{}, true},
+		{&microservicev1alpha2.Damping{EwmaAlpha: 1, Hysteresis: 0.1, MaxStep: 0.2, MinQuota: 1, MaxQuota: 100}, true},
+		{&microservicev1alpha2.Damping{EwmaAlpha: -0.1}, false},
+		{&microservicev1alpha2.Damping{EwmaAlpha: 1.5}, false},
+		{&microservicev1alpha2.Damping{Hysteresis: -0.1}, false},
+		{&microservicev1alpha2.Damping{MaxStep: -0.1}, false},
+		{&microservicev1alpha2.Damping{MinQuota: -1}, false},
+		{&microservicev1alpha2.Damping{MinQuota: 100, MaxQuota: 10}, false},
+		{&microservicev1alpha2.Damping{Hysteresis: 0.2, MaxStep: 0.1}, false},
+	}
+	for _, c := range cases {
+		if err := validateDamping(c.damping); (err == nil) != c.valid {
+			t.Errorf("damping %+v: got err %v, want valid %v", c.damping, err, c.valid)
+		}
+	}
+}
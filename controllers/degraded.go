@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// degradedGauge is 1 if limiter runs in degraded mode for the reason, it is exposed by the metrics
+// endpoint of the manager
+var degradedGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "slime_limiter_degraded",
+	Help: "Whether limiter runs in degraded mode, labeled by the reason",
+}, []string{"reason"})
+
+func init() {
+	metrics.Registry.MustRegister(degradedGauge)
+}
+
+// setDegraded records the degraded mode and disables the adaptive limits, the message is reported in status of SmartLimiters
+func (r *SmartLimiterReconciler) setDegraded(reason, message string) {
+	r.degradedLock.Lock()
+	defer r.degradedLock.Unlock()
+	r.degraded = message
+	r.adaptive = false
+	degradedGauge.WithLabelValues(reason).Set(1)
+}
+
+// clearDegraded records that limiter does not run in degraded mode for the reason
+func (r *SmartLimiterReconciler) clearDegraded(reason string) {
+	r.degradedLock.Lock()
+	defer r.degradedLock.Unlock()
+	r.degraded = ""
+	degradedGauge.WithLabelValues(reason).Set(0)
+}
+
+// degradedMessage returns the reason why limiter runs in degraded mode, it is empty if not degraded
+func (r *SmartLimiterReconciler) degradedMessage() string {
+	r.degradedLock.RLock()
+	defer r.degradedLock.RUnlock()
+	return r.degraded
+}
+
+// adaptiveEnabled returns whether the adaptive limits are enabled, it is false if limiter runs in degraded mode
+func (r *SmartLimiterReconciler) adaptiveEnabled() bool {
+	r.degradedLock.RLock()
+	defer r.degradedLock.RUnlock()
+	return r.adaptive
+}
@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"slime.io/slime/modules/limiter/model"
+)
+
+func TestDegraded(t *testing.T) {
+	r := &SmartLimiterReconciler{adaptive: true}
+	gauge := degradedGauge.WithLabelValues(model.DegradedReasonMetricSource)
+
+	r.clearDegraded(model.DegradedReasonMetricSource)
+	if got := testutil.ToFloat64(gauge); got != 0 {
+		t.Errorf("got gauge %v, want 0", got)
+	}
+	if r.degradedMessage() != "" || !r.adaptiveEnabled() {
+		t.Errorf("limiter should not be degraded")
+	}
+
+	r.setDegraded(model.DegradedReasonMetricSource, "empty prometheus config")
+	if got := testutil.ToFloat64(gauge); got != 1 {
+		t.Errorf("got gauge %v, want 1", got)
+	}
+	if got := r.degradedMessage(); got != "empty prometheus config" {
+		t.Errorf("got message %q", got)
+	}
+	if r.adaptiveEnabled() {
+		t.Errorf("adaptive limits should be disabled in degraded mode")
+	}
+
+	r.clearDegraded(model.DegradedReasonMetricSource)
+	if got := testutil.ToFloat64(gauge); got != 0 {
+		t.Errorf("got gauge %v, want 0 after recovery", got)
+	}
+}
+
+func TestDegradedConcurrent(t *testing.T) {
+	r := &SmartLimiterReconciler{}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			r.setDegraded(model.DegradedReasonMetricSource, "degraded")
+		}()
+		go func() {
+			defer wg.Done()
+			_ = r.degradedMessage()
+			_ = r.adaptiveEnabled()
+		}()
+	}
+	wg.Wait()
+}
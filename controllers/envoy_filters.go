@@ -1,11 +1,10 @@
 package controllers
 
 import (
-	"context"
 	"fmt"
+	"time"
 
 	networking "istio.io/api/networking/v1alpha3"
-	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 	"slime.io/slime/framework/controllers"
@@ -23,28 +22,61 @@ func (r *SmartLimiterReconciler) GenerateEnvoyConfigs(spec microservicev1alpha2.
 	setsSmartLimitDescriptor := make(map[string]*microservicev1alpha2.SmartLimitDescriptors)
 	// global descriptors
 	globalDescriptors := make([]*model.Descriptor, 0)
-	host := util.UnityHost(loc.Name, loc.Namespace)
 	rls := spec.Rls
+	target, err := resolveTarget(spec, loc)
+	if err != nil {
+		log.Errorf("%+v", err.Error())
+		return setsEnvoyFilter, setsSmartLimitDescriptor, globalDescriptors, err
+	}
+	host := target.host()
+	scope := target.valueScope(loc)
 
-	// get destinationrule subset of the host
+	// get destinationrule subset of the host, there is no subset if the workloads are selected by labels
 	var sets []*networking.Subset
-	if controllers.HostSubsetMapping.Get(host) != nil {
+	if host != "" && controllers.HostSubsetMapping.Get(host) != nil {
 		sets = controllers.HostSubsetMapping.Get(host).([]*networking.Subset)
 	} else {
 		sets = make([]*networking.Subset, 0, 1)
 	}
 	sets = append(sets, &networking.Subset{Name: util.Wellkonw_BaseSet})
 
-	svc := &v1.Service{}
-	if err := r.Client.Get(context.TODO(), loc, svc); err != nil {
+	services, err := target.services(r.kubeCache)
+	if err != nil {
 		if errors.IsNotFound(err) {
-			log.Errorf("svc %s:%s is not found", loc.Name, loc.Namespace)
+			log.Errorf("svc %s:%s is not found", target.service, target.namespace)
 		} else {
-			log.Errorf("get svc %s:%s err: %+v", loc.Name, loc.Namespace, err.Error())
+			log.Errorf("get svc %s:%s err: %+v", target.service, target.namespace, err.Error())
 		}
 		return setsEnvoyFilter, setsSmartLimitDescriptor, globalDescriptors, err
 	}
-	svcSelector := svc.Spec.Selector
+	svcSelector := target.labels
+	tcpPorts := make(map[uint32]uint32)
+	unsupportedPorts := make(map[uint32]string)
+	for _, svc := range services {
+		if target.service != "" {
+			svcSelector = svc.Spec.Selector
+		}
+		// the endpoints resolve the named target ports, it is fine to be absent if there are no named ones
+		ep, _ := r.kubeCache.endpoints.Endpoints(svc.Namespace).Get(svc.Name)
+		ports, unsupported := generateTcpPorts(svc, r.kubeCache.appProtocols.get(svc), ep)
+		for port, targetPort := range ports {
+			tcpPorts[port] = targetPort
+		}
+		for port, reason := range unsupported {
+			unsupportedPorts[port] = reason
+		}
+	}
+	staleness := r.newStalenessChecker(loc, spec.MetricTtl)
+	merge := r.mergeRoutes(spec, loc, target)
+	invalid := invalidDescriptors(spec.Sets)
+	for k, v := range tcpLocalConflicts(spec.Sets, tcpPorts) {
+		invalid[k] = v
+	}
+	for k, v := range unsupportedTcpDescriptors(spec.Sets, unsupportedPorts) {
+		invalid[k] = v
+	}
+	r.recordInvalidDescriptors(loc, invalid)
+	now := time.Now()
 
 	for _, set := range sets {
 		if setDescriptor, ok := spec.Sets[set.Name]; !ok {
@@ -53,7 +85,27 @@ func (r *SmartLimiterReconciler) GenerateEnvoyConfigs(spec microservicev1alpha2.
 			setsEnvoyFilter[set.Name] = nil
 		} else {
 			validDescriptor := &microservicev1alpha2.SmartLimitDescriptors{}
-			for _, des := range setDescriptor.Descriptor_ {
+			for i, des := range setDescriptor.Descriptor_ {
+				if reason, ok := invalid[descriptorIndexKey(set.Name, i)]; ok {
+					log.Errorf("descriptor %s in %s is invalid, %s", descriptorIndexKey(set.Name, i), loc, reason)
+					continue
+				}
+				key := descriptorKey(set.Name, i, des)
+				if staleness.isStale(des) {
+					policy := fallbackPolicy(des)
+					staleness.stale[key] = policy
+					switch policy {
+					case model.FallbackDisable:
+						log.Infof("metrics of descriptor %s in %s are stale, disable it", key, loc)
+						continue
+					case model.FallbackFixed:
+						log.Infof("metrics of descriptor %s in %s are stale, use fixed quota %d", key, loc, des.Fallback.Quota)
+						if des.Action != nil {
+							validDescriptor.Descriptor_ = append(validDescriptor.Descriptor_, generateValidDescriptor(des, int(des.Fallback.Quota)))
+						}
+						continue
+					}
+				}
 				// update the EnvoyFilter when condition value is true after calculate
 				if shouldUpdate, err := util.CalculateTemplateBool(des.Condition, materialInterface); err != nil {
 					log.Errorf("calaulate %s condition err, %+v", des.Condition, err.Error())
@@ -63,19 +115,17 @@ func (r *SmartLimiterReconciler) GenerateEnvoyConfigs(spec microservicev1alpha2.
 				} else {
 					// update
 					if des.Action != nil {
-						if rateLimitValue, err := util.CalculateTemplate(des.Action.Quota, materialInterface); err != nil {
-							log.Errorf("calculate quota %s err, %+v", des.Action.Quota, err.Error())
+						quota := scheduledQuota(des, now)
+						if rateLimitValue, err := util.CalculateTemplate(quota, materialInterface); err != nil {
+							log.Errorf("calculate quota %s err, %+v", quota, err.Error())
 						} else {
+							if des.Feedback != nil {
+								rateLimitValue = r.feedbackQuota(loc, key, rateLimitValue, des.Feedback, material,
+									staleness.sampleTime(des.Feedback.Metric))
+							}
+							rateLimitValue = r.dampQuota(loc, key, rateLimitValue, spec.Damping, staleness.latestSampleTime(des))
 							// log.Infof("after calculate, the quota %s is %d",des.Action.Quota,rateLimitValue)
-							validDescriptor.Descriptor_ = append(validDescriptor.Descriptor_, &microservicev1alpha2.SmartLimitDescriptor{
-								Action: &microservicev1alpha2.SmartLimitDescriptor_Action{
-									Quota:        fmt.Sprintf("%d", rateLimitValue),
-									FillInterval: des.Action.FillInterval,
-									Strategy:     des.Action.Strategy,
-								},
-								Match:  des.Match,
-								Target: des.Target,
-							})
+							validDescriptor.Descriptor_ = append(validDescriptor.Descriptor_, generateValidDescriptor(des, rateLimitValue))
 						}
 					}
 				}
@@ -90,19 +140,39 @@ func (r *SmartLimiterReconciler) GenerateEnvoyConfigs(spec microservicev1alpha2.
 				for k, v := range set.Labels {
 					selector[k] = v
 				}
-				ef := descriptorsToEnvoyFilter(validDescriptor.Descriptor_, selector, loc, rls)
+				ef := descriptorsToEnvoyFilter(validDescriptor.Descriptor_, selector, scope, rls, tcpPorts, merge)
 				setsEnvoyFilter[set.Name] = ef
 				setsSmartLimitDescriptor[set.Name] = validDescriptor
 
-				desc := descriptorsToGlobalRateLimit(validDescriptor.Descriptor_, loc)
+				desc := descriptorsToGlobalRateLimit(validDescriptor.Descriptor_, scope)
 				globalDescriptors = append(globalDescriptors, desc...)
 			}
 		}
 	}
+	r.setStaleDescriptors(loc, staleness.stale)
+	r.setRouteMerge(loc, merge)
 	return setsEnvoyFilter, setsSmartLimitDescriptor, globalDescriptors, nil
 }
 
-func descriptorsToEnvoyFilter(descriptors []*microservicev1alpha2.SmartLimitDescriptor, labels map[string]string, loc types.NamespacedName, rls string) *networking.EnvoyFilter {
+// generateValidDescriptor copies the descriptor with the calculated quota
+func generateValidDescriptor(des *microservicev1alpha2.SmartLimitDescriptor, quota int) *microservicev1alpha2.SmartLimitDescriptor {
+	return &microservicev1alpha2.SmartLimitDescriptor{
+		Action: &microservicev1alpha2.SmartLimitDescriptor_Action{
+			Quota:        fmt.Sprintf("%d", quota),
+			FillInterval: des.Action.FillInterval,
+			Strategy:     des.Action.Strategy,
+		},
+		Match:       des.Match,
+		Target:      des.Target,
+		Path:        des.Path,
+		Method:      des.Method,
+		CustomKey:   des.CustomKey,
+		CustomValue: des.CustomValue,
+		Name:        des.Name,
+	}
+}
+
+func descriptorsToEnvoyFilter(descriptors []*microservicev1alpha2.SmartLimitDescriptor, labels map[string]string, scope valueScope, rls string, tcpPorts map[uint32]uint32, merge *routeMerge) *networking.EnvoyFilter {
 	ef := &networking.EnvoyFilter{
 		WorkloadSelector: &networking.WorkloadSelector{
 			Labels: labels,
@@ -111,20 +181,36 @@ func descriptorsToEnvoyFilter(descriptors []*microservicev1alpha2.SmartLimitDesc
 	ef.ConfigPatches = make([]*networking.EnvoyFilter_EnvoyConfigObjectPatch, 0)
 	globalDescriptors := make([]*microservicev1alpha2.SmartLimitDescriptor, 0)
 	localDescriptors := make([]*microservicev1alpha2.SmartLimitDescriptor, 0)
+	httpDescriptors := make([]*microservicev1alpha2.SmartLimitDescriptor, 0)
+	tcpGlobalDescriptors := make([]*microservicev1alpha2.SmartLimitDescriptor, 0)
+	tcpLocalDescriptors := make([]*microservicev1alpha2.SmartLimitDescriptor, 0)
 
 	// split descriptors due to different envoy plugins
 	for _, descriptor := range descriptors {
-		if descriptor.Action != nil {
+		if descriptor.Action == nil {
+			continue
+		}
+		if isTcpDescriptor(descriptor, tcpPorts) {
+			if hasHeaderMatch(descriptor) {
+				log.Infof("port %d of %s is tcp, header matchers are ignored", descriptor.Target.Port, scope.limiter)
+			}
 			if descriptor.Action.Strategy == model.GlobalSmartLimiter {
-				globalDescriptors = append(globalDescriptors, descriptor)
+				tcpGlobalDescriptors = append(tcpGlobalDescriptors, descriptor)
 			} else {
-				localDescriptors = append(localDescriptors, descriptor)
+				tcpLocalDescriptors = append(tcpLocalDescriptors, descriptor)
 			}
+			continue
+		}
+		httpDescriptors = append(httpDescriptors, descriptor)
+		if descriptor.Action.Strategy == model.GlobalSmartLimiter {
+			globalDescriptors = append(globalDescriptors, descriptor)
+		} else {
+			localDescriptors = append(localDescriptors, descriptor)
 		}
 	}
 
 	// http router
-	httpRouterPatches, err := generateHttpRouterPatch(descriptors, loc)
+	httpRouterPatches, err := generateHttpRouterPatch(httpDescriptors, scope)
 	if err != nil {
 		log.Errorf("generateHttpRouterPatch err: %+v", err.Error())
 		return nil
@@ -142,22 +228,33 @@ func descriptorsToEnvoyFilter(descriptors []*microservicev1alpha2.SmartLimitDesc
 	}
 
 	// enable and config plugin envoy.filters.http.local_ratelimit
-	if len(localDescriptors) > 0 {
+	if len(localDescriptors) > 0 || (merge != nil && len(merge.merged) > 0) {
 		httpFilterLocalRateLimitPatch := generateHttpFilterLocalRateLimitPatch()
 		ef.ConfigPatches = append(ef.ConfigPatches, httpFilterLocalRateLimitPatch)
 
-		perFilterPatch := generateLocalRateLimitPerFilterPatch(localDescriptors, loc)
+		perFilterPatch := generateLocalRateLimitPerFilterPatch(localDescriptors, scope, merge)
 		ef.ConfigPatches = append(ef.ConfigPatches, perFilterPatch...)
 	}
+
+	// config plugin envoy.filters.network.ratelimit
+	if len(tcpGlobalDescriptors) > 0 {
+		server := getRateLimiterServerCluster(rls)
+		ef.ConfigPatches = append(ef.ConfigPatches, generateNetworkRateLimitPatches(tcpGlobalDescriptors, scope, tcpPorts, server)...)
+	}
+
+	// config plugin envoy.filters.network.local_ratelimit
+	if len(tcpLocalDescriptors) > 0 {
+		ef.ConfigPatches = append(ef.ConfigPatches, generateNetworkLocalRateLimitPatches(tcpLocalDescriptors, tcpPorts)...)
+	}
 	return ef
 }
 
-func descriptorsToGlobalRateLimit(descriptors []*microservicev1alpha2.SmartLimitDescriptor, loc types.NamespacedName) []*model.Descriptor {
+func descriptorsToGlobalRateLimit(descriptors []*microservicev1alpha2.SmartLimitDescriptor, scope valueScope) []*model.Descriptor {
 	globalDescriptors := make([]*microservicev1alpha2.SmartLimitDescriptor, 0)
 	for _, descriptor := range descriptors {
 		if descriptor.Action.Strategy == model.GlobalSmartLimiter {
 			globalDescriptors = append(globalDescriptors, descriptor)
 		}
 	}
-	return generateGlobalRateLimitDescriptor(globalDescriptors, loc)
+	return generateGlobalRateLimitDescriptor(globalDescriptors, scope)
 }
@@ -100,7 +100,7 @@ func generateEnvoyHttpFilterRateLimitServicePatch(rs *structpb.Struct) *networki
 	}
 }
 
-func generateGlobalRateLimitDescriptor(descriptors []*microservicev1alpha2.SmartLimitDescriptor, loc types.NamespacedName) []*model.Descriptor {
+func generateGlobalRateLimitDescriptor(descriptors []*microservicev1alpha2.SmartLimitDescriptor, scope valueScope) []*model.Descriptor {
 	desc := make([]*model.Descriptor, 0)
 	for _, descriptor := range descriptors {
 		quota, unit, err := calculateQuotaPerUnit(descriptor)
@@ -108,18 +108,30 @@ func generateGlobalRateLimitDescriptor(descriptors []*microservicev1alpha2.Smart
 			log.Errorf("calculateQuotaPerUnit err: %+v", err)
 			return desc
 		}
+		rateLimit := &model.RateLimit{
+			RequestsPerUnit: clampUint32(quota),
+			Unit:            unit,
+		}
 		item := &model.Descriptor{
-			Value: generateDescriptorValue(descriptor, loc),
-			RateLimit: &model.RateLimit{
-				RequestsPerUnit: uint32(quota),
-				Unit:            unit,
-			},
+			Value: generateDescriptorValue(descriptor, scope),
 		}
-		if len(descriptor.Match) == 0 {
+		if !hasHeaderMatch(descriptor) {
 			item.Key = model.GenericKey
 		} else {
 			item.Key = model.HeaderValueMatch
 		}
+		// the custom key is nested in the descriptor, keep the value of the outer one identify the service
+		if hasCustomKey(descriptor) {
+			item.Descriptors = []*model.Descriptor{
+				{
+					Key:       descriptor.CustomKey,
+					Value:     descriptor.CustomValue,
+					RateLimit: rateLimit,
+				},
+			}
+		} else {
+			item.RateLimit = rateLimit
+		}
 		desc = append(desc, item)
 	}
 	return desc
@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+	microservicev1alpha2 "slime.io/slime/modules/limiter/api/v1alpha2"
+	"slime.io/slime/modules/limiter/model"
+)
+
+func TestGenerateGlobalRateLimitDescriptor(t *testing.T) {
+	scope := valueScope{
+		service: types.NamespacedName{Namespace: "default", Name: "reviews"},
+		limiter: types.NamespacedName{Namespace: "default", Name: "reviews"},
+	}
+	value := "Service[reviews.default]-User[none]-Name[a]"
+	global := func(seconds int64, key, value string, match bool) *microservicev1alpha2.SmartLimitDescriptor {
+		d := namedDescriptor("a", "10")
+		d.Action.Strategy = model.GlobalSmartLimiter
+		d.Action.FillInterval = &microservicev1alpha2.Duration{Seconds: seconds}
+		d.CustomKey, d.CustomValue = key, value
+		if match {
+			d.Method = []string{"GET"}
+		}
+		return d
+	}
+	cases := []struct {
+		name       string
+		descriptor *microservicev1alpha2.SmartLimitDescriptor
+		want       []*model.Descriptor
+	}{
+		{
+			name:       "without custom key",
+			descriptor: global(1, "", "", false),
+			want: []*model.Descriptor{{Key: model.GenericKey, Value: value,
+				RateLimit: &model.RateLimit{RequestsPerUnit: 10, Unit: "SECOND"}}},
+		},
+		{
+			name:       "custom key is nested",
+			descriptor: global(60, "tenant", "gold", false),
+			want: []*model.Descriptor{{Key: model.GenericKey, Value: value, Descriptors: []*model.Descriptor{
+				{Key: "tenant", Value: "gold", RateLimit: &model.RateLimit{RequestsPerUnit: 10, Unit: "MINUTE"}},
+			}}},
+		},
+		{
+			name:       "custom key with header match",
+			descriptor: global(60*60, "tenant", "gold", true),
+			want: []*model.Descriptor{{Key: model.HeaderValueMatch, Value: value, Descriptors: []*model.Descriptor{
+				{Key: "tenant", Value: "gold", RateLimit: &model.RateLimit{RequestsPerUnit: 10, Unit: "HOUR"}},
+			}}},
+		},
+		{
+			name:       "custom key without value",
+			descriptor: global(60*60*24, "tenant", "", false),
+			want: []*model.Descriptor{{Key: model.GenericKey, Value: value,
+				RateLimit: &model.RateLimit{RequestsPerUnit: 10, Unit: "DAY"}}},
+		},
+		{
+			name:       "fill interval is not supported by rls",
+			descriptor: global(2, "tenant", "gold", false),
+			want:       []*model.Descriptor{},
+		},
+	}
+	for _, c := range cases {
+		got := generateGlobalRateLimitDescriptor([]*microservicev1alpha2.SmartLimitDescriptor{c.descriptor}, scope)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got %+v, want %+v", c.name, got, c.want)
+		}
+	}
+}
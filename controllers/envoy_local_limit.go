@@ -8,6 +8,9 @@ package controllers
 import (
 	"fmt"
 	"hash/adler32"
+	"math"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -28,9 +31,9 @@ import (
 	"slime.io/slime/modules/limiter/model"
 )
 
-// store the vhostName/routeName and action
+// store the vhostName/routeName and actions
 type routeConfig struct {
-	action    *envoy_config_route_v3.RateLimit_Action
+	actions   []*envoy_config_route_v3.RateLimit_Action
 	routeName string
 	vhostName string
 	direction string
@@ -84,18 +87,18 @@ func generateDefaultInboundRouteConfigs(target *microservicev1alpha2.SmartLimitD
 	return rcs
 }
 
-func generateHttpRouterPatch(descriptors []*microservicev1alpha2.SmartLimitDescriptor, loc types.NamespacedName) ([]*networking.EnvoyFilter_EnvoyConfigObjectPatch, error) {
+func generateHttpRouterPatch(descriptors []*microservicev1alpha2.SmartLimitDescriptor, scope valueScope) ([]*networking.EnvoyFilter_EnvoyConfigObjectPatch, error) {
 	patches := make([]*networking.EnvoyFilter_EnvoyConfigObjectPatch, 0)
 	route2RouteConfig := make(map[string][]*routeConfig)
 
 	for _, descriptor := range descriptors {
 		rcs := generateRouteConfigs(descriptor.Target)
-		action := generateRouteRateLimitAction(descriptor, loc)
-		if action == nil {
+		actions := generateRouteRateLimitActions(descriptor, scope)
+		if len(actions) == 0 {
 			continue
 		}
 		for _, rc := range rcs {
-			rc.action = action
+			rc.actions = actions
 			vHostRouteName := genVhostRouteName(rc)
 			if _, ok := route2RouteConfig[vHostRouteName]; !ok {
 				route2RouteConfig[vHostRouteName] = []*routeConfig{rc}
@@ -108,7 +111,7 @@ func generateHttpRouterPatch(descriptors []*microservicev1alpha2.SmartLimitDescr
 	for _, rcs := range route2RouteConfig {
 		rateLimits := make([]*envoy_config_route_v3.RateLimit, 0)
 		for _, rc := range rcs {
-			rateLimits = append(rateLimits, &envoy_config_route_v3.RateLimit{Actions: []*envoy_config_route_v3.RateLimit_Action{rc.action}})
+			rateLimits = append(rateLimits, &envoy_config_route_v3.RateLimit{Actions: rc.actions})
 		}
 		route := &envoy_config_route_v3.Route{
 			Action: &envoy_config_route_v3.Route_Route{
@@ -179,7 +182,9 @@ func generateHttpFilterLocalRateLimitPatch() *networking.EnvoyFilter_EnvoyConfig
 	return patch
 }
 
-func generateLocalRateLimitPerFilterPatch(descriptors []*microservicev1alpha2.SmartLimitDescriptor, loc types.NamespacedName) []*networking.EnvoyFilter_EnvoyConfigObjectPatch {
+// generateLocalRateLimitPerFilterPatch generates the local rate limit config of each route, the routes owned by
+// other SmartLimiters are skipped, and the descriptors of other SmartLimiters merged into this one are appended
+func generateLocalRateLimitPerFilterPatch(descriptors []*microservicev1alpha2.SmartLimitDescriptor, scope valueScope, merge *routeMerge) []*networking.EnvoyFilter_EnvoyConfigObjectPatch {
 	patches := make([]*networking.EnvoyFilter_EnvoyConfigObjectPatch, 0)
 	route2Descriptors := make(map[string][]*microservicev1alpha2.SmartLimitDescriptor)
 	route2RouteConfig := make(map[string][]*routeConfig)
@@ -188,6 +193,9 @@ func generateLocalRateLimitPerFilterPatch(descriptors []*microservicev1alpha2.Sm
 		rcs := generateRouteConfigs(descriptor.Target)
 		for _, rc := range rcs {
 			vHostRouteName := genVhostRouteName(rc)
+			if merge.ownedByOthers(vHostRouteName) {
+				continue
+			}
 			if _, ok := route2Descriptors[vHostRouteName]; !ok {
 				route2Descriptors[vHostRouteName] = []*microservicev1alpha2.SmartLimitDescriptor{descriptor}
 			} else {
@@ -202,8 +210,29 @@ func generateLocalRateLimitPerFilterPatch(descriptors []*microservicev1alpha2.Sm
 		}
 	}
 
+	// the merged routes which have no valid descriptor of this SmartLimiter
+	if merge != nil {
+		for vr, merged := range merge.merged {
+			if _, ok := route2RouteConfig[vr]; ok || len(merged) == 0 || len(merged[0].descriptors) == 0 {
+				continue
+			}
+			for _, rc := range generateRouteConfigs(merged[0].descriptors[0].Target) {
+				if genVhostRouteName(rc) == vr {
+					route2RouteConfig[vr] = []*routeConfig{rc}
+					route2Descriptors[vr] = []*microservicev1alpha2.SmartLimitDescriptor{}
+				}
+			}
+		}
+	}
+
 	for vr, desc := range route2Descriptors {
-		localRateLimitDescriptors := generateLocalRateLimitDescriptors(desc, loc)
+		localRateLimitDescriptors := generateLocalRateLimitDescriptors(desc, scope)
+		for _, merged := range merge.mergedDescriptors(vr) {
+			localRateLimitDescriptors = append(localRateLimitDescriptors, generateLocalRateLimitDescriptors(merged.descriptors, merged.scope)...)
+		}
+		if len(localRateLimitDescriptors) < 1 {
+			continue
+		}
 		localRateLimit := &envoy_extensions_filters_http_local_ratelimit_v3.LocalRateLimit{
 			TokenBucket:    generateCustomTokenBucket(100000, 100000, 1),
 			Descriptors:    localRateLimitDescriptors,
@@ -213,10 +242,8 @@ func generateLocalRateLimitPerFilterPatch(descriptors []*microservicev1alpha2.Sm
 		}
 		local, err := util.MessageToStruct(localRateLimit)
 		if err != nil {
-			return nil
-		}
-		if len(desc) < 1 {
-			return nil
+			log.Errorf("convert local rate limit of route %s to struct err, %+v", vr, err)
+			continue
 		}
 		patch := &networking.EnvoyFilter_EnvoyConfigObjectPatch{
 			ApplyTo: networking.EnvoyFilter_HTTP_ROUTE,
@@ -229,60 +256,166 @@ func generateLocalRateLimitPerFilterPatch(descriptors []*microservicev1alpha2.Sm
 }
 
 /*
-// if key/value is not empty, envoyplugin is needed, we will not generate http route patch
 // 有match时，只有当header中的值与match相匹配才会进行对路由进行action限流，需要注意的是RegexMatch(name 的值是否匹配正则)与
 // PresentMatch(name是否存在)互斥
-// 这里之前打算在pb声明为oneof,但是用kubebuilder生成api的过程中无法识别相关interface{}
+// 匹配方式在pb中声明为oneof(header_match_specifier),deepcopy与json序列化见api/v1alpha2/smartlimiter_oneof.go
 */
-func generateRouteRateLimitAction(descriptor *microservicev1alpha2.SmartLimitDescriptor, loc types.NamespacedName) *envoy_config_route_v3.RateLimit_Action {
+// generateRouteRateLimitActions returns the actions of the route rate limit, the order of the actions must be
+// same with the entries generated by generateLocalRateLimitDescriptorEntries.
+// if customKey/customValue is not empty, a generic_key action with the custom key is appended after the action of the descriptor
+func generateRouteRateLimitActions(descriptor *microservicev1alpha2.SmartLimitDescriptor, scope valueScope) []*envoy_config_route_v3.RateLimit_Action {
+	actions := []*envoy_config_route_v3.RateLimit_Action{generateRouteRateLimitAction(descriptor, scope)}
+	if hasCustomKey(descriptor) {
+		actions = append(actions, &envoy_config_route_v3.RateLimit_Action{
+			ActionSpecifier: &envoy_config_route_v3.RateLimit_Action_GenericKey_{
+				GenericKey: &envoy_config_route_v3.RateLimit_Action_GenericKey{
+					DescriptorKey:   descriptor.CustomKey,
+					DescriptorValue: descriptor.CustomValue,
+				},
+			},
+		})
+	}
+	return actions
+}
+
+func hasCustomKey(descriptor *microservicev1alpha2.SmartLimitDescriptor) bool {
+	return descriptor.CustomKey != "" && descriptor.CustomValue != ""
+}
+
+func generateRouteRateLimitAction(descriptor *microservicev1alpha2.SmartLimitDescriptor, scope valueScope) *envoy_config_route_v3.RateLimit_Action {
 	action := &envoy_config_route_v3.RateLimit_Action{}
-	if descriptor.CustomKey != "" && descriptor.CustomValue != "" {
-		log.Infof("customKey/customValue is not empty, users should apply a envoyplugin with same customKey/customValue")
-		return nil
-	} else if len(descriptor.Match) == 0 {
+	if !hasHeaderMatch(descriptor) {
 		action.ActionSpecifier = &envoy_config_route_v3.RateLimit_Action_GenericKey_{
 			GenericKey: &envoy_config_route_v3.RateLimit_Action_GenericKey{
-				DescriptorValue: generateDescriptorValue(descriptor, loc),
+				DescriptorValue: generateDescriptorValue(descriptor, scope),
 			},
 		}
 	} else {
-		headers := make([]*envoy_config_route_v3.HeaderMatcher, 0)
-		for _, match := range descriptor.Match {
-			header := &envoy_config_route_v3.HeaderMatcher{}
-			header.Name = match.Name
-			header.InvertMatch = generateInvertMatch(match)
-			switch {
-			case match.RegexMatch != "":
-				header.HeaderMatchSpecifier = generateSafeRegexMatch(match)
-			case match.ExactMatch != "":
-				header.HeaderMatchSpecifier = generateExactMatch(match)
-			case match.PrefixMatch != "":
-				header.HeaderMatchSpecifier = generatePrefixMatch(match)
-			case match.SuffixMatch != "":
-				header.HeaderMatchSpecifier = generateSuffixMatch(match)
-			default:
-				if match.IsExactMatchEmpty {
-					header.HeaderMatchSpecifier = generateExactMatch(match)
-				} else {
-					header.HeaderMatchSpecifier = generatePresentMatch(match)
-				}
-			}
-			headers = append(headers, header)
-		}
 		action.ActionSpecifier = &envoy_config_route_v3.RateLimit_Action_HeaderValueMatch_{
 			HeaderValueMatch: &envoy_config_route_v3.RateLimit_Action_HeaderValueMatch{
-				DescriptorValue: generateDescriptorValue(descriptor, loc),
-				Headers:         headers,
+				DescriptorValue: generateDescriptorValue(descriptor, scope),
+				Headers:         generateHeaderMatchers(descriptor),
 			},
 		}
 	}
 	return action
 }
 
-func generateLocalRateLimitDescriptors(descriptors []*microservicev1alpha2.SmartLimitDescriptor, loc types.NamespacedName) []*envoy_ratelimit_v3.LocalRateLimitDescriptor {
+// hasHeaderMatch returns true if the descriptor should be translated into a header_value_match action,
+// path and method matchers are expressed by the pseudo-headers :path and :method
+func hasHeaderMatch(descriptor *microservicev1alpha2.SmartLimitDescriptor) bool {
+	return len(descriptor.Match) > 0 || descriptor.Path != nil || len(descriptor.Method) > 0
+}
+
+func generateHeaderMatchers(descriptor *microservicev1alpha2.SmartLimitDescriptor) []*envoy_config_route_v3.HeaderMatcher {
+	headers := make([]*envoy_config_route_v3.HeaderMatcher, 0)
+	for _, match := range descriptor.Match {
+		header := &envoy_config_route_v3.HeaderMatcher{}
+		header.Name = match.Name
+		header.InvertMatch = generateInvertMatch(match)
+		switch match.HeaderMatchSpecifier.(type) {
+		case *microservicev1alpha2.SmartLimitDescriptor_HeaderMatcher_RegexMatch:
+			header.HeaderMatchSpecifier = generateSafeRegexMatch(match)
+		case *microservicev1alpha2.SmartLimitDescriptor_HeaderMatcher_ExactMatch:
+			header.HeaderMatchSpecifier = generateExactMatch(match)
+		case *microservicev1alpha2.SmartLimitDescriptor_HeaderMatcher_PrefixMatch:
+			header.HeaderMatchSpecifier = generatePrefixMatch(match)
+		case *microservicev1alpha2.SmartLimitDescriptor_HeaderMatcher_SuffixMatch:
+			header.HeaderMatchSpecifier = generateSuffixMatch(match)
+		case *microservicev1alpha2.SmartLimitDescriptor_HeaderMatcher_RangeMatch:
+			header.HeaderMatchSpecifier = generateRangeMatch(match)
+		case *microservicev1alpha2.SmartLimitDescriptor_HeaderMatcher_ContainsMatch:
+			header.HeaderMatchSpecifier = generateContainsMatch(match)
+		default:
+			// present_match or none is specified
+			header.HeaderMatchSpecifier = generatePresentMatch(match)
+		}
+		headers = append(headers, header)
+	}
+	if descriptor.Path != nil {
+		if header := generatePathHeaderMatcher(descriptor.Path); header != nil {
+			headers = append(headers, header)
+		}
+	}
+	if len(descriptor.Method) > 0 {
+		headers = append(headers, generateMethodHeaderMatcher(descriptor.Method))
+	}
+	return headers
+}
+
+// pathSpecifiers returns the ways to match set in the path matcher, in the order of precedence
+func pathSpecifiers(path *microservicev1alpha2.SmartLimitDescriptor_PathMatcher) []string {
+	var names []string
+	for _, s := range []struct {
+		name  string
+		value string
+	}{{"exact", path.Exact}, {"prefix", path.Prefix}, {"regex", path.Regex}, {"template", path.Template}} {
+		if s.value != "" {
+			names = append(names, s.name)
+		}
+	}
+	return names
+}
+
+// the value of :path contains the query string, so exact/regex/template are translated into
+// regex which allows an optional query string. if more than one way is set, the first one in
+// the order of exact, prefix, regex and template is used, see matcherConflicts
+func generatePathHeaderMatcher(path *microservicev1alpha2.SmartLimitDescriptor_PathMatcher) *envoy_config_route_v3.HeaderMatcher {
+	header := &envoy_config_route_v3.HeaderMatcher{
+		Name:        model.HeaderPath,
+		InvertMatch: path.InvertMatch,
+	}
+	switch {
+	case path.Exact != "":
+		header.HeaderMatchSpecifier = generateSafeRegex("^" + regexp.QuoteMeta(path.Exact) + model.QueryStringRegex + "$")
+	case path.Prefix != "":
+		header.HeaderMatchSpecifier = &envoy_config_route_v3.HeaderMatcher_PrefixMatch{PrefixMatch: path.Prefix}
+	case path.Regex != "":
+		header.HeaderMatchSpecifier = generateSafeRegex("^(?:" + path.Regex + ")" + model.QueryStringRegex + "$")
+	case path.Template != "":
+		header.HeaderMatchSpecifier = generateSafeRegex("^" + pathTemplateToRegex(path.Template) + model.QueryStringRegex + "$")
+	default:
+		log.Errorf("path matcher %+v is empty, skip it", path)
+		return nil
+	}
+	return header
+}
+
+// pathTemplateToRegex converts path template like /orders/{id}/items to regex,
+// the segment {id} or * matches exactly one segment, and ** matches the rest of the path
+func pathTemplateToRegex(template string) string {
+	segments := strings.Split(template, "/")
+	for i, segment := range segments {
+		switch {
+		case segment == "**" || (strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "=**}")):
+			segments[i] = `[^?#]*`
+		case segment == "*" || (strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")):
+			segments[i] = `[^/?#]+`
+		default:
+			segments[i] = regexp.QuoteMeta(segment)
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func generateMethodHeaderMatcher(methods []string) *envoy_config_route_v3.HeaderMatcher {
+	header := &envoy_config_route_v3.HeaderMatcher{Name: model.HeaderMethod}
+	if len(methods) == 1 {
+		header.HeaderMatchSpecifier = &envoy_config_route_v3.HeaderMatcher_ExactMatch{ExactMatch: strings.ToUpper(methods[0])}
+		return header
+	}
+	quoted := make([]string, 0, len(methods))
+	for _, method := range methods {
+		quoted = append(quoted, regexp.QuoteMeta(strings.ToUpper(method)))
+	}
+	header.HeaderMatchSpecifier = generateSafeRegex("^(" + strings.Join(quoted, "|") + ")$")
+	return header
+}
+
+func generateLocalRateLimitDescriptors(descriptors []*microservicev1alpha2.SmartLimitDescriptor, scope valueScope) []*envoy_ratelimit_v3.LocalRateLimitDescriptor {
 	localRateLimitDescriptors := make([]*envoy_ratelimit_v3.LocalRateLimitDescriptor, 0)
 	for _, item := range descriptors {
-		entries := generateLocalRateLimitDescriptorEntries(item, loc)
+		entries := generateLocalRateLimitDescriptorEntries(item, scope)
 		tokenBucket := generateTokenBucket(item)
 		localRateLimitDescriptors = append(localRateLimitDescriptors, &envoy_ratelimit_v3.LocalRateLimitDescriptor{
 			Entries:     entries,
@@ -292,59 +425,239 @@ func generateLocalRateLimitDescriptors(descriptors []*microservicev1alpha2.Smart
 	return localRateLimitDescriptors
 }
 
-func generateLocalRateLimitDescriptorEntries(item *microservicev1alpha2.SmartLimitDescriptor, loc types.NamespacedName) []*envoy_ratelimit_v3.RateLimitDescriptor_Entry {
+func generateLocalRateLimitDescriptorEntries(item *microservicev1alpha2.SmartLimitDescriptor, scope valueScope) []*envoy_ratelimit_v3.RateLimitDescriptor_Entry {
 	entry := &envoy_ratelimit_v3.RateLimitDescriptor_Entry{}
-	if item.CustomKey != "" && item.CustomValue != "" {
-		entry.Key = item.CustomKey
-		entry.Value = item.CustomValue
-	} else if len(item.Match) == 0 {
+	if !hasHeaderMatch(item) {
 		entry.Key = model.GenericKey
-		entry.Value = generateDescriptorValue(item, loc)
+		entry.Value = generateDescriptorValue(item, scope)
 	} else {
 		entry.Key = model.HeaderValueMatch
-		entry.Value = generateDescriptorValue(item, loc)
+		entry.Value = generateDescriptorValue(item, scope)
 	}
-	return []*envoy_ratelimit_v3.RateLimitDescriptor_Entry{entry}
+	entries := []*envoy_ratelimit_v3.RateLimitDescriptor_Entry{entry}
+	if hasCustomKey(item) {
+		entries = append(entries, &envoy_ratelimit_v3.RateLimitDescriptor_Entry{
+			Key:   item.CustomKey,
+			Value: item.CustomValue,
+		})
+	}
+	return entries
 }
 
 func generateTokenBucket(item *microservicev1alpha2.SmartLimitDescriptor) *envoy_type_v3.TokenBucket {
 	i, _ := strconv.Atoi(item.Action.Quota)
 	return &envoy_type_v3.TokenBucket{
-		MaxTokens: uint32(i),
+		MaxTokens: clampUint32(i),
 		FillInterval: &duration.Duration{
 			Seconds: item.Action.FillInterval.Seconds,
 			Nanos:   item.Action.FillInterval.Nanos,
 		},
-		TokensPerFill: &wrappers.UInt32Value{Value: uint32(i)},
+		TokensPerFill: &wrappers.UInt32Value{Value: clampUint32(i)},
+	}
+}
+
+// clampUint32 converts the quota to uint32 of envoy without wrapping, the quota can be out of range
+// after calculated from the metrics or adjusted by feedback
+func clampUint32(i int) uint32 {
+	if i < 0 {
+		return 0
+	}
+	if uint64(i) > math.MaxUint32 {
+		return math.MaxUint32
+	}
+	return uint32(i)
+}
+
+func generateDescriptorValue(item *microservicev1alpha2.SmartLimitDescriptor, scope valueScope) string {
+	if item.Name != "" {
+		return fmt.Sprintf("%sUser[none]-Name[%s]", descriptorValuePrefix(scope), item.Name)
+	}
+	id := adler32.Checksum([]byte(descriptorIdentity(item).String() + scope.limiter.String()))
+	return fmt.Sprintf("%sUser[none]-Id[%d]", descriptorValuePrefix(scope), id)
+}
+
+// descriptorValuePrefix is the prefix of the descriptor values generated by the SmartLimiter, the SmartLimiter
+// is only added if it is not named after the service, so the values of the default one are not changed
+func descriptorValuePrefix(scope valueScope) string {
+	if scope.limiter.Name == scope.service.Name {
+		return fmt.Sprintf("Service[%s.%s]-", scope.service.Name, scope.service.Namespace)
+	}
+	return fmt.Sprintf("Service[%s.%s]-SmartLimiter[%s]-", scope.service.Name, scope.service.Namespace, scope.limiter.Name)
+}
+
+// descriptorIdentity only keeps the fields which define the bucket of the descriptor,
+// so the value is not changed when the condition or quota is changed
+func descriptorIdentity(item *microservicev1alpha2.SmartLimitDescriptor) *microservicev1alpha2.SmartLimitDescriptor {
+	identity := &microservicev1alpha2.SmartLimitDescriptor{
+		Match:       item.Match,
+		Target:      item.Target,
+		CustomKey:   item.CustomKey,
+		CustomValue: item.CustomValue,
+		Path:        item.Path,
+		Method:      item.Method,
+	}
+	if item.Action != nil {
+		identity.Action = &microservicev1alpha2.SmartLimitDescriptor_Action{
+			FillInterval: item.Action.FillInterval,
+			Strategy:     item.Action.Strategy,
+		}
+	}
+	return identity
+}
+
+// generateDescriptorValues returns the mapping from descriptor in spec to the generated value, the key is set/name,
+// or set/#index if the name is not specified. the invalid descriptors are skipped
+func generateDescriptorValues(sets map[string]*microservicev1alpha2.SmartLimitDescriptors, invalid map[string]string, scope valueScope) map[string]string {
+	values := make(map[string]string)
+	for set, desc := range sets {
+		if desc == nil {
+			continue
+		}
+		for i, item := range desc.Descriptor_ {
+			if item == nil {
+				continue
+			}
+			if _, ok := invalid[descriptorIndexKey(set, i)]; ok {
+				continue
+			}
+			values[descriptorKey(set, i, item)] = generateDescriptorValue(item, scope)
+		}
+	}
+	return values
+}
+
+// invalidDescriptors returns the descriptors whose name is used by another one in the same or other sets, since
+// they generate the same descriptor value. the first one in the order of set name and index keeps the name.
+// the descriptors with an empty path matcher are also invalid, the key is set/#index, and the value is the reason
+func invalidDescriptors(sets map[string]*microservicev1alpha2.SmartLimitDescriptors) map[string]string {
+	setNames := make([]string, 0, len(sets))
+	for set := range sets {
+		setNames = append(setNames, set)
+	}
+	sort.Strings(setNames)
+
+	invalid := make(map[string]string)
+	// key is the name, value is the key of the descriptor using it
+	names := make(map[string]string)
+	for _, set := range setNames {
+		if sets[set] == nil {
+			continue
+		}
+		for i, item := range sets[set].Descriptor_ {
+			if item == nil {
+				continue
+			}
+			if item.Path != nil && len(pathSpecifiers(item.Path)) == 0 {
+				// the header_value_match action without headers matches all the requests
+				invalid[descriptorIndexKey(set, i)] = "path matcher is empty"
+				continue
+			}
+			if item.Name == "" {
+				continue
+			}
+			if first, ok := names[item.Name]; ok {
+				invalid[descriptorIndexKey(set, i)] = fmt.Sprintf("duplicate name %s, it is used by %s", item.Name, first)
+				continue
+			}
+			names[item.Name] = descriptorIndexKey(set, i)
+		}
 	}
+	return invalid
 }
 
-func generateDescriptorValue(item *microservicev1alpha2.SmartLimitDescriptor, loc types.NamespacedName) string {
-	id := adler32.Checksum([]byte(item.String() + loc.String()))
-	return fmt.Sprintf("Service[%s.%s]-User[none]-Id[%d]", loc.Name, loc.Namespace, id)
+// matcherConflicts returns the descriptors whose matchers have more than one way to match, they are applied with the
+// one of the highest precedence as before, the key is set/#index, and the value tells which one is used
+func matcherConflicts(instance *microservicev1alpha2.SmartLimiter) map[string]string {
+	conflicts := make(map[string]string, len(instance.HeaderConflicts))
+	for k, v := range instance.HeaderConflicts {
+		conflicts[k] = v
+	}
+	for set, descriptors := range instance.Spec.Sets {
+		if descriptors == nil {
+			continue
+		}
+		for i, item := range descriptors.Descriptor_ {
+			if item == nil || item.Path == nil {
+				continue
+			}
+			names := pathSpecifiers(item.Path)
+			if len(names) <= 1 {
+				continue
+			}
+			conflict := fmt.Sprintf("path matcher has more than one way to match %v, %s is used", names, names[0])
+			if header, ok := conflicts[descriptorIndexKey(set, i)]; ok {
+				conflict = header + "; " + conflict
+			}
+			conflicts[descriptorIndexKey(set, i)] = conflict
+		}
+	}
+	if len(conflicts) == 0 {
+		return nil
+	}
+	return conflicts
+}
+
+// recordInvalidDescriptors records the descriptors ignored in the latest refresh, they are reported in status
+func (r *SmartLimiterReconciler) recordInvalidDescriptors(loc types.NamespacedName, invalid map[string]string) {
+	qs := r.getQuotaStates(loc)
+	qs.Lock()
+	defer qs.Unlock()
+	qs.invalid = invalid
+}
+
+// recordedInvalidDescriptors returns the descriptors ignored in the latest refresh
+func (r *SmartLimiterReconciler) recordedInvalidDescriptors(loc types.NamespacedName) map[string]string {
+	qs := r.getQuotaStates(loc)
+	qs.Lock()
+	defer qs.Unlock()
+	if len(qs.invalid) == 0 {
+		return nil
+	}
+	invalid := make(map[string]string, len(qs.invalid))
+	for k, v := range qs.invalid {
+		invalid[k] = v
+	}
+	return invalid
 }
 
 func generateSafeRegexMatch(match *microservicev1alpha2.SmartLimitDescriptor_HeaderMatcher) *envoy_config_route_v3.HeaderMatcher_SafeRegexMatch {
+	return generateSafeRegex(match.GetRegexMatch())
+}
+
+func generateSafeRegex(regex string) *envoy_config_route_v3.HeaderMatcher_SafeRegexMatch {
 	return &envoy_config_route_v3.HeaderMatcher_SafeRegexMatch{
 		SafeRegexMatch: &envoy_match_v3.RegexMatcher{
 			EngineType: &envoy_match_v3.RegexMatcher_GoogleRe2{
 				GoogleRe2: &envoy_match_v3.RegexMatcher_GoogleRE2{},
 			},
-			Regex: match.RegexMatch,
+			Regex: regex,
 		},
 	}
 }
 
 func generatePrefixMatch(match *microservicev1alpha2.SmartLimitDescriptor_HeaderMatcher) *envoy_config_route_v3.HeaderMatcher_PrefixMatch {
-	return &envoy_config_route_v3.HeaderMatcher_PrefixMatch{PrefixMatch: match.PrefixMatch}
+	return &envoy_config_route_v3.HeaderMatcher_PrefixMatch{PrefixMatch: match.GetPrefixMatch()}
 }
 
 func generateSuffixMatch(match *microservicev1alpha2.SmartLimitDescriptor_HeaderMatcher) *envoy_config_route_v3.HeaderMatcher_SuffixMatch {
-	return &envoy_config_route_v3.HeaderMatcher_SuffixMatch{SuffixMatch: match.SuffixMatch}
+	return &envoy_config_route_v3.HeaderMatcher_SuffixMatch{SuffixMatch: match.GetSuffixMatch()}
 }
 
 func generateExactMatch(match *microservicev1alpha2.SmartLimitDescriptor_HeaderMatcher) *envoy_config_route_v3.HeaderMatcher_ExactMatch {
-	return &envoy_config_route_v3.HeaderMatcher_ExactMatch{ExactMatch: match.ExactMatch}
+	return &envoy_config_route_v3.HeaderMatcher_ExactMatch{ExactMatch: match.GetExactMatch()}
+}
+
+func generateRangeMatch(match *microservicev1alpha2.SmartLimitDescriptor_HeaderMatcher) *envoy_config_route_v3.HeaderMatcher_RangeMatch {
+	return &envoy_config_route_v3.HeaderMatcher_RangeMatch{
+		RangeMatch: &envoy_type_v3.Int64Range{
+			Start: match.GetRangeMatch().GetStart(),
+			End:   match.GetRangeMatch().GetEnd(),
+		},
+	}
+}
+
+func generateContainsMatch(match *microservicev1alpha2.SmartLimitDescriptor_HeaderMatcher) *envoy_config_route_v3.HeaderMatcher_ContainsMatch {
+	return &envoy_config_route_v3.HeaderMatcher_ContainsMatch{ContainsMatch: match.GetContainsMatch()}
 }
 
 func generateInvertMatch(match *microservicev1alpha2.SmartLimitDescriptor_HeaderMatcher) bool {
@@ -352,17 +665,17 @@ func generateInvertMatch(match *microservicev1alpha2.SmartLimitDescriptor_Header
 }
 
 func generatePresentMatch(match *microservicev1alpha2.SmartLimitDescriptor_HeaderMatcher) *envoy_config_route_v3.HeaderMatcher_PresentMatch {
-	return &envoy_config_route_v3.HeaderMatcher_PresentMatch{PresentMatch: match.PresentMatch}
+	return &envoy_config_route_v3.HeaderMatcher_PresentMatch{PresentMatch: match.GetPresentMatch()}
 }
 
 // TODO
 func generateCustomTokenBucket(maxTokens, tokensPerFill, second int) *envoy_type_v3.TokenBucket {
 	return &envoy_type_v3.TokenBucket{
-		MaxTokens: uint32(maxTokens),
+		MaxTokens: clampUint32(maxTokens),
 		FillInterval: &duration.Duration{
 			Seconds: int64(second),
 		},
-		TokensPerFill: &wrappers.UInt32Value{Value: uint32(tokensPerFill)},
+		TokensPerFill: &wrappers.UInt32Value{Value: clampUint32(tokensPerFill)},
 	}
 }
 
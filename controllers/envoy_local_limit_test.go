@@ -0,0 +1,327 @@
+package controllers
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+
+	envoy_config_route_v3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	microservicev1alpha2 "slime.io/slime/modules/limiter/api/v1alpha2"
+	"slime.io/slime/modules/limiter/model"
+)
+
+func namedDescriptor(name, quota string) *microservicev1alpha2.SmartLimitDescriptor {
+	return &microservicev1alpha2.SmartLimitDescriptor{
+		Name:   name,
+		Action: &microservicev1alpha2.SmartLimitDescriptor_Action{Quota: quota},
+	}
+}
+
+func TestInvalidDescriptors(t *testing.T) {
+	sets := map[string]*microservicev1alpha2.SmartLimitDescriptors{
+		"_base": {Descriptor_: []*microservicev1alpha2.SmartLimitDescriptor{
+			namedDescriptor("a", "1"),
+			namedDescriptor("", "2"),
+			namedDescriptor("a", "3"),
+			nil,
+			namedDescriptor("b", "4"),
+		}},
+		"v1": {Descriptor_: []*microservicev1alpha2.SmartLimitDescriptor{
+			namedDescriptor("b", "5"),
+			namedDescriptor("", "6"),
+			namedDescriptor("c", "7"),
+			namedDescriptor("d", "8"),
+		}},
+		"v2": nil,
+	}
+	sets["v1"].Descriptor_[3].Path = &microservicev1alpha2.SmartLimitDescriptor_PathMatcher{InvertMatch: true}
+	want := map[string]string{
+		"_base/#2": "duplicate name a, it is used by _base/#0",
+		"v1/#0":    "duplicate name b, it is used by _base/#4",
+		"v1/#3":    "path matcher is empty",
+	}
+	if got := invalidDescriptors(sets); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestGenerateDescriptorValues(t *testing.T) {
+	loc := types.NamespacedName{Namespace: "default", Name: "reviews"}
+	scope := valueScope{service: loc, limiter: loc}
+	sets := map[string]*microservicev1alpha2.SmartLimitDescriptors{
+		"_base": {Descriptor_: []*microservicev1alpha2.SmartLimitDescriptor{
+			// the condition of the first one may be false, the index in spec is still used
+			namedDescriptor("", "1"),
+			namedDescriptor("", "2"),
+			namedDescriptor("a", "3"),
+			namedDescriptor("a", "4"),
+		}},
+	}
+	sets["_base"].Descriptor_[1].Method = []string{"GET"}
+	values := generateDescriptorValues(sets, invalidDescriptors(sets), scope)
+
+	want := map[string]string{
+		"_base/#0": generateDescriptorValue(sets["_base"].Descriptor_[0], scope),
+		"_base/#1": generateDescriptorValue(sets["_base"].Descriptor_[1], scope),
+		"_base/a":  "Service[reviews.default]-User[none]-Name[a]",
+	}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("got %v, want %v", values, want)
+	}
+	if values["_base/#0"] == values["_base/#1"] {
+		t.Errorf("descriptors with different identities have the same value %s", values["_base/#0"])
+	}
+}
+
+func TestGenerateDescriptorValueIgnoresQuota(t *testing.T) {
+	loc := types.NamespacedName{Namespace: "default", Name: "reviews"}
+	scope := valueScope{service: loc, limiter: loc}
+	a, b := namedDescriptor("", "1"), namedDescriptor("", "{{._base.pod}}")
+	b.Condition = "true"
+	if generateDescriptorValue(a, scope) != generateDescriptorValue(b, scope) {
+		t.Errorf("the value is changed by quota or condition")
+	}
+	other := valueScope{service: loc, limiter: types.NamespacedName{Namespace: "default", Name: "ratings"}}
+	if generateDescriptorValue(a, scope) == generateDescriptorValue(a, other) {
+		t.Errorf("the values of different SmartLimiters are the same")
+	}
+}
+
+func TestDescriptorValueOfService(t *testing.T) {
+	limiter := func(name string, target *microservicev1alpha2.TargetRef, labels map[string]string) *microservicev1alpha2.SmartLimiter {
+		instance := &microservicev1alpha2.SmartLimiter{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name}}
+		instance.Spec.TargetRef = target
+		if labels != nil {
+			instance.Spec.WorkloadSelector = &microservicev1alpha2.WorkloadSelector{Labels: labels}
+		}
+		return instance
+	}
+	reviews := &microservicev1alpha2.TargetRef{Kind: model.TargetKindService, Name: "reviews"}
+	cases := []struct {
+		name     string
+		instance *microservicev1alpha2.SmartLimiter
+		want     string
+	}{
+		{"default", limiter("reviews", nil, nil), "Service[reviews.default]-User[none]-Name[a]"},
+		{"target ref", limiter("reviews-canary", reviews, nil), "Service[reviews.default]-SmartLimiter[reviews-canary]-User[none]-Name[a]"},
+		{"workload selector", limiter("canary", nil, map[string]string{"app": "reviews"}), "Service[canary.default]-User[none]-Name[a]"},
+		{"invalid target", limiter("other", &microservicev1alpha2.TargetRef{Kind: "Deployment", Name: "reviews"}, nil), "Service[other.default]-User[none]-Name[a]"},
+	}
+	for _, c := range cases {
+		if got := generateDescriptorValue(namedDescriptor("a", "1"), limiterValueScope(c.instance)); got != c.want {
+			t.Errorf("%s: got %s, want %s", c.name, got, c.want)
+		}
+	}
+}
+
+// matchHeader evaluates the header matcher against value, the regex is fully matched as envoy does
+func matchHeader(t *testing.T, header *envoy_config_route_v3.HeaderMatcher, value string) bool {
+	t.Helper()
+	var matched bool
+	switch m := header.HeaderMatchSpecifier.(type) {
+	case *envoy_config_route_v3.HeaderMatcher_ExactMatch:
+		matched = value == m.ExactMatch
+	case *envoy_config_route_v3.HeaderMatcher_PrefixMatch:
+		matched = len(value) >= len(m.PrefixMatch) && value[:len(m.PrefixMatch)] == m.PrefixMatch
+	case *envoy_config_route_v3.HeaderMatcher_SafeRegexMatch:
+		re, err := regexp.Compile("^(?:" + m.SafeRegexMatch.Regex + ")$")
+		if err != nil {
+			t.Fatalf("invalid regex %s, %v", m.SafeRegexMatch.Regex, err)
+		}
+		matched = re.MatchString(value)
+	default:
+		t.Fatalf("unexpected header matcher %T", m)
+	}
+	return matched != header.InvertMatch
+}
+
+func TestPathTemplateToRegex(t *testing.T) {
+	cases := []struct {
+		template string
+		match    []string
+		mismatch []string
+	}{
+		{"/orders/{id}/items", []string{"/orders/1/items", "/orders/abc/items?page=2"},
+			[]string{"/orders/items", "/orders/1/2/items", "/orders//items", "/orders/1/items/3"}},
+		{"/orders/*", []string{"/orders/1", "/orders/1?a=b"}, []string{"/orders", "/orders/", "/orders/1/items"}},
+		{"/static/**", []string{"/static/", "/static/js/app.js", "/static/a?v=1"}, []string{"/static", "/statics/a"}},
+		{"/files/{path=**}", []string{"/files/a/b/c"}, []string{"/file/a"}},
+		// regex characters in the literal segments are quoted
+		{"/v1.0/a+b", []string{"/v1.0/a+b"}, []string{"/v1x0/a+b", "/v1.0/aab"}},
+	}
+	for _, c := range cases {
+		header := generatePathHeaderMatcher(&microservicev1alpha2.SmartLimitDescriptor_PathMatcher{Template: c.template})
+		if header == nil || header.Name != model.HeaderPath {
+			t.Fatalf("%s: unexpected header matcher %v", c.template, header)
+		}
+		for _, path := range c.match {
+			if !matchHeader(t, header, path) {
+				t.Errorf("%s (regex %s) should match %s", c.template, pathTemplateToRegex(c.template), path)
+			}
+		}
+		for _, path := range c.mismatch {
+			if matchHeader(t, header, path) {
+				t.Errorf("%s (regex %s) should not match %s", c.template, pathTemplateToRegex(c.template), path)
+			}
+		}
+	}
+}
+
+func TestGeneratePathHeaderMatcher(t *testing.T) {
+	cases := []struct {
+		name     string
+		path     *microservicev1alpha2.SmartLimitDescriptor_PathMatcher
+		match    []string
+		mismatch []string
+	}{
+		{"exact", &microservicev1alpha2.SmartLimitDescriptor_PathMatcher{Exact: "/orders"},
+			[]string{"/orders", "/orders?id=1"}, []string{"/orders/1", "/orders-list"}},
+		{"prefix", &microservicev1alpha2.SmartLimitDescriptor_PathMatcher{Prefix: "/orders"},
+			[]string{"/orders/1", "/orders-list"}, []string{"/order"}},
+		{"regex", &microservicev1alpha2.SmartLimitDescriptor_PathMatcher{Regex: "/orders/[0-9]+"},
+			[]string{"/orders/12", "/orders/12?a=b"}, []string{"/orders/ab", "/orders/12/items"}},
+		{"invert", &microservicev1alpha2.SmartLimitDescriptor_PathMatcher{Exact: "/health", InvertMatch: true},
+			[]string{"/orders"}, []string{"/health", "/health?full=1"}},
+		// exact is used if more than one way is set
+		{"conflict", &microservicev1alpha2.SmartLimitDescriptor_PathMatcher{Exact: "/orders", Prefix: "/"},
+			[]string{"/orders"}, []string{"/orders/1", "/"}},
+	}
+	for _, c := range cases {
+		header := generatePathHeaderMatcher(c.path)
+		if m, ok := header.HeaderMatchSpecifier.(*envoy_config_route_v3.HeaderMatcher_SafeRegexMatch); ok {
+			if re := m.SafeRegexMatch.Regex; re[0] != '^' || re[len(re)-1] != '$' {
+				t.Errorf("%s: regex %s is not anchored", c.name, re)
+			}
+		}
+		for _, path := range c.match {
+			if !matchHeader(t, header, path) {
+				t.Errorf("%s should match %s", c.name, path)
+			}
+		}
+		for _, path := range c.mismatch {
+			if matchHeader(t, header, path) {
+				t.Errorf("%s should not match %s", c.name, path)
+			}
+		}
+	}
+	if header := generatePathHeaderMatcher(&microservicev1alpha2.SmartLimitDescriptor_PathMatcher{}); header != nil {
+		t.Errorf("empty path matcher should be skipped, got %v", header)
+	}
+}
+
+func TestGenerateMethodHeaderMatcher(t *testing.T) {
+	single := generateMethodHeaderMatcher([]string{"get"})
+	if single.Name != model.HeaderMethod {
+		t.Errorf("got header %s", single.Name)
+	}
+	if m, ok := single.HeaderMatchSpecifier.(*envoy_config_route_v3.HeaderMatcher_ExactMatch); !ok || m.ExactMatch != "GET" {
+		t.Errorf("single method should be an exact match of upper case, got %v", single.HeaderMatchSpecifier)
+	}
+
+	multiple := generateMethodHeaderMatcher([]string{"get", "POST"})
+	for _, method := range []string{"GET", "POST"} {
+		if !matchHeader(t, multiple, method) {
+			t.Errorf("should match %s", method)
+		}
+	}
+	for _, method := range []string{"PUT", "GETX", "XPOST", "get"} {
+		if matchHeader(t, multiple, method) {
+			t.Errorf("should not match %s", method)
+		}
+	}
+}
+
+func TestMatcherConflicts(t *testing.T) {
+	instance := &microservicev1alpha2.SmartLimiter{
+		Spec: microservicev1alpha2.SmartLimiterSpec{Sets: map[string]*microservicev1alpha2.SmartLimitDescriptors{
+			"_base": {Descriptor_: []*microservicev1alpha2.SmartLimitDescriptor{
+				namedDescriptor("", "1"),
+				namedDescriptor("", "2"),
+				namedDescriptor("", "3"),
+				nil,
+			}},
+		}},
+		HeaderConflicts: map[string]string{"_base/#2": "header matcher foo has more than one specifier [regex exact], regex is used"},
+	}
+	instance.Spec.Sets["_base"].Descriptor_[0].Path = &microservicev1alpha2.SmartLimitDescriptor_PathMatcher{Prefix: "/orders"}
+	instance.Spec.Sets["_base"].Descriptor_[1].Path = &microservicev1alpha2.SmartLimitDescriptor_PathMatcher{Regex: "/a", Template: "/b"}
+	instance.Spec.Sets["_base"].Descriptor_[2].Path = &microservicev1alpha2.SmartLimitDescriptor_PathMatcher{Exact: "/a", Prefix: "/b"}
+
+	want := map[string]string{
+		"_base/#1": "path matcher has more than one way to match [regex template], regex is used",
+		"_base/#2": "header matcher foo has more than one specifier [regex exact], regex is used; " +
+			"path matcher has more than one way to match [exact prefix], exact is used",
+	}
+	if got := matcherConflicts(instance); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := matcherConflicts(&microservicev1alpha2.SmartLimiter{}); got != nil {
+		t.Errorf("got %v without conflicts", got)
+	}
+}
+
+// actionEntry returns the descriptor entry which envoy produces from the rate limit action
+func actionEntry(t *testing.T, action *envoy_config_route_v3.RateLimit_Action) [2]string {
+	t.Helper()
+	switch a := action.ActionSpecifier.(type) {
+	case *envoy_config_route_v3.RateLimit_Action_GenericKey_:
+		key := a.GenericKey.DescriptorKey
+		if key == "" {
+			key = model.GenericKey
+		}
+		return [2]string{key, a.GenericKey.DescriptorValue}
+	case *envoy_config_route_v3.RateLimit_Action_HeaderValueMatch_:
+		return [2]string{model.HeaderValueMatch, a.HeaderValueMatch.DescriptorValue}
+	default:
+		t.Fatalf("unexpected action %T", a)
+		return [2]string{}
+	}
+}
+
+func TestGenerateCustomKeyActions(t *testing.T) {
+	scope := valueScope{
+		service: types.NamespacedName{Namespace: "default", Name: "reviews"},
+		limiter: types.NamespacedName{Namespace: "default", Name: "reviews"},
+	}
+	value := "Service[reviews.default]-User[none]-Name[a]"
+	custom := func(key, value string, match bool) *microservicev1alpha2.SmartLimitDescriptor {
+		d := namedDescriptor("a", "10")
+		d.CustomKey, d.CustomValue = key, value
+		if match {
+			d.Method = []string{"GET"}
+		}
+		return d
+	}
+	cases := []struct {
+		name       string
+		descriptor *microservicev1alpha2.SmartLimitDescriptor
+		want       [][2]string
+	}{
+		{"without custom key", custom("", "", false), [][2]string{{model.GenericKey, value}}},
+		{"custom key", custom("tenant", "gold", false), [][2]string{{model.GenericKey, value}, {"tenant", "gold"}}},
+		{"custom key with header match", custom("tenant", "gold", true), [][2]string{{model.HeaderValueMatch, value}, {"tenant", "gold"}}},
+		// both key and value are required
+		{"custom key without value", custom("tenant", "", false), [][2]string{{model.GenericKey, value}}},
+		{"custom value without key", custom("", "gold", false), [][2]string{{model.GenericKey, value}}},
+	}
+	for _, c := range cases {
+		actions := generateRouteRateLimitActions(c.descriptor, scope)
+		got := make([][2]string, 0, len(actions))
+		for _, action := range actions {
+			got = append(got, actionEntry(t, action))
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got actions %v, want %v", c.name, got, c.want)
+		}
+		// the entries of local descriptor must be the ones produced by the actions in the same order
+		entries := make([][2]string, 0)
+		for _, entry := range generateLocalRateLimitDescriptorEntries(c.descriptor, scope) {
+			entries = append(entries, [2]string{entry.Key, entry.Value})
+		}
+		if !reflect.DeepEqual(entries, c.want) {
+			t.Errorf("%s: got entries %v, want %v", c.name, entries, c.want)
+		}
+	}
+}
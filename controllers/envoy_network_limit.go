@@ -0,0 +1,265 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	envoy_ratelimit_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/common/ratelimit/v3"
+	envoy_extensions_filters_network_local_ratelimit_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/local_ratelimit/v3"
+	envoy_extensions_filters_network_ratelimit_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/ratelimit/v3"
+	structpb "github.com/gogo/protobuf/types"
+	networking "istio.io/api/networking/v1alpha3"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"slime.io/slime/framework/util"
+	microservicev1alpha2 "slime.io/slime/modules/limiter/api/v1alpha2"
+	"slime.io/slime/modules/limiter/model"
+)
+
+// generateTcpPorts returns the tcp ports of the service, the key is the service port and the value is the
+// target port, which is the port of the inbound filter chain in sidecar. appProtocols is the appProtocol of ports.
+// the tcp ports which can not be limited are returned in unsupported with the reason
+func generateTcpPorts(svc *v1.Service, appProtocols map[int32]string, ep *v1.Endpoints) (ports map[uint32]uint32, unsupported map[uint32]string) {
+	ports = make(map[uint32]uint32)
+	unsupported = make(map[uint32]string)
+	for _, port := range svc.Spec.Ports {
+		protocol := portProtocol(port, appProtocols[port.Port])
+		switch protocol {
+		case model.ProtocolTCP, model.ProtocolTLS, model.ProtocolHTTPS:
+		case model.ProtocolMongo, model.ProtocolMySQL, model.ProtocolRedis:
+			// istio may insert mongo_proxy, mysql_proxy or redis_proxy, the last of which replaces tcp_proxy,
+			// the local_ratelimit can not be inserted before tcp_proxy reliably
+			unsupported[uint32(port.Port)] = fmt.Sprintf("tcp port %d of %s protocol is not supported", port.Port, protocol)
+			continue
+		default:
+			continue
+		}
+		target, err := resolveTargetPort(port, ep)
+		if err != nil {
+			unsupported[uint32(port.Port)] = err.Error()
+			continue
+		}
+		ports[uint32(port.Port)] = target
+	}
+	return ports, unsupported
+}
+
+// resolveTargetPort returns the number of target port, the named target port is resolved by the endpoints,
+// whose ports are named after the service port and hold the container port of pods
+func resolveTargetPort(port v1.ServicePort, ep *v1.Endpoints) (uint32, error) {
+	if port.TargetPort.Type == intstr.Int {
+		if port.TargetPort.IntVal == 0 {
+			// target port defaults to the service port
+			return uint32(port.Port), nil
+		}
+		return uint32(port.TargetPort.IntVal), nil
+	}
+	var target int32
+	if ep != nil {
+		for _, subset := range ep.Subsets {
+			for _, p := range subset.Ports {
+				if p.Name != port.Name {
+					continue
+				}
+				if target != 0 && target != p.Port {
+					return 0, fmt.Errorf("named target port %s of port %d is resolved to both %d and %d", port.TargetPort.StrVal, port.Port, target, p.Port)
+				}
+				target = p.Port
+			}
+		}
+	}
+	if target == 0 {
+		return 0, fmt.Errorf("named target port %s of port %d is not found in endpoints", port.TargetPort.StrVal, port.Port)
+	}
+	return uint32(target), nil
+}
+
+// portProtocol returns the protocol of the port in lower case, it is selected as istio does,
+// appProtocol takes precedence over the prefix of port name, like tcp-db or mysql. udp ports have no protocol
+func portProtocol(port v1.ServicePort, appProtocol string) string {
+	if port.Protocol != "" && port.Protocol != v1.ProtocolTCP {
+		return ""
+	}
+	protocol := strings.ToLower(appProtocol)
+	if protocol == "" {
+		protocol = strings.ToLower(strings.SplitN(port.Name, "-", 2)[0])
+	}
+	return protocol
+}
+
+// isTcpDescriptor returns true if the descriptor targets an inbound tcp port of the service,
+// header, path and method matchers make no sense for tcp and will be ignored
+func isTcpDescriptor(descriptor *microservicev1alpha2.SmartLimitDescriptor, tcpPorts map[uint32]uint32) bool {
+	port, ok := inboundPort(descriptor)
+	if !ok {
+		return false
+	}
+	_, ok = tcpPorts[port]
+	return ok
+}
+
+// inboundPort returns the port of descriptor targeting an inbound port without routes
+func inboundPort(descriptor *microservicev1alpha2.SmartLimitDescriptor) (uint32, bool) {
+	target := descriptor.Target
+	if target == nil || target.Port == 0 || len(target.Route) > 0 {
+		return 0, false
+	}
+	if target.Direction != "" && target.Direction != model.Inbound {
+		return 0, false
+	}
+	return uint32(target.Port), true
+}
+
+// unsupportedTcpDescriptors returns the descriptors targeting the tcp ports which can not be limited,
+// they would be limited as http otherwise. the key is set/#index, and the value is the reason
+func unsupportedTcpDescriptors(sets map[string]*microservicev1alpha2.SmartLimitDescriptors, unsupported map[uint32]string) map[string]string {
+	invalid := make(map[string]string)
+	for set, desc := range sets {
+		if desc == nil {
+			continue
+		}
+		for i, item := range desc.Descriptor_ {
+			if item == nil {
+				continue
+			}
+			if port, ok := inboundPort(item); ok {
+				if reason, ok := unsupported[port]; ok {
+					invalid[descriptorIndexKey(set, i)] = reason
+				}
+			}
+		}
+	}
+	return invalid
+}
+
+// tcpLocalConflicts returns the local descriptors targeting a tcp port which is already limited by another local
+// descriptor in the same set, since envoy.filters.network.local_ratelimit only has one token bucket.
+// the first one in the order of index keeps the port, the key is set/#index, and the value is the reason
+func tcpLocalConflicts(sets map[string]*microservicev1alpha2.SmartLimitDescriptors, tcpPorts map[uint32]uint32) map[string]string {
+	conflicts := make(map[string]string)
+	for set, desc := range sets {
+		if desc == nil {
+			continue
+		}
+		// key is the port, value is the key of the descriptor limiting it
+		ports := make(map[int32]string)
+		for i, item := range desc.Descriptor_ {
+			if item == nil || item.Action == nil || item.Action.Strategy == model.GlobalSmartLimiter ||
+				!isTcpDescriptor(item, tcpPorts) {
+				continue
+			}
+			if first, ok := ports[item.Target.Port]; ok {
+				conflicts[descriptorIndexKey(set, i)] = fmt.Sprintf("tcp port %d is already limited by %s", item.Target.Port, first)
+				continue
+			}
+			ports[item.Target.Port] = descriptorIndexKey(set, i)
+		}
+	}
+	return conflicts
+}
+
+// generateNetworkLocalRateLimitPatches limits the connections per fill_interval, every descriptor is translated into
+// a network filter envoy.filters.network.local_ratelimit inserted before tcp_proxy, as it only has one token bucket
+func generateNetworkLocalRateLimitPatches(descriptors []*microservicev1alpha2.SmartLimitDescriptor, tcpPorts map[uint32]uint32) []*networking.EnvoyFilter_EnvoyConfigObjectPatch {
+	patches := make([]*networking.EnvoyFilter_EnvoyConfigObjectPatch, 0)
+	for _, descriptor := range descriptors {
+		localRateLimit := &envoy_extensions_filters_network_local_ratelimit_v3.LocalRateLimit{
+			StatPrefix:  model.EnvoyTcpLocalRateLimiterStatPrefix,
+			TokenBucket: generateTokenBucket(descriptor),
+		}
+		local, err := util.MessageToStruct(localRateLimit)
+		if err != nil {
+			log.Errorf("MessageToStruct err: %+v", err.Error())
+			continue
+		}
+		patches = append(patches, &networking.EnvoyFilter_EnvoyConfigObjectPatch{
+			ApplyTo: networking.EnvoyFilter_NETWORK_FILTER,
+			Match:   generateEnvoyTcpProxyMatch(tcpPorts[uint32(descriptor.Target.Port)]),
+			Patch:   generateNetworkFilterPatch(model.EnvoyFiltersNetworkLocalRateLimit, model.TypeUrlEnvoyNetworkLocalRateLimit, local),
+		})
+	}
+	return patches
+}
+
+// generateNetworkRateLimitPatches inserts a network filter envoy.filters.network.ratelimit before tcp_proxy for each port,
+// the descriptors are the same with the ones in configmap of rls
+func generateNetworkRateLimitPatches(descriptors []*microservicev1alpha2.SmartLimitDescriptor, scope valueScope, tcpPorts map[uint32]uint32, server string) []*networking.EnvoyFilter_EnvoyConfigObjectPatch {
+	patches := make([]*networking.EnvoyFilter_EnvoyConfigObjectPatch, 0)
+	ports := make([]uint32, 0)
+	port2Descriptors := make(map[uint32][]*envoy_ratelimit_v3.RateLimitDescriptor)
+	for _, descriptor := range descriptors {
+		port := tcpPorts[uint32(descriptor.Target.Port)]
+		if _, ok := port2Descriptors[port]; !ok {
+			ports = append(ports, port)
+		}
+		port2Descriptors[port] = append(port2Descriptors[port], &envoy_ratelimit_v3.RateLimitDescriptor{
+			Entries: generateLocalRateLimitDescriptorEntries(descriptor, scope),
+		})
+	}
+
+	for _, port := range ports {
+		rateLimit := &envoy_extensions_filters_network_ratelimit_v3.RateLimit{
+			StatPrefix:       model.EnvoyTcpRateLimiterStatPrefix,
+			Domain:           model.Domain,
+			Descriptors:      port2Descriptors[port],
+			RateLimitService: generateRateLimitService(server),
+		}
+		rl, err := util.MessageToStruct(rateLimit)
+		if err != nil {
+			log.Errorf("MessageToStruct err: %+v", err.Error())
+			continue
+		}
+		patches = append(patches, &networking.EnvoyFilter_EnvoyConfigObjectPatch{
+			ApplyTo: networking.EnvoyFilter_NETWORK_FILTER,
+			Match:   generateEnvoyTcpProxyMatch(port),
+			Patch:   generateNetworkFilterPatch(model.EnvoyFiltersNetworkRateLimit, model.TypeUrlEnvoyNetworkRateLimit, rl),
+		})
+	}
+	return patches
+}
+
+func generateEnvoyTcpProxyMatch(port uint32) *networking.EnvoyFilter_EnvoyConfigObjectMatch {
+	return &networking.EnvoyFilter_EnvoyConfigObjectMatch{
+		Context: networking.EnvoyFilter_SIDECAR_INBOUND,
+		ObjectTypes: &networking.EnvoyFilter_EnvoyConfigObjectMatch_Listener{
+			Listener: &networking.EnvoyFilter_ListenerMatch{
+				PortNumber: port,
+				FilterChain: &networking.EnvoyFilter_ListenerMatch_FilterChainMatch{
+					Filter: &networking.EnvoyFilter_ListenerMatch_FilterMatch{
+						Name: model.EnvoyFiltersNetworkTcpProxy,
+					},
+				},
+			},
+		},
+	}
+}
+
+func generateNetworkFilterPatch(name, typeUrl string, value *structpb.Struct) *networking.EnvoyFilter_Patch {
+	return &networking.EnvoyFilter_Patch{
+		Operation: networking.EnvoyFilter_Patch_INSERT_BEFORE,
+		Value: &structpb.Struct{
+			Fields: map[string]*structpb.Value{
+				util.Struct_HttpFilter_Name: {
+					Kind: &structpb.Value_StringValue{StringValue: name},
+				},
+				util.Struct_HttpFilter_TypedConfig: {
+					Kind: &structpb.Value_StructValue{
+						StructValue: &structpb.Struct{
+							Fields: map[string]*structpb.Value{
+								util.Struct_Any_AtType: {
+									Kind: &structpb.Value_StringValue{StringValue: util.TypeUrl_UdpaTypedStruct},
+								},
+								util.Struct_Any_TypedUrl: {
+									Kind: &structpb.Value_StringValue{StringValue: typeUrl},
+								},
+								util.Struct_Any_Value: {
+									Kind: &structpb.Value_StructValue{StructValue: value},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
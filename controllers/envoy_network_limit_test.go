@@ -0,0 +1,268 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	structpb "github.com/gogo/protobuf/types"
+	networking "istio.io/api/networking/v1alpha3"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"slime.io/slime/framework/util"
+	microservicev1alpha2 "slime.io/slime/modules/limiter/api/v1alpha2"
+	"slime.io/slime/modules/limiter/model"
+)
+
+func tcpDescriptor(port int32, quota, strategy string) *microservicev1alpha2.SmartLimitDescriptor {
+	return &microservicev1alpha2.SmartLimitDescriptor{
+		Action: &microservicev1alpha2.SmartLimitDescriptor_Action{
+			Quota:        quota,
+			FillInterval: &microservicev1alpha2.Duration{Seconds: 1},
+			Strategy:     strategy,
+		},
+		Target: &microservicev1alpha2.SmartLimitDescriptor_Target{Port: port},
+	}
+}
+
+// typedConfig returns the name of the filter in patch and its config
+func typedConfig(t *testing.T, patch *networking.EnvoyFilter_EnvoyConfigObjectPatch) (string, *structpb.Struct) {
+	t.Helper()
+	fields := patch.Patch.Value.Fields
+	config := fields[util.Struct_HttpFilter_TypedConfig].GetStructValue().Fields[util.Struct_Any_Value].GetStructValue()
+	if config == nil {
+		t.Fatalf("no typed config in patch %s", patch.Patch.Value)
+	}
+	return fields[util.Struct_HttpFilter_Name].GetStringValue(), config
+}
+
+func TestPortProtocol(t *testing.T) {
+	cases := []struct {
+		name        string
+		port        v1.ServicePort
+		appProtocol string
+		want        string
+	}{
+		{"tcp prefix", v1.ServicePort{Name: "tcp-db"}, "", "tcp"},
+		{"mysql", v1.ServicePort{Name: "mysql"}, "", "mysql"},
+		{"http prefix", v1.ServicePort{Name: "http-web"}, "", "http"},
+		{"unnamed", v1.ServicePort{}, "", ""},
+		{"udp", v1.ServicePort{Name: "tcp-dns", Protocol: v1.ProtocolUDP}, "", ""},
+		{"appProtocol tcp", v1.ServicePort{Name: "web"}, "tcp", "tcp"},
+		{"appProtocol takes precedence", v1.ServicePort{Name: "tcp-web"}, "http", "http"},
+		{"appProtocol is case insensitive", v1.ServicePort{Name: "http-db"}, "Redis", "redis"},
+	}
+	for _, c := range cases {
+		if got := portProtocol(c.port, c.appProtocol); got != c.want {
+			t.Errorf("%s: got %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestGenerateTcpPorts(t *testing.T) {
+	svc := &v1.Service{Spec: v1.ServiceSpec{Ports: []v1.ServicePort{
+		{Name: "http", Port: 80, TargetPort: intstr.FromInt(8080)},
+		{Name: "tcp-db", Port: 3306, TargetPort: intstr.FromInt(13306)},
+		{Name: "tcp-default", Port: 9000},
+		{Name: "tcp-named", Port: 9001, TargetPort: intstr.FromString("admin")},
+		{Name: "tcp-missing", Port: 9002, TargetPort: intstr.FromString("missing")},
+		{Name: "tcp-ambiguous", Port: 9003, TargetPort: intstr.FromString("ambiguous")},
+		{Name: "db", Port: 6379, TargetPort: intstr.FromInt(6379)},
+		{Name: "mongo", Port: 27017},
+	}}}
+	ep := &v1.Endpoints{Subsets: []v1.EndpointSubset{
+		{Ports: []v1.EndpointPort{{Name: "tcp-named", Port: 19001}, {Name: "tcp-ambiguous", Port: 19003}}},
+		{Ports: []v1.EndpointPort{{Name: "tcp-named", Port: 19001}, {Name: "tcp-ambiguous", Port: 29003}}},
+	}}
+	got, unsupported := generateTcpPorts(svc, map[int32]string{6379: "redis"}, ep)
+	// named target port is resolved by the port of endpoints named after the service port
+	want := map[uint32]uint32{3306: 13306, 9000: 9000, 9001: 19001}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	wantUnsupported := []uint32{6379, 9002, 9003, 27017}
+	if len(unsupported) != len(wantUnsupported) {
+		t.Errorf("got unsupported %v, want ports %v", unsupported, wantUnsupported)
+	}
+	for _, port := range wantUnsupported {
+		if _, ok := unsupported[port]; !ok {
+			t.Errorf("port %d is not unsupported, got %v", port, unsupported)
+		}
+	}
+
+	// named target port can not be resolved without endpoints
+	if got, unsupported := generateTcpPorts(svc, nil, nil); got[9001] != 0 || unsupported[9001] == "" {
+		t.Errorf("got %v and unsupported %v, want port 9001 unresolved", got, unsupported)
+	}
+}
+
+func TestUnsupportedTcpDescriptors(t *testing.T) {
+	sets := map[string]*microservicev1alpha2.SmartLimitDescriptors{
+		"_base": {Descriptor_: []*microservicev1alpha2.SmartLimitDescriptor{
+			tcpDescriptor(6379, "10", "single"),
+			tcpDescriptor(3306, "10", model.GlobalSmartLimiter),
+			{Action: &microservicev1alpha2.SmartLimitDescriptor_Action{Quota: "10"},
+				Target: &microservicev1alpha2.SmartLimitDescriptor_Target{Port: 6379, Direction: model.Outbound}},
+		}},
+	}
+	got := unsupportedTcpDescriptors(sets, map[uint32]string{6379: "tcp port 6379 of redis protocol is not supported"})
+	want := map[string]string{descriptorIndexKey("_base", 0): "tcp port 6379 of redis protocol is not supported"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// serviceReader reads the unstructured services, like the api reader
+type serviceReader struct {
+	client.Reader
+	services map[types.NamespacedName]*unstructured.Unstructured
+	gets     int
+}
+
+func (r *serviceReader) Get(_ context.Context, key client.ObjectKey, obj runtime.Object) error {
+	r.gets++
+	svc, ok := r.services[key]
+	if !ok {
+		return errors.NewNotFound(v1.Resource("services"), key.Name)
+	}
+	svc.DeepCopyInto(obj.(*unstructured.Unstructured))
+	return nil
+}
+
+func TestAppProtocolCache(t *testing.T) {
+	nn := types.NamespacedName{Namespace: "default", Name: "db"}
+	reader := &serviceReader{services: map[types.NamespacedName]*unstructured.Unstructured{nn: {Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"metadata":   map[string]interface{}{"name": "db", "namespace": "default", "resourceVersion": "1"},
+		"spec": map[string]interface{}{
+			"ports": []interface{}{
+				map[string]interface{}{"name": "web", "port": int64(80)},
+				map[string]interface{}{"name": "db", "port": int64(3306), "appProtocol": "mysql"},
+			},
+		},
+	}}}}
+	c := newAppProtocolCache(reader)
+	svc := &v1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "db", ResourceVersion: "1"}}
+	for i := 0; i < 2; i++ {
+		if got, want := c.get(svc), map[int32]string{3306: "mysql"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	}
+	if reader.gets != 1 {
+		t.Errorf("got %d reads, the service is read again before it is changed", reader.gets)
+	}
+	svc.ResourceVersion = "2"
+	c.get(svc)
+	if reader.gets != 2 {
+		t.Errorf("got %d reads, the changed service is not read", reader.gets)
+	}
+
+	missing := &v1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "missing"}}
+	if got := c.get(missing); len(got) != 0 {
+		t.Errorf("got %v for missing service", got)
+	}
+	c.forget("default/db")
+	if _, ok := c.services["default/db"]; ok {
+		t.Errorf("the deleted service is kept")
+	}
+}
+
+func TestTcpLocalConflicts(t *testing.T) {
+	tcpPorts := map[uint32]uint32{3306: 3306, 6379: 6379}
+	sets := map[string]*microservicev1alpha2.SmartLimitDescriptors{
+		"_base": {Descriptor_: []*microservicev1alpha2.SmartLimitDescriptor{
+			tcpDescriptor(3306, "10", "single"),
+			tcpDescriptor(3306, "20", "single"),
+			// global descriptors share the filter
+			tcpDescriptor(3306, "30", model.GlobalSmartLimiter),
+			tcpDescriptor(6379, "10", "single"),
+			// http port
+			tcpDescriptor(80, "10", "single"),
+			tcpDescriptor(80, "10", "single"),
+		}},
+		// other sets select other workloads
+		"v1": {Descriptor_: []*microservicev1alpha2.SmartLimitDescriptor{
+			tcpDescriptor(3306, "10", "single"),
+		}},
+	}
+	want := map[string]string{"_base/#1": "tcp port 3306 is already limited by _base/#0"}
+	if got := tcpLocalConflicts(sets, tcpPorts); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestGenerateNetworkLocalRateLimitPatches(t *testing.T) {
+	tcpPorts := map[uint32]uint32{3306: 13306, 6379: 6379}
+	patches := generateNetworkLocalRateLimitPatches([]*microservicev1alpha2.SmartLimitDescriptor{
+		tcpDescriptor(3306, "10", "single"),
+		tcpDescriptor(6379, "20", "single"),
+	}, tcpPorts)
+	if len(patches) != 2 {
+		t.Fatalf("got %d patches, want 2", len(patches))
+	}
+	for i, want := range []struct {
+		port   uint32
+		tokens float64
+	}{{13306, 10}, {6379, 20}} {
+		patch := patches[i]
+		if patch.ApplyTo != networking.EnvoyFilter_NETWORK_FILTER || patch.Patch.Operation != networking.EnvoyFilter_Patch_INSERT_BEFORE {
+			t.Errorf("patch %d: unexpected patch %v %v", i, patch.ApplyTo, patch.Patch.Operation)
+		}
+		listener := patch.Match.GetListener()
+		if listener.GetPortNumber() != want.port || listener.GetFilterChain().GetFilter().GetName() != model.EnvoyFiltersNetworkTcpProxy {
+			t.Errorf("patch %d: unexpected match %v", i, listener)
+		}
+		name, config := typedConfig(t, patch)
+		if name != model.EnvoyFiltersNetworkLocalRateLimit {
+			t.Errorf("patch %d: got filter %s", i, name)
+		}
+		bucket := config.Fields["token_bucket"].GetStructValue()
+		if got := bucket.GetFields()["max_tokens"].GetNumberValue(); got != want.tokens {
+			t.Errorf("patch %d: got max_tokens %v, want %v", i, got, want.tokens)
+		}
+	}
+}
+
+func TestGenerateNetworkRateLimitPatches(t *testing.T) {
+	loc := types.NamespacedName{Namespace: "default", Name: "db"}
+	scope := valueScope{service: loc, limiter: loc}
+	tcpPorts := map[uint32]uint32{3306: 13306, 6379: 6379}
+	first := tcpDescriptor(3306, "10", model.GlobalSmartLimiter)
+	first.Name = "first"
+	second := tcpDescriptor(3306, "20", model.GlobalSmartLimiter)
+	second.Name = "second"
+	patches := generateNetworkRateLimitPatches([]*microservicev1alpha2.SmartLimitDescriptor{
+		first, second, tcpDescriptor(6379, "30", model.GlobalSmartLimiter),
+	}, scope, tcpPorts, model.RateLimitService)
+	// the descriptors of the same port share one filter
+	if len(patches) != 2 {
+		t.Fatalf("got %d patches, want 2", len(patches))
+	}
+	if got := patches[0].Match.GetListener().GetPortNumber(); got != 13306 {
+		t.Errorf("got port %d, want 13306", got)
+	}
+	name, config := typedConfig(t, patches[0])
+	if name != model.EnvoyFiltersNetworkRateLimit {
+		t.Errorf("got filter %s", name)
+	}
+	if got := config.Fields["domain"].GetStringValue(); got != model.Domain {
+		t.Errorf("got domain %s", got)
+	}
+	descriptors := config.Fields["descriptors"].GetListValue().GetValues()
+	if len(descriptors) != 2 {
+		t.Fatalf("got %d descriptors, want 2", len(descriptors))
+	}
+	for i, desc := range []*microservicev1alpha2.SmartLimitDescriptor{first, second} {
+		entry := descriptors[i].GetStructValue().Fields["entries"].GetListValue().GetValues()[0].GetStructValue()
+		if got, want := entry.Fields["value"].GetStringValue(), generateDescriptorValue(desc, scope); got != want {
+			t.Errorf("descriptor %d: got value %s, want %s", i, got, want)
+		}
+	}
+}
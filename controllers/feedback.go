@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	microservicev1alpha2 "slime.io/slime/modules/limiter/api/v1alpha2"
+	"slime.io/slime/modules/limiter/model"
+)
+
+// restoreFeedbackStates loads the feedback states from status if they are not in memory, e.g. after restart
+func (r *SmartLimiterReconciler) restoreFeedbackStates(instance *microservicev1alpha2.SmartLimiter) {
+	loc := types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}
+	qs := r.getQuotaStates(loc)
+	qs.Lock()
+	defer qs.Unlock()
+	for key, status := range instance.Status.FeedbackStatus {
+		if _, ok := qs.feedback[key]; !ok && status != nil {
+			qs.feedback[key] = status.DeepCopy()
+		}
+	}
+}
+
+// feedbackStatus returns a copy of the feedback states of descriptors which are still in spec
+func (r *SmartLimiterReconciler) feedbackStatus(loc types.NamespacedName, spec microservicev1alpha2.SmartLimiterSpec) map[string]*microservicev1alpha2.FeedbackStatus {
+	keys := make(map[string]struct{})
+	for set, desc := range spec.Sets {
+		if desc == nil {
+			continue
+		}
+		for i, item := range desc.Descriptor_ {
+			if item.Feedback != nil {
+				keys[descriptorKey(set, i, item)] = struct{}{}
+			}
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	qs := r.getQuotaStates(loc)
+	qs.Lock()
+	defer qs.Unlock()
+	status := make(map[string]*microservicev1alpha2.FeedbackStatus)
+	for key, state := range qs.feedback {
+		if _, ok := keys[key]; !ok {
+			delete(qs.feedback, key)
+			continue
+		}
+		status[key] = state.DeepCopy()
+	}
+	return status
+}
+
+// feedbackQuota adjusts the quota according to the metric and the state of last refresh,
+// base is the quota calculated from action.quota. the quota is adjusted only once per metric sample,
+// the SmartLimiter may be refreshed many times by the events and timers without a new sample
+func (r *SmartLimiterReconciler) feedbackQuota(loc types.NamespacedName, key string, base int, feedback *microservicev1alpha2.Feedback,
+	material map[string]string, sample time.Time) int {
+	qs := r.getQuotaStates(loc)
+	qs.Lock()
+	defer qs.Unlock()
+
+	state, ok := qs.feedback[key]
+	if !ok {
+		state = &microservicev1alpha2.FeedbackStatus{Quota: int64(base)}
+		qs.feedback[key] = state
+	}
+	return int(stepFeedback(state, base, feedback, material, sample))
+}
+
+// stepFeedback adjusts the quota in state if the sample is new, and returns the quota
+func stepFeedback(state *microservicev1alpha2.FeedbackStatus, base int, feedback *microservicev1alpha2.Feedback,
+	material map[string]string, sample time.Time) int64 {
+	value, err := strconv.ParseFloat(material[feedback.Metric], 64)
+	if err != nil {
+		log.Infof("metric %s is not available, keep quota %d", feedback.Metric, state.Quota)
+		return clampFeedbackQuota(float64(state.Quota), feedback)
+	}
+	if sample.IsZero() || sample.UnixNano() == state.SampleTime {
+		return clampFeedbackQuota(float64(state.Quota), feedback)
+	}
+	state.Metric = value
+
+	var quota float64
+	switch feedback.Algorithm {
+	case model.FeedbackPID:
+		quota = calculatePIDQuota(state, base, value, feedback)
+	default:
+		quota = calculateAIMDQuota(state, value, feedback)
+	}
+	state.Quota = clampFeedbackQuota(quota, feedback)
+	state.SampleTime = sample.UnixNano()
+	state.UpdateTime = time.Now().Unix()
+	return state.Quota
+}
+
+func calculateAIMDQuota(state *microservicev1alpha2.FeedbackStatus, value float64, feedback *microservicev1alpha2.Feedback) float64 {
+	quota := float64(state.Quota)
+	if value > feedback.Target {
+		decrease := feedback.Decrease
+		if decrease <= 0 || decrease >= 1 {
+			decrease = model.DefaultFeedbackDecrease
+		}
+		return math.Floor(quota * decrease)
+	}
+	increase := feedback.Increase
+	if increase <= 0 {
+		increase = model.DefaultFeedbackIncrease
+	}
+	return quota + increase
+}
+
+func calculatePIDQuota(state *microservicev1alpha2.FeedbackStatus, base int, value float64, feedback *microservicev1alpha2.Feedback) float64 {
+	if feedback.Target == 0 {
+		return float64(base)
+	}
+	e := (feedback.Target - value) / feedback.Target
+	integral := state.Integral + e
+	// anti-windup, the integral term can change the quota by 100% at most
+	if feedback.Ki > 0 {
+		integral = math.Max(-1/feedback.Ki, math.Min(1/feedback.Ki, integral))
+	}
+	u := feedback.Kp*e + feedback.Ki*integral + feedback.Kd*(e-state.LastError)
+	state.Integral = integral
+	state.LastError = e
+	return math.Round(float64(base) * (1 + u))
+}
+
+// clampFeedbackQuota keeps the quota in [min_quota, max_quota], the quota is never larger than the max tokens
+// of envoy, so it does not grow without bound if max_quota is not set
+func clampFeedbackQuota(quota float64, feedback *microservicev1alpha2.Feedback) int64 {
+	min := feedback.MinQuota
+	if min <= 0 {
+		min = 1
+	}
+	max := int64(math.MaxUint32)
+	if feedback.MaxQuota > 0 && feedback.MaxQuota < max {
+		max = feedback.MaxQuota
+	}
+	if math.IsNaN(quota) || quota < float64(min) {
+		return min
+	}
+	if quota > float64(max) {
+		return max
+	}
+	return int64(quota)
+}
@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"math"
+	"strconv"
+	"testing"
+	"time"
+
+	cmap "github.com/orcaman/concurrent-map"
+	"k8s.io/apimachinery/pkg/types"
+	microservicev1alpha2 "slime.io/slime/modules/limiter/api/v1alpha2"
+	"slime.io/slime/modules/limiter/model"
+)
+
+func TestCalculateAIMDQuota(t *testing.T) {
+	cases := []struct {
+		name     string
+		quota    int64
+		value    float64
+		feedback *microservicev1alpha2.Feedback
+		want     float64
+	}{
+		{"decrease", 100, 20, &microservicev1alpha2.Feedback{Target: 10, Decrease: 0.8}, 80},
+		{"default decrease", 100, 20, &microservicev1alpha2.Feedback{Target: 10}, 50},
+		{"invalid decrease", 100, 20, &microservicev1alpha2.Feedback{Target: 10, Decrease: 1.5}, 50},
+		{"decrease floors", 5, 20, &microservicev1alpha2.Feedback{Target: 10}, 2},
+		{"increase", 100, 5, &microservicev1alpha2.Feedback{Target: 10, Increase: 10}, 110},
+		{"default increase", 100, 5, &microservicev1alpha2.Feedback{Target: 10}, 101},
+		{"increase at target", 100, 10, &microservicev1alpha2.Feedback{Target: 10, Increase: 10}, 110},
+	}
+	for _, c := range cases {
+		state := &microservicev1alpha2.FeedbackStatus{Quota: c.quota}
+		if got := calculateAIMDQuota(state, c.value, c.feedback); got != c.want {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestCalculatePIDQuota(t *testing.T) {
+	cases := []struct {
+		name         string
+		state        microservicev1alpha2.FeedbackStatus
+		value        float64
+		feedback     *microservicev1alpha2.Feedback
+		want         float64
+		wantIntegral float64
+	}{
+		{"proportional increase", microservicev1alpha2.FeedbackStatus{}, 5,
+			&microservicev1alpha2.Feedback{Target: 10, Kp: 1}, 150, 0.5},
+		{"proportional decrease", microservicev1alpha2.FeedbackStatus{}, 15,
+			&microservicev1alpha2.Feedback{Target: 10, Kp: 1}, 50, -0.5},
+		{"integral", microservicev1alpha2.FeedbackStatus{Integral: 0.5}, 5,
+			&microservicev1alpha2.Feedback{Target: 10, Ki: 0.2}, 120, 1},
+		{"integral anti-windup", microservicev1alpha2.FeedbackStatus{Integral: 4.8}, 5,
+			&microservicev1alpha2.Feedback{Target: 10, Ki: 0.2}, 200, 5},
+		{"derivative", microservicev1alpha2.FeedbackStatus{LastError: 0.5}, 10,
+			&microservicev1alpha2.Feedback{Target: 10, Kd: 1}, 50, 0},
+		{"zero target keeps base", microservicev1alpha2.FeedbackStatus{}, 5,
+			&microservicev1alpha2.Feedback{Kp: 1}, 100, 0},
+	}
+	for _, c := range cases {
+		state := c.state
+		if got := calculatePIDQuota(&state, 100, c.value, c.feedback); got != c.want {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+		if state.Integral != c.wantIntegral {
+			t.Errorf("%s: got integral %v, want %v", c.name, state.Integral, c.wantIntegral)
+		}
+	}
+}
+
+func TestClampFeedbackQuota(t *testing.T) {
+	cases := []struct {
+		quota    float64
+		feedback *microservicev1alpha2.Feedback
+		want     int64
+	}{
+		{0, &microservicev1alpha2.Feedback{}, 1},
+		{-5, &microservicev1alpha2.Feedback{}, 1},
+		{5, &microservicev1alpha2.Feedback{MinQuota: 10}, 10},
+		{500, &microservicev1alpha2.Feedback{MaxQuota: 200}, 200},
+		{150, &microservicev1alpha2.Feedback{MinQuota: 10, MaxQuota: 200}, 150},
+		// the quota can not exceed the max tokens of envoy without max_quota
+		{1e12, &microservicev1alpha2.Feedback{}, math.MaxUint32},
+		{1e12, &microservicev1alpha2.Feedback{MaxQuota: 1e11}, math.MaxUint32},
+		{math.NaN(), &microservicev1alpha2.Feedback{MinQuota: 10}, 10},
+	}
+	for _, c := range cases {
+		if got := clampFeedbackQuota(c.quota, c.feedback); got != c.want {
+			t.Errorf("clamp %v with %+v: got %d, want %d", c.quota, c.feedback, got, c.want)
+		}
+	}
+}
+
+func TestFeedbackQuotaStepsOncePerSample(t *testing.T) {
+	r := &SmartLimiterReconciler{quotaStates: cmap.New()}
+	loc := types.NamespacedName{Namespace: "default", Name: "reviews"}
+	material := map[string]string{"_base.rt99": "20"}
+	t0 := time.Unix(1000, 0)
+
+	cases := []struct {
+		name     string
+		feedback *microservicev1alpha2.Feedback
+		steps    []time.Time
+		want     []int
+	}{
+		{
+			name:     "aimd",
+			feedback: &microservicev1alpha2.Feedback{Metric: "_base.rt99", Target: 10, MinQuota: 10},
+			// the repeated sample is a refresh triggered without a new metric
+			steps: []time.Time{t0, t0, t0, t0.Add(time.Second), t0.Add(2 * time.Second), t0.Add(3 * time.Second)},
+			want:  []int{50, 50, 50, 25, 12, 10},
+		},
+		{
+			name:     "pid",
+			feedback: &microservicev1alpha2.Feedback{Metric: "_base.rt99", Target: 10, Ki: 0.1, Algorithm: model.FeedbackPID},
+			steps:    []time.Time{t0, t0, t0.Add(time.Second)},
+			want:     []int{90, 90, 80},
+		},
+		{
+			name:     "no sample",
+			feedback: &microservicev1alpha2.Feedback{Metric: "_base.rt99", Target: 10},
+			steps:    []time.Time{{}, {}},
+			want:     []int{100, 100},
+		},
+	}
+	for _, c := range cases {
+		for i, sample := range c.steps {
+			if got := r.feedbackQuota(loc, c.name, 100, c.feedback, material, sample); got != c.want[i] {
+				t.Errorf("%s: step %d got %d, want %d", c.name, i, got, c.want[i])
+			}
+		}
+	}
+
+	state := r.getQuotaStates(loc).feedback["pid"]
+	if state.Integral != -2 || state.SampleTime != t0.Add(time.Second).UnixNano() {
+		t.Errorf("pid state %+v, want integral -2 and the latest sample time", state)
+	}
+}
+
+func TestFeedbackQuotaMissingMetric(t *testing.T) {
+	r := &SmartLimiterReconciler{quotaStates: cmap.New()}
+	loc := types.NamespacedName{Namespace: "default", Name: "reviews"}
+	feedback := &microservicev1alpha2.Feedback{Metric: "_base.rt99", Target: 10, MaxQuota: 80}
+	if got := r.feedbackQuota(loc, "aimd", 100, feedback, nil, time.Unix(1000, 0)); got != 80 {
+		t.Errorf("got %d, want the clamped quota 80", got)
+	}
+}
+
+func TestAIMDQuotaIsBounded(t *testing.T) {
+	feedback := &microservicev1alpha2.Feedback{Metric: "cpu", Target: 80, Increase: math.MaxUint32}
+	state := &microservicev1alpha2.FeedbackStatus{Quota: math.MaxUint32 - 1}
+	material := map[string]string{"cpu": "10"}
+	for i := 1; i <= 3; i++ {
+		if got := stepFeedback(state, 100, feedback, material, time.Unix(int64(i), 0)); got != math.MaxUint32 {
+			t.Fatalf("step %d: got %d, want %d", i, got, uint32(math.MaxUint32))
+		}
+	}
+	descriptor := &microservicev1alpha2.SmartLimitDescriptor{Action: &microservicev1alpha2.SmartLimitDescriptor_Action{
+		Quota:        strconv.FormatInt(state.Quota+1, 10),
+		FillInterval: &microservicev1alpha2.Duration{Seconds: 1},
+	}}
+	if got := generateTokenBucket(descriptor).MaxTokens; got != math.MaxUint32 {
+		t.Errorf("got max tokens %d, want %d", got, uint32(math.MaxUint32))
+	}
+}
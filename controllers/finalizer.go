@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	microservicev1alpha2 "slime.io/slime/modules/limiter/api/v1alpha2"
+	"slime.io/slime/modules/limiter/model"
+)
+
+func (r *SmartLimiterReconciler) globalRateLimitEnabled() bool {
+	return r.env.Config != nil && r.env.Config.Limiter != nil && !r.env.Config.Limiter.GetDisableGlobalRateLimit()
+}
+
+func hasFinalizer(instance *microservicev1alpha2.SmartLimiter) bool {
+	for _, f := range instance.Finalizers {
+		if f == model.LimiterFinalizer {
+			return true
+		}
+	}
+	return false
+}
+
+// ensureFinalizer adds the finalizer to the SmartLimiter, so its global descriptors are deleted from
+// the configmap before it disappears, even if the controller is down when it is deleted
+func (r *SmartLimiterReconciler) ensureFinalizer(instance *microservicev1alpha2.SmartLimiter) error {
+	if hasFinalizer(instance) || !r.globalRateLimitEnabled() {
+		return nil
+	}
+	controllerutil.AddFinalizer(instance, model.LimiterFinalizer)
+	return r.Client.Update(context.TODO(), instance)
+}
+
+// finalize deletes the global descriptors of the SmartLimiter being deleted and then removes the finalizer,
+// the error is returned to requeue it with backoff if the configmap is not updated. the descriptors are
+// deleted even if global rate limit is disabled now, since they may be added before it is disabled
+func (r *SmartLimiterReconciler) finalize(instance *microservicev1alpha2.SmartLimiter) error {
+	loc := types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}
+	r.forget(loc)
+	if !hasFinalizer(instance) {
+		return nil
+	}
+	if err := refreshConfigMap([]*model.Descriptor{}, nil, r, loc); err != nil {
+		log.Errorf("delete global descriptors of smartlimiter %s err, %+v", loc, err)
+		return err
+	}
+	controllerutil.RemoveFinalizer(instance, model.LimiterFinalizer)
+	log.Infof("global descriptors of smartlimiter %s are deleted, remove the finalizer", loc)
+	return r.Client.Update(context.TODO(), instance)
+}
+
+// forget clears the states of the deleted SmartLimiter
+func (r *SmartLimiterReconciler) forget(loc types.NamespacedName) {
+	key := loc.Namespace + "/" + loc.Name
+	log.Infof("metricInfo.Pop, name %s, namespace,%s", loc.Name, loc.Namespace)
+	r.metricInfo.Pop(key)
+	r.interest.Pop(key)
+	// the SmartLimiters which the local limits are merged into should drop them
+	r.refreshRouteOwners(r.mergedOwners(loc))
+	r.quotaStates.Pop(key)
+	r.stopScheduleTimer(loc)
+	r.lastUpdatePolicyLock.Lock()
+	r.lastUpdatePolicy = microservicev1alpha2.SmartLimiterSpec{}
+	r.lastUpdatePolicyLock.Unlock()
+}
+
+// deleting tells whether the SmartLimiter is deleted or being deleted, its descriptors should not be added
+// to the configmap any more
+func (r *SmartLimiterReconciler) deleting(loc types.NamespacedName) bool {
+	instance := &microservicev1alpha2.SmartLimiter{}
+	if err := r.Client.Get(context.TODO(), loc, instance); err != nil {
+		return errors.IsNotFound(err)
+	}
+	return instance.DeletionTimestamp != nil
+}
@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+
+	cmap "github.com/orcaman/concurrent-map"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	microservicev1alpha2 "slime.io/slime/modules/limiter/api/v1alpha2"
+	"slime.io/slime/modules/limiter/model"
+)
+
+func TestFinalizeWithGlobalRateLimitDisabled(t *testing.T) {
+	now := metav1.Now()
+	instance := &microservicev1alpha2.SmartLimiter{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         "default",
+			Name:              "reviews",
+			Finalizers:        []string{model.LimiterFinalizer},
+			DeletionTimestamp: &now,
+		},
+	}
+	cm := rateLimitConfigMap(t,
+		"Service[reviews.default]-User[none]-Name[a]",
+		"Service[my-reviews.default]-User[none]-Name[a]",
+	)
+	r := newFakeReconciler(t, cm, instance)
+	r.metricInfo, r.interest, r.quotaStates, r.scheduleTimers = cmap.New(), cmap.New(), cmap.New(), cmap.New()
+	r.lastUpdatePolicyLock = &sync.RWMutex{}
+	if r.globalRateLimitEnabled() {
+		t.Fatalf("global rate limit should be disabled without config")
+	}
+
+	if err := r.finalize(instance.DeepCopy()); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := configMapValues(t, r), []string{"Service[my-reviews.default]-User[none]-Name[a]"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	found := &microservicev1alpha2.SmartLimiter{}
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "reviews"}, found); err != nil {
+		t.Fatal(err)
+	}
+	if hasFinalizer(found) {
+		t.Errorf("finalizer is not removed")
+	}
+}
+
+func TestFinalizeWithMalformedConfigMap(t *testing.T) {
+	now := metav1.Now()
+	instance := &microservicev1alpha2.SmartLimiter{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         "default",
+			Name:              "reviews",
+			Finalizers:        []string{model.LimiterFinalizer},
+			DeletionTimestamp: &now,
+		},
+	}
+	cm := rateLimitConfigMap(t, "Service[reviews.default]-User[none]-Name[a]")
+	cm.Data[model.ConfigMapConfig] = "descriptors: ["
+	r := newFakeReconciler(t, cm, instance)
+	r.metricInfo, r.interest, r.quotaStates, r.scheduleTimers = cmap.New(), cmap.New(), cmap.New(), cmap.New()
+	r.lastUpdatePolicyLock = &sync.RWMutex{}
+
+	if err := r.finalize(instance.DeepCopy()); err == nil {
+		t.Errorf("should fail to requeue the deletion")
+	}
+	found := &microservicev1alpha2.SmartLimiter{}
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "reviews"}, found); err != nil {
+		t.Fatal(err)
+	}
+	if !hasFinalizer(found) {
+		t.Errorf("finalizer is removed before the global descriptors are deleted")
+	}
+}
@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"slime.io/slime/framework/apis/networking/v1alpha3"
+	"slime.io/slime/framework/util"
+	microservicev1alpha2 "slime.io/slime/modules/limiter/api/v1alpha2"
+	"slime.io/slime/modules/limiter/model"
+)
+
+// envoyFilterName returns the name of the EnvoyFilter generated for the set of the SmartLimiter
+func envoyFilterName(instance *microservicev1alpha2.SmartLimiter, set string) string {
+	if set == util.Wellkonw_BaseSet {
+		return fmt.Sprintf("%s.%s.ratelimit", instance.Name, instance.Namespace)
+	}
+	return fmt.Sprintf("%s.%s.%s.ratelimit", instance.Name, instance.Namespace, set)
+}
+
+// gcEnvoyFilters deletes the EnvoyFilters owned by the SmartLimiter which are not generated in the latest refresh,
+// like the ones of the subsets removed from DestinationRule or the sets removed from spec. it also cleans up
+// the ones left before the controller restarts. only the EnvoyFilters labeled by the limiter are listed, except
+// the first time, see gcLegacyEnvoyFilters
+func (r *SmartLimiterReconciler) gcEnvoyFilters(instance *microservicev1alpha2.SmartLimiter, generated map[string]struct{}) {
+	list := &v1alpha3.EnvoyFilterList{}
+	if err := r.Client.List(context.TODO(), list, client.InNamespace(instance.Namespace), ownershipLabels(instance)); err != nil {
+		log.Errorf("list envoyfilters in %s err, %+v", instance.Namespace, err)
+		return
+	}
+	for i := range list.Items {
+		ef := &list.Items[i]
+		if _, ok := generated[ef.Name]; ok {
+			continue
+		}
+		if !ownedBy(ef, instance) {
+			continue
+		}
+		log.Infof("delete orphaned envoyfilter %s/%s of smartlimiter %s", ef.Namespace, ef.Name, instance.Name)
+		if err := r.Client.Delete(context.TODO(), ef); err != nil && !errors.IsNotFound(err) {
+			log.Errorf("delete envoyfilter %s/%s err, %+v", ef.Namespace, ef.Name, err)
+		}
+	}
+	r.gcLegacyEnvoyFilters(instance, generated)
+}
+
+// gcLegacyEnvoyFilters deletes the EnvoyFilters generated by the releases before the ownership labels are added,
+// which are not generated in the latest refresh. they are recognized by the name and the owner reference, and
+// they are only listed once for each SmartLimiter after the controller starts, since later ones are labeled
+func (r *SmartLimiterReconciler) gcLegacyEnvoyFilters(instance *microservicev1alpha2.SmartLimiter, generated map[string]struct{}) {
+	qs := r.getQuotaStates(types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name})
+	qs.Lock()
+	collected := qs.legacyCollected
+	qs.Unlock()
+	if collected {
+		return
+	}
+
+	list := &v1alpha3.EnvoyFilterList{}
+	if err := r.Client.List(context.TODO(), list, client.InNamespace(instance.Namespace)); err != nil {
+		log.Errorf("list envoyfilters in %s err, %+v", instance.Namespace, err)
+		return
+	}
+	collected = true
+	for i := range list.Items {
+		ef := &list.Items[i]
+		if _, ok := generated[ef.Name]; ok || ef.Labels[model.LabelManagedBy] == model.LabelManagedByValue {
+			continue
+		}
+		if !ownedBy(ef, instance) || !legacyEnvoyFilterName(ef.Name, instance) {
+			continue
+		}
+		log.Infof("delete legacy envoyfilter %s/%s of smartlimiter %s", ef.Namespace, ef.Name, instance.Name)
+		if err := r.Client.Delete(context.TODO(), ef); err != nil && !errors.IsNotFound(err) {
+			log.Errorf("delete envoyfilter %s/%s err, %+v", ef.Namespace, ef.Name, err)
+			// retry in the next refresh
+			collected = false
+		}
+	}
+	qs.Lock()
+	qs.legacyCollected = collected
+	qs.Unlock()
+}
+
+// legacyEnvoyFilterName tells whether the name is generated by envoyFilterName for the SmartLimiter
+func legacyEnvoyFilterName(name string, instance *microservicev1alpha2.SmartLimiter) bool {
+	prefix := instance.Name + "." + instance.Namespace + "."
+	return strings.HasPrefix(name, prefix) && strings.HasSuffix(name[len(prefix)-1:], ".ratelimit")
+}
+
+// ownershipLabels selects the EnvoyFilters generated for the SmartLimiter, see generatedLabels
+func ownershipLabels(instance *microservicev1alpha2.SmartLimiter) client.MatchingLabels {
+	labels := client.MatchingLabels{model.LabelManagedBy: model.LabelManagedByValue}
+	if len(validation.IsValidLabelValue(instance.Name)) == 0 {
+		labels[model.LabelSmartLimiter] = instance.Name
+	}
+	return labels
+}
+
+func ownedBy(obj metav1.Object, instance *microservicev1alpha2.SmartLimiter) bool {
+	ref := metav1.GetControllerOf(obj)
+	return ref != nil && ref.UID == instance.UID
+}
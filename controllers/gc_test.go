@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	cmap "github.com/orcaman/concurrent-map"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"slime.io/slime/framework/apis/networking/v1alpha3"
+	microservicev1alpha2 "slime.io/slime/modules/limiter/api/v1alpha2"
+)
+
+// generatedEnvoyFilter returns the EnvoyFilter generated for the set of the SmartLimiter, owned by owner
+func generatedEnvoyFilter(instance *microservicev1alpha2.SmartLimiter, set string, owner types.UID) *v1alpha3.EnvoyFilter {
+	controller := true
+	return &v1alpha3.EnvoyFilter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      envoyFilterName(instance, set),
+			Namespace: instance.Namespace,
+			Labels:    generatedLabels(instance, set),
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: microservicev1alpha2.GroupVersion.String(),
+				Kind:       "SmartLimiter",
+				Name:       instance.Name,
+				UID:        owner,
+				Controller: &controller,
+			}},
+		},
+	}
+}
+
+func TestGcEnvoyFilters(t *testing.T) {
+	instance := &microservicev1alpha2.SmartLimiter{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "reviews", UID: "uid-reviews"}}
+	other := &microservicev1alpha2.SmartLimiter{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "ratings", UID: "uid-ratings"}}
+
+	// the EnvoyFilter generated by the release before the labels are added
+	legacy := generatedEnvoyFilter(instance, "v1", instance.UID)
+	legacy.Labels = nil
+	// the EnvoyFilter owned by the limiter but not named by it, like the ones created by user
+	unlabeled := generatedEnvoyFilter(instance, "manual", instance.UID)
+	unlabeled.Name, unlabeled.Labels = "reviews-manual", nil
+	otherLegacy := generatedEnvoyFilter(other, "_base", other.UID)
+	otherLegacy.Labels = nil
+	// the EnvoyFilter labeled with the name of the limiter, but owned by the one recreated with the same name
+	recreated := generatedEnvoyFilter(instance, "stale", "uid-old")
+
+	objs := []runtime.Object{
+		generatedEnvoyFilter(instance, "_base", instance.UID),
+		// v2 is removed from DestinationRule
+		generatedEnvoyFilter(instance, "v2", instance.UID),
+		// canary is removed from spec
+		generatedEnvoyFilter(instance, "canary", instance.UID),
+		generatedEnvoyFilter(other, "v2", other.UID),
+		unlabeled,
+		recreated,
+		legacy,
+		otherLegacy,
+	}
+	r := newFakeReconciler(t, objs...)
+	r.quotaStates = cmap.New()
+	r.gcEnvoyFilters(instance, map[string]struct{}{envoyFilterName(instance, "_base"): {}})
+
+	list := &v1alpha3.EnvoyFilterList{}
+	if err := r.Client.List(context.TODO(), list, client.InNamespace("default")); err != nil {
+		t.Fatal(err)
+	}
+	got := make([]string, 0, len(list.Items))
+	for _, ef := range list.Items {
+		got = append(got, ef.Name)
+	}
+	sort.Strings(got)
+	want := []string{
+		envoyFilterName(other, "v2"),
+		envoyFilterName(instance, "_base"),
+		unlabeled.Name,
+		recreated.Name,
+		otherLegacy.Name,
+	}
+	sort.Strings(want)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if !r.getQuotaStates(types.NamespacedName{Namespace: "default", Name: "reviews"}).legacyCollected {
+		t.Errorf("the legacy EnvoyFilters are listed again in the next refresh")
+	}
+}
@@ -0,0 +1,358 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups="",resources=services;pods;endpoints,verbs=get;list;watch
+
+// kubeCache reads services, pods, endpoints, hpas and nodes from the informers of the manager cache,
+// which are shared with the controllers. the pods of each service and the replicas of hpas are kept
+// incrementally by the informer handlers, so the producers do not list them on every tick
+type kubeCache struct {
+	services     corelisters.ServiceLister
+	pods         corelisters.PodLister
+	endpoints    corelisters.EndpointsLister
+	nodes        toolscache.Indexer
+	appProtocols *appProtocolCache
+	// onEndpoints is called with the endpoints changed, it should not block the informer
+	onEndpoints func(nn types.NamespacedName)
+
+	sync.RWMutex
+	// the selectors of services, the key is namespace, the key of value is the service name
+	selectors map[string]map[string]labels.Selector
+	// the pods selected by services, the key is namespace/name of the service, the key of value is the pod name
+	servicePods map[string]map[string]struct{}
+	// the scale target and replicas of hpas, the key is namespace, the key of value is the hpa name
+	hpas map[string]map[string]hpaScale
+}
+
+// hpaScale is the min, max and desired replicas of the scale target, which is kind/name
+type hpaScale struct {
+	target   string
+	replicas [3]int32
+}
+
+// newKubeCache gets the informers from the cache of manager, they are started and synced with the manager.
+// reader reads the services from api server for appProtocol, see appProtocolCache
+func newKubeCache(c cache.Cache, reader client.Reader, onEndpoints func(nn types.NamespacedName)) (*kubeCache, error) {
+	kc := &kubeCache{
+		appProtocols: newAppProtocolCache(reader),
+		onEndpoints:  onEndpoints,
+		selectors:    make(map[string]map[string]labels.Selector),
+		servicePods:  make(map[string]map[string]struct{}),
+		hpas:         make(map[string]map[string]hpaScale),
+	}
+	services, err := serviceInformer(c)
+	if err != nil {
+		return nil, err
+	}
+	pods, err := sharedInformer(c, &v1.Pod{})
+	if err != nil {
+		return nil, err
+	}
+	endpoints, err := sharedInformer(c, &v1.Endpoints{})
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := sharedInformer(c, &v1.Node{})
+	if err != nil {
+		return nil, err
+	}
+	hpas, err := sharedInformer(c, &autoscalingv1.HorizontalPodAutoscaler{})
+	if err != nil {
+		return nil, err
+	}
+	kc.services = corelisters.NewServiceLister(services.GetIndexer())
+	kc.pods = corelisters.NewPodLister(pods.GetIndexer())
+	kc.endpoints = corelisters.NewEndpointsLister(endpoints.GetIndexer())
+	kc.nodes = nodes.GetIndexer()
+
+	services.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { kc.onService(obj, false) },
+		UpdateFunc: func(_, obj interface{}) { kc.onService(obj, false) },
+		DeleteFunc: func(obj interface{}) { kc.onService(obj, true) },
+	})
+	pods.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { kc.onPod(obj, false) },
+		UpdateFunc: func(_, obj interface{}) { kc.onPod(obj, false) },
+		DeleteFunc: func(obj interface{}) { kc.onPod(obj, true) },
+	})
+	endpoints.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc: kc.onEndpointsEvent,
+		UpdateFunc: func(old, obj interface{}) {
+			// skip the resync
+			if o, err := meta.Accessor(old); err == nil {
+				if n, err := meta.Accessor(obj); err == nil && o.GetResourceVersion() == n.GetResourceVersion() {
+					return
+				}
+			}
+			kc.onEndpointsEvent(obj)
+		},
+		DeleteFunc: kc.onEndpointsEvent,
+	})
+	hpas.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { kc.onHpa(obj, false) },
+		UpdateFunc: func(_, obj interface{}) { kc.onHpa(obj, false) },
+		DeleteFunc: func(obj interface{}) { kc.onHpa(obj, true) },
+	})
+	return kc, nil
+}
+
+// sharedInformer returns the informer of the kind of obj in the cache of manager
+func sharedInformer(c cache.Cache, obj runtime.Object) (toolscache.SharedIndexInformer, error) {
+	informer, err := c.GetInformer(obj)
+	if err != nil {
+		return nil, fmt.Errorf("get informer of %T err, %v", obj, err)
+	}
+	shared, ok := informer.(toolscache.SharedIndexInformer)
+	if !ok {
+		return nil, fmt.Errorf("informer of %T is not a shared index informer", obj)
+	}
+	return shared, nil
+}
+
+// serviceInformer returns the informer of services in the cache of manager
+func serviceInformer(c cache.Cache) (toolscache.SharedIndexInformer, error) {
+	return sharedInformer(c, &v1.Service{})
+}
+
+// tombstone returns the object in the tombstone of delete events
+func tombstone(obj interface{}) interface{} {
+	if t, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+		return t.Obj
+	}
+	return obj
+}
+
+// onService records the selector of service, and resets the pods of it
+func (kc *kubeCache) onService(obj interface{}, deleted bool) {
+	svc, ok := tombstone(obj).(*v1.Service)
+	if !ok {
+		log.Errorf("handle service event err, unexpected object %T", obj)
+		return
+	}
+	key := svc.Namespace + "/" + svc.Name
+	if deleted {
+		kc.appProtocols.forget(key)
+	}
+	kc.Lock()
+	defer kc.Unlock()
+	if deleted {
+		delete(kc.selectors[svc.Namespace], svc.Name)
+		if len(kc.selectors[svc.Namespace]) == 0 {
+			delete(kc.selectors, svc.Namespace)
+		}
+		delete(kc.servicePods, key)
+		return
+	}
+	if kc.selectors[svc.Namespace] == nil {
+		kc.selectors[svc.Namespace] = make(map[string]labels.Selector)
+	}
+	pods := make(map[string]struct{})
+	// the service without selector selects no pod
+	if len(svc.Spec.Selector) == 0 {
+		kc.selectors[svc.Namespace][svc.Name] = labels.Nothing()
+		kc.servicePods[key] = pods
+		return
+	}
+	selector := labels.SelectorFromSet(svc.Spec.Selector)
+	kc.selectors[svc.Namespace][svc.Name] = selector
+	list, err := kc.pods.Pods(svc.Namespace).List(selector)
+	if err != nil {
+		log.Errorf("list pods of service %s err, %+v", key, err)
+	}
+	for _, pod := range list {
+		pods[pod.Name] = struct{}{}
+	}
+	kc.servicePods[key] = pods
+}
+
+// onEndpointsEvent passes the endpoints changed to onEndpoints, which replaces the watch of endpoints
+// of the watcher producer
+func (kc *kubeCache) onEndpointsEvent(obj interface{}) {
+	if kc.onEndpoints == nil {
+		return
+	}
+	o, err := meta.Accessor(tombstone(obj))
+	if err != nil {
+		log.Errorf("handle endpoints event err, %+v", err)
+		return
+	}
+	kc.onEndpoints(types.NamespacedName{Namespace: o.GetNamespace(), Name: o.GetName()})
+}
+
+// onPod adds the pod to or removes it from the services in its namespace
+func (kc *kubeCache) onPod(obj interface{}, deleted bool) {
+	pod, ok := tombstone(obj).(*v1.Pod)
+	if !ok {
+		log.Errorf("handle pod event err, unexpected object %T", obj)
+		return
+	}
+	set := labels.Set(pod.Labels)
+	kc.Lock()
+	defer kc.Unlock()
+	for name, selector := range kc.selectors[pod.Namespace] {
+		pods := kc.servicePods[pod.Namespace+"/"+name]
+		if pods == nil {
+			continue
+		}
+		if !deleted && selector.Matches(set) {
+			pods[pod.Name] = struct{}{}
+		} else {
+			delete(pods, pod.Name)
+		}
+	}
+}
+
+// onHpa records the scale target and replicas of hpa
+func (kc *kubeCache) onHpa(obj interface{}, deleted bool) {
+	hpa, ok := tombstone(obj).(*autoscalingv1.HorizontalPodAutoscaler)
+	if !ok {
+		log.Errorf("handle hpa event err, unexpected object %T", obj)
+		return
+	}
+	kc.Lock()
+	defer kc.Unlock()
+	if deleted {
+		delete(kc.hpas[hpa.Namespace], hpa.Name)
+		if len(kc.hpas[hpa.Namespace]) == 0 {
+			delete(kc.hpas, hpa.Namespace)
+		}
+		return
+	}
+	min := int32(1)
+	if hpa.Spec.MinReplicas != nil {
+		min = *hpa.Spec.MinReplicas
+	}
+	ref := hpa.Spec.ScaleTargetRef
+	if kc.hpas[hpa.Namespace] == nil {
+		kc.hpas[hpa.Namespace] = make(map[string]hpaScale)
+	}
+	kc.hpas[hpa.Namespace][hpa.Name] = hpaScale{
+		target:   ref.Kind + "/" + ref.Name,
+		replicas: [3]int32{min, hpa.Spec.MaxReplicas, hpa.Status.DesiredReplicas},
+	}
+}
+
+// podsOfService returns the names of pods selected by the service, false if the service is not found
+func (kc *kubeCache) podsOfService(namespace, name string) ([]string, bool) {
+	kc.RLock()
+	defer kc.RUnlock()
+	pods, ok := kc.servicePods[namespace+"/"+name]
+	if !ok {
+		return nil, false
+	}
+	names := make([]string, 0, len(pods))
+	for pod := range pods {
+		names = append(names, pod)
+	}
+	return names, true
+}
+
+// hpaReplicas returns the min, max and desired replicas of hpas in namespace, the key is kind/name of the target
+func (kc *kubeCache) hpaReplicas(namespace string) map[string][3]int32 {
+	kc.RLock()
+	defer kc.RUnlock()
+	replicas := make(map[string][3]int32, len(kc.hpas[namespace]))
+	for _, scale := range kc.hpas[namespace] {
+		replicas[scale.target] = scale.replicas
+	}
+	return replicas
+}
+
+// nodeCount returns the number of nodes in cache
+func (kc *kubeCache) nodeCount() int {
+	return len(kc.nodes.ListKeys())
+}
+
+// appProtocolCache reads the appProtocol of service ports, the Service of the pinned k8s api drops the fields
+// added later, so the services are got as unstructured from api server. only the services targeted by SmartLimiters
+// are read, and they are cached until the resourceVersion is changed
+type appProtocolCache struct {
+	reader client.Reader
+
+	sync.Mutex
+	// key is namespace/name of the service
+	services map[string]versionedAppProtocols
+}
+
+type versionedAppProtocols struct {
+	resourceVersion string
+	protocols       map[int32]string
+}
+
+func newAppProtocolCache(reader client.Reader) *appProtocolCache {
+	return &appProtocolCache{reader: reader, services: make(map[string]versionedAppProtocols)}
+}
+
+// get returns the appProtocol of the ports of service, the key is the service port
+func (c *appProtocolCache) get(svc *v1.Service) map[int32]string {
+	if c == nil || c.reader == nil {
+		return nil
+	}
+	key := svc.Namespace + "/" + svc.Name
+	c.Lock()
+	cached, ok := c.services[key]
+	c.Unlock()
+	if ok && cached.resourceVersion == svc.ResourceVersion {
+		return cached.protocols
+	}
+
+	u := unstructuredService()
+	if err := c.reader.Get(context.TODO(), types.NamespacedName{Namespace: svc.Namespace, Name: svc.Name}, u); err != nil {
+		log.Errorf("get appProtocol of service %s err, %+v", key, err)
+		return cached.protocols
+	}
+	protocols := appProtocolsOf(u)
+	c.Lock()
+	c.services[key] = versionedAppProtocols{resourceVersion: u.GetResourceVersion(), protocols: protocols}
+	c.Unlock()
+	return protocols
+}
+
+// forget deletes the cached appProtocol of the deleted service
+func (c *appProtocolCache) forget(key string) {
+	if c == nil {
+		return
+	}
+	c.Lock()
+	defer c.Unlock()
+	delete(c.services, key)
+}
+
+func unstructuredService() *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(v1.SchemeGroupVersion.WithKind("Service"))
+	return u
+}
+
+func appProtocolsOf(u *unstructured.Unstructured) map[int32]string {
+	ports, _, _ := unstructured.NestedSlice(u.Object, "spec", "ports")
+	protocols := make(map[int32]string)
+	for _, p := range ports {
+		port, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		number, _, _ := unstructured.NestedInt64(port, "port")
+		if protocol, _, _ := unstructured.NestedString(port, "appProtocol"); protocol != "" {
+			protocols[int32(number)] = protocol
+		}
+	}
+	return protocols
+}
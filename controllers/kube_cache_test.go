@@ -0,0 +1,143 @@
+package controllers
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	toolscache "k8s.io/client-go/tools/cache"
+)
+
+// serviceLister returns the lister of services, like the service informer
+func serviceLister(t *testing.T, services ...*v1.Service) corelisters.ServiceLister {
+	t.Helper()
+	indexer := toolscache.NewIndexer(toolscache.MetaNamespaceKeyFunc, toolscache.Indexers{toolscache.NamespaceIndex: toolscache.MetaNamespaceIndexFunc})
+	for _, svc := range services {
+		if err := indexer.Add(svc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return corelisters.NewServiceLister(indexer)
+}
+
+// newTestKubeCache returns the kube cache whose handlers are called by the test instead of informers
+func newTestKubeCache() (*kubeCache, toolscache.Indexer) {
+	pods := toolscache.NewIndexer(toolscache.MetaNamespaceKeyFunc, toolscache.Indexers{toolscache.NamespaceIndex: toolscache.MetaNamespaceIndexFunc})
+	endpoints := toolscache.NewIndexer(toolscache.MetaNamespaceKeyFunc, toolscache.Indexers{toolscache.NamespaceIndex: toolscache.MetaNamespaceIndexFunc})
+	return &kubeCache{
+		pods:        corelisters.NewPodLister(pods),
+		endpoints:   corelisters.NewEndpointsLister(endpoints),
+		selectors:   make(map[string]map[string]labels.Selector),
+		servicePods: make(map[string]map[string]struct{}),
+		hpas:        make(map[string]map[string]hpaScale),
+	}, pods
+}
+
+func testPod(name string, labels map[string]string) *v1.Pod {
+	return &v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Labels: labels}}
+}
+
+func sortedPodsOfService(kc *kubeCache, name string) ([]string, bool) {
+	pods, ok := kc.podsOfService("default", name)
+	sort.Strings(pods)
+	return pods, ok
+}
+
+func TestKubeCacheServicePods(t *testing.T) {
+	kc, pods := newTestKubeCache()
+	reviews := map[string]string{"app": "reviews"}
+	// the pods existing before the service are listed once the service is added
+	for _, pod := range []*v1.Pod{testPod("r1", reviews), testPod("p1", map[string]string{"app": "productpage"})} {
+		if err := pods.Add(pod); err != nil {
+			t.Fatal(err)
+		}
+	}
+	svc := policyService("reviews", nil, reviews)
+	kc.onService(svc, false)
+	kc.onService(policyService("external", nil, nil), false)
+
+	if got, _ := sortedPodsOfService(kc, "reviews"); !reflect.DeepEqual(got, []string{"r1"}) {
+		t.Errorf("got pods %v, want [r1]", got)
+	}
+
+	steps := []struct {
+		name    string
+		pod     interface{}
+		deleted bool
+		want    []string
+	}{
+		{"pod added", testPod("r2", reviews), false, []string{"r1", "r2"}},
+		{"other pod added", testPod("p2", map[string]string{"app": "productpage"}), false, []string{"r1", "r2"}},
+		{"labels changed", testPod("r1", map[string]string{"app": "other"}), false, []string{"r2"}},
+		{"pod deleted", testPod("r2", reviews), true, []string{}},
+		{"pod added back", testPod("r2", reviews), false, []string{"r2"}},
+		{"tombstone", toolscache.DeletedFinalStateUnknown{Key: "default/r2", Obj: testPod("r2", reviews)}, true, []string{}},
+	}
+	for _, step := range steps {
+		kc.onPod(step.pod, step.deleted)
+		if got, _ := sortedPodsOfService(kc, "reviews"); !reflect.DeepEqual(got, step.want) {
+			t.Errorf("%s: got pods %v, want %v", step.name, got, step.want)
+		}
+	}
+
+	// the service without selector selects no pod
+	if got, ok := sortedPodsOfService(kc, "external"); !ok || len(got) != 0 {
+		t.Errorf("got pods %v of service without selector", got)
+	}
+
+	kc.onService(toolscache.DeletedFinalStateUnknown{Key: "default/reviews", Obj: svc}, true)
+	if _, ok := kc.podsOfService("default", "reviews"); ok {
+		t.Errorf("pods of deleted service are kept")
+	}
+}
+
+func TestKubeCacheHpaReplicas(t *testing.T) {
+	kc, _ := newTestKubeCache()
+	hpa := func(name, target string, min *int32, max, desired int32) *autoscalingv1.HorizontalPodAutoscaler {
+		return &autoscalingv1.HorizontalPodAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			Spec: autoscalingv1.HorizontalPodAutoscalerSpec{
+				ScaleTargetRef: autoscalingv1.CrossVersionObjectReference{Kind: "Deployment", Name: target},
+				MinReplicas:    min,
+				MaxReplicas:    max,
+			},
+			Status: autoscalingv1.HorizontalPodAutoscalerStatus{DesiredReplicas: desired},
+		}
+	}
+	two := int32(2)
+	kc.onHpa(hpa("reviews", "reviews-v1", &two, 10, 3), false)
+	kc.onHpa(hpa("ratings", "ratings-v1", nil, 5, 1), false)
+	want := map[string][3]int32{"Deployment/reviews-v1": {2, 10, 3}, "Deployment/ratings-v1": {1, 5, 1}}
+	if got := kc.hpaReplicas("default"); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	kc.onHpa(hpa("reviews", "reviews-v1", &two, 10, 6), false)
+	kc.onHpa(toolscache.DeletedFinalStateUnknown{Key: "default/ratings", Obj: hpa("ratings", "ratings-v1", nil, 5, 1)}, true)
+	want = map[string][3]int32{"Deployment/reviews-v1": {2, 10, 6}}
+	if got := kc.hpaReplicas("default"); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := kc.hpaReplicas("other"); len(got) != 0 {
+		t.Errorf("got %v in other namespace", got)
+	}
+}
+
+func TestKubeCacheEndpointsEvents(t *testing.T) {
+	kc, _ := newTestKubeCache()
+	var got []types.NamespacedName
+	kc.onEndpoints = func(nn types.NamespacedName) { got = append(got, nn) }
+	ep := &v1.Endpoints{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "reviews"}}
+	kc.onEndpointsEvent(ep)
+	kc.onEndpointsEvent(toolscache.DeletedFinalStateUnknown{Key: "default/reviews", Obj: ep})
+	want := []types.NamespacedName{{Namespace: "default", Name: "reviews"}, {Namespace: "default", Name: "reviews"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,226 @@
+package controllers
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/json"
+	"slime.io/slime/framework/bootstrap"
+	"slime.io/slime/framework/model/metric"
+	microservicev1alpha2 "slime.io/slime/modules/limiter/api/v1alpha2"
+	"slime.io/slime/modules/limiter/model"
+)
+
+// metricSources returns the metric sources in module config, default is prometheus
+func metricSources(cfg *microservicev1alpha2.Limiter) []*microservicev1alpha2.MetricSource {
+	if cfg == nil || len(cfg.MetricSources) == 0 {
+		return []*microservicev1alpha2.MetricSource{{Type: microservicev1alpha2.MetricSource_prometheus}}
+	}
+	return cfg.MetricSources
+}
+
+func metricSourceEnabled(cfg *microservicev1alpha2.Limiter, typ microservicev1alpha2.MetricSource_Type) bool {
+	for _, s := range metricSources(cfg) {
+		if s.Type == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// newMetricSource creates the source of adaptive limits, the sources are combined if more than one is specified,
+// the failed prometheus handlers of each SmartLimiter are reported by onQueryErrors
+func newMetricSource(env bootstrap.Environment, cfg *microservicev1alpha2.Limiter, onQueryErrors func(meta string, errs map[string]string)) (metric.Source, error) {
+	sources := make([]metric.Source, 0)
+	for _, s := range metricSources(cfg) {
+		timeout := model.DefaultMetricSourceTimeout
+		if s.Timeout != nil && *s.Timeout > 0 {
+			timeout = *s.Timeout
+		}
+		switch s.Type {
+		case microservicev1alpha2.MetricSource_prometheus:
+			prometheusSourceConfig, err := newPrometheusSourceConfig(env, s, timeout)
+			if err != nil {
+				return nil, err
+			}
+			log.Infof("create new prometheus client success")
+			sources = append(sources, &isolatedSource{Source: metric.NewPrometheusSource(prometheusSourceConfig), onErrors: onQueryErrors})
+		case microservicev1alpha2.MetricSource_kubeMetrics:
+			if env.K8SClient == nil {
+				return nil, fmt.Errorf("kubernetes client is required by metric source %s", s.Type)
+			}
+			sources = append(sources, newKubeMetricsSource(env.K8SClient, timeout))
+		case microservicev1alpha2.MetricSource_envoyStats:
+			sources = append(sources, newEnvoyStatsSource(s, timeout))
+		case microservicev1alpha2.MetricSource_limiterStats:
+			sources = append(sources, newLimiterStatsSource(s, timeout))
+		case microservicev1alpha2.MetricSource_static:
+			if s.StaticFile == "" {
+				return nil, fmt.Errorf("staticFile is required by metric source %s", s.Type)
+			}
+			sources = append(sources, newStaticSource(s.StaticFile))
+		default:
+			return nil, fmt.Errorf("unknown metric source %s", s.Type)
+		}
+		log.Infof("metric source %s is enabled", s.Type)
+	}
+	// a composite source is used even if there is only one source, it keeps the meta without handlers,
+	// so the number of pods is refreshed even if no handler is referenced
+	return &compositeSource{sources: sources}, nil
+}
+
+// compositeSource queries all the sources and merges the results, one unavailable source will not block the
+// others. the metas are returned even if all the sources fail, so the pod counts are still refreshed and the
+// metrics which are not updated become stale and fall back by the fallback policy of descriptors
+type compositeSource struct {
+	sources []metric.Source
+}
+
+func (s *compositeSource) Start() error {
+	for _, source := range s.sources {
+		if err := source.Start(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *compositeSource) QueryMetric(queryMap metric.QueryMap) (metric.Metric, error) {
+	result := make(map[string][]metric.Result)
+	for meta := range queryMap {
+		result[meta] = []metric.Result{}
+	}
+	failed := 0
+	for _, source := range s.sources {
+		m, err := source.QueryMetric(queryMap)
+		if err != nil {
+			log.Errorf("query metric err, %+v", err)
+			failed++
+			continue
+		}
+		for meta, results := range m {
+			result[meta] = append(result[meta], results...)
+		}
+	}
+	if failed > 0 && failed == len(s.sources) {
+		log.Errorf("all the %d metric sources fail, only the pods are refreshed", failed)
+	}
+	return result, nil
+}
+
+// isolatedSource queries each handler of each SmartLimiter on its own. the prometheus source aborts on the
+// first failed query, so a malformed query of one SmartLimiter would stop the metrics of all the others.
+// the failed handlers are reported by onErrors, it fails only if all the queries fail
+type isolatedSource struct {
+	metric.Source
+	onErrors func(meta string, errs map[string]string)
+}
+
+func (s *isolatedSource) QueryMetric(queryMap metric.QueryMap) (metric.Metric, error) {
+	result := make(map[string][]metric.Result)
+	var lastErr error
+	total, failed := 0, 0
+	for meta, handlers := range queryMap {
+		errs := make(map[string]string)
+		for _, handler := range handlers {
+			total++
+			m, err := s.Source.QueryMetric(metric.QueryMap{meta: {handler}})
+			if err != nil {
+				log.Errorf("query handler %s err, %+v", handler.Name, err)
+				errs[handler.Name] = err.Error()
+				lastErr = err
+				failed++
+				continue
+			}
+			result[meta] = append(result[meta], m[meta]...)
+		}
+		if s.onErrors != nil {
+			s.onErrors(meta, errs)
+		}
+	}
+	if failed > 0 && failed == total {
+		return nil, lastErr
+	}
+	return result, nil
+}
+
+// recordQueryErrors records the failed prometheus handlers of the SmartLimiter in meta, they are reported in status
+func (r *SmartLimiterReconciler) recordQueryErrors(meta string, errs map[string]string) {
+	m, err := parseStaticMeta(meta)
+	if err != nil {
+		log.Errorf("%+v", err)
+		return
+	}
+	qs := r.getQuotaStates(types.NamespacedName{Namespace: m.Namespace, Name: m.Name})
+	qs.Lock()
+	defer qs.Unlock()
+	qs.queryErrors = errs
+}
+
+// queryErrors returns the failed prometheus handlers of the SmartLimiter in the latest query
+func (r *SmartLimiterReconciler) queryErrors(loc types.NamespacedName) map[string]string {
+	qs := r.getQuotaStates(loc)
+	qs.Lock()
+	defer qs.Unlock()
+	if len(qs.queryErrors) == 0 {
+		return nil
+	}
+	errs := make(map[string]string, len(qs.queryErrors))
+	for k, v := range qs.queryErrors {
+		errs[k] = v
+	}
+	return errs
+}
+
+// producerSources are the metric sources of the watcher and ticker producers, each producer has its own
+// sources since some of them, e.g. limiterStats, report the increments since the last query
+type producerSources struct {
+	watcher, ticker metric.Source
+}
+
+// startProducers is the same as metric.NewProducer, except that the sources are specified
+func startProducers(config *metric.ProducerConfig, sources producerSources) {
+	var wp *metric.WatcherProducer
+	var tp *metric.TickerProducer
+
+	if config.EnableWatcherProducer {
+		wp = metric.NewWatcherProducer(config.WatcherProducerConfig, sources.watcher)
+		wp.Start()
+		go wp.HandleWatcherEvent()
+	}
+
+	if config.EnableTickerProducer {
+		tp = metric.NewTickerProducer(config.TickerProducerConfig, sources.ticker)
+		tp.Start()
+		go tp.HandleTickerEvent()
+	}
+
+	// stop producers
+	go func() {
+		<-config.StopChan
+		if config.EnableWatcherProducer {
+			wp.Stop()
+		}
+		if config.EnableTickerProducer {
+			tp.Stop()
+		}
+		log.Infof("all producers stopped")
+	}()
+}
+
+// subsetResult returns a non-group result of the subset
+func subsetResult(subset, name string, value string) metric.Result {
+	n := subset + "." + name
+	return metric.Result{
+		Name:  n,
+		Value: map[string]string{n: value},
+	}
+}
+
+func parseStaticMeta(meta string) (*StaticMeta, error) {
+	m := &StaticMeta{}
+	if err := json.Unmarshal([]byte(meta), m); err != nil {
+		return nil, fmt.Errorf("unmarshal static meta info err, %+v", err.Error())
+	}
+	return m, nil
+}
@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+	"slime.io/slime/framework/model/metric"
+)
+
+// querySource fails on the query bad like the prometheus source, which aborts on the first failed query
+type querySource struct{}
+
+func (querySource) Start() error { return nil }
+
+func (querySource) QueryMetric(queryMap metric.QueryMap) (metric.Metric, error) {
+	m := make(map[string][]metric.Result)
+	for meta, handlers := range queryMap {
+		for _, handler := range handlers {
+			if handler.Query == "bad" {
+				return nil, fmt.Errorf("parse error")
+			}
+			m[meta] = append(m[meta], metric.Result{Name: handler.Name, Value: map[string]string{handler.Name: "1"}})
+		}
+	}
+	return m, nil
+}
+
+func TestIsolatedSource(t *testing.T) {
+	good := StaticMeta{Namespace: "default", Name: "good"}.String()
+	bad := StaticMeta{Namespace: "default", Name: "bad"}.String()
+	errs := make(map[string]map[string]string)
+	s := &isolatedSource{Source: querySource{}, onErrors: func(meta string, e map[string]string) { errs[meta] = e }}
+
+	m, err := s.QueryMetric(metric.QueryMap{
+		good: {{Name: "cpu.max", Query: "max(cpu)"}},
+		bad:  {{Name: "broken", Query: "bad"}, {Name: "cpu.max", Query: "max(cpu)"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, meta := range []string{good, bad} {
+		if len(m[meta]) != 1 || m[meta][0].Name != "cpu.max" {
+			t.Errorf("got results %v of %s, want cpu.max", m[meta], meta)
+		}
+	}
+	if len(errs[good]) != 0 {
+		t.Errorf("got errors %v of the good limiter", errs[good])
+	}
+	if _, ok := errs[bad]["broken"]; !ok || len(errs[bad]) != 1 {
+		t.Errorf("got errors %v of the bad limiter, want broken", errs[bad])
+	}
+
+	if _, err := s.QueryMetric(metric.QueryMap{bad: {{Name: "broken", Query: "bad"}}}); err == nil {
+		t.Errorf("should fail if all the queries fail")
+	}
+}
+
+func TestRecordQueryErrors(t *testing.T) {
+	r := newRefreshReconciler(t)
+	loc := types.NamespacedName{Namespace: "default", Name: "reviews"}
+	meta := StaticMeta{Namespace: loc.Namespace, Name: loc.Name}.String()
+
+	r.recordQueryErrors(meta, map[string]string{"broken": "parse error"})
+	if got, want := r.queryErrors(loc), map[string]string{"broken": "parse error"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	r.recordQueryErrors(meta, map[string]string{})
+	if got := r.queryErrors(loc); got != nil {
+		t.Errorf("got %v after the query succeeds", got)
+	}
+}
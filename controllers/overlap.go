@@ -0,0 +1,365 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	microservicev1alpha2 "slime.io/slime/modules/limiter/api/v1alpha2"
+	"slime.io/slime/modules/limiter/model"
+)
+
+// routeMerge is the result of merging the local limits of SmartLimiters on the same route of the same workloads.
+// envoy keeps only one local rate limit config of a route, so the one with the largest priority generates it
+// with the descriptors of all of them, and the others only generate the rate limit actions
+type routeMerge struct {
+	// key is the route, value is the SmartLimiter generating the local rate limit config of the route
+	owner map[string]types.NamespacedName
+	// key is the route, value is the descriptors of the other SmartLimiters merged into this one
+	merged map[string][]mergedDescriptors
+	// key is the route, value describes the merge, it is reported in status
+	conflicts map[string]string
+}
+
+type mergedDescriptors struct {
+	scope       valueScope
+	descriptors []*microservicev1alpha2.SmartLimitDescriptor
+}
+
+func (m *routeMerge) ownedByOthers(route string) bool {
+	if m == nil {
+		return false
+	}
+	_, ok := m.owner[route]
+	return ok
+}
+
+func (m *routeMerge) mergedDescriptors(route string) []mergedDescriptors {
+	if m == nil {
+		return nil
+	}
+	return m.merged[route]
+}
+
+// localRoutes returns the routes of the local descriptors
+func localRoutes(descriptors []*microservicev1alpha2.SmartLimitDescriptor) map[string][]*microservicev1alpha2.SmartLimitDescriptor {
+	routes := make(map[string][]*microservicev1alpha2.SmartLimitDescriptor)
+	for _, des := range descriptors {
+		if des == nil || des.Action == nil || des.Action.Strategy == model.GlobalSmartLimiter {
+			continue
+		}
+		for _, rc := range generateRouteConfigs(des.Target) {
+			vr := genVhostRouteName(rc)
+			routes[vr] = append(routes[vr], des)
+		}
+	}
+	return routes
+}
+
+func specDescriptors(sets map[string]*microservicev1alpha2.SmartLimitDescriptors) []*microservicev1alpha2.SmartLimitDescriptor {
+	descriptors := make([]*microservicev1alpha2.SmartLimitDescriptor, 0)
+	for _, set := range sets {
+		if set != nil {
+			descriptors = append(descriptors, set.Descriptor_...)
+		}
+	}
+	return descriptors
+}
+
+// localRouteIndex indexes the SmartLimiters by the routes of their local descriptors in spec, so the ones
+// overlapping with a SmartLimiter are found without listing all of them
+func localRouteIndex(obj runtime.Object) []string {
+	instance, ok := obj.(*microservicev1alpha2.SmartLimiter)
+	if !ok {
+		return nil
+	}
+	routes := localRoutes(specDescriptors(instance.Spec.Sets))
+	keys := make([]string, 0, len(routes))
+	for vr := range routes {
+		keys = append(keys, vr)
+	}
+	return keys
+}
+
+func newRouteMerge() *routeMerge {
+	return &routeMerge{
+		owner:     make(map[string]types.NamespacedName),
+		merged:    make(map[string][]mergedDescriptors),
+		conflicts: make(map[string]string),
+	}
+}
+
+// mergeRoutes detects the SmartLimiters which have local limits on the same routes as the SmartLimiter and
+// target the same pods, the routes are compared by the descriptors in spec. the ones targeting only part of
+// the pods are not merged, since the merged config is applied by the workload selector of the owner
+func (r *SmartLimiterReconciler) mergeRoutes(spec microservicev1alpha2.SmartLimiterSpec, loc types.NamespacedName, target limitTarget) *routeMerge {
+	own := localRoutes(specDescriptors(spec.Sets))
+	if len(own) == 0 {
+		return newRouteMerge()
+	}
+	candidates := r.routeCandidates(loc, own)
+	if len(candidates) == 0 {
+		return newRouteMerge()
+	}
+	same, partial := r.overlappingLimiters(target, candidates)
+	merge := r.buildRouteMerge(spec, loc, own, same)
+	for _, other := range partial {
+		for vr := range localRoutes(specDescriptors(other.Spec.Sets)) {
+			if _, ok := own[vr]; !ok {
+				continue
+			}
+			conflict := fmt.Sprintf("overlaps with %s on part of the pods, not merged", other.Name)
+			if merge.conflicts[vr] != "" {
+				conflict = merge.conflicts[vr] + "; " + conflict
+			}
+			merge.conflicts[vr] = conflict
+		}
+	}
+	return merge
+}
+
+// overlappingLimiters returns the candidates selecting the same workloads as the target, and the ones selecting
+// only part of the pods of the target, or some other pods besides. the workloads are the same if the selectors
+// and the subsets are the same
+func (r *SmartLimiterReconciler) overlappingLimiters(target limitTarget, candidates []*microservicev1alpha2.SmartLimiter) (
+	same, partial []*microservicev1alpha2.SmartLimiter) {
+	pods, err := queryServicePods(r.kubeCache, target)
+	if err != nil || len(pods) == 0 {
+		return nil, nil
+	}
+	ownPods := make(map[string]struct{}, len(pods))
+	for _, pod := range pods {
+		ownPods[pod.Name] = struct{}{}
+	}
+	selector, err := workloadLabels(r.kubeCache, target)
+	if err != nil {
+		return nil, nil
+	}
+
+	for _, other := range candidates {
+		otherTarget, err := resolveTarget(other.Spec, types.NamespacedName{Namespace: other.Namespace, Name: other.Name})
+		if err != nil || !r.targetsOverlap(otherTarget, ownPods) {
+			continue
+		}
+		otherSelector, err := workloadLabels(r.kubeCache, otherTarget)
+		if err == nil && labels.Equals(selector, otherSelector) && target.host() == otherTarget.host() {
+			same = append(same, other)
+		} else {
+			partial = append(partial, other)
+		}
+	}
+	return same, partial
+}
+
+// workloadLabels returns the labels selecting the workloads of the target
+func workloadLabels(kc *kubeCache, target limitTarget) (map[string]string, error) {
+	if target.service == "" {
+		return target.labels, nil
+	}
+	services, err := target.services(kc)
+	if err != nil {
+		return nil, err
+	}
+	return services[0].Spec.Selector, nil
+}
+
+// routeCandidates returns the other SmartLimiters having local limits on any of the routes by the route index,
+// the ones being deleted are skipped
+func (r *SmartLimiterReconciler) routeCandidates(loc types.NamespacedName, routes map[string][]*microservicev1alpha2.SmartLimitDescriptor) []*microservicev1alpha2.SmartLimiter {
+	found := make(map[string]*microservicev1alpha2.SmartLimiter)
+	for vr := range routes {
+		list := &microservicev1alpha2.SmartLimiterList{}
+		if err := r.Client.List(context.TODO(), list, client.InNamespace(loc.Namespace),
+			client.MatchingFields{model.IndexLocalRoute: vr}); err != nil {
+			log.Errorf("list smartlimiters of route %s in %s err, %+v", vr, loc.Namespace, err)
+			continue
+		}
+		for i := range list.Items {
+			other := &list.Items[i]
+			if other.Name == loc.Name || other.DeletionTimestamp != nil {
+				continue
+			}
+			found[other.Name] = other
+		}
+	}
+	candidates := make([]*microservicev1alpha2.SmartLimiter, 0, len(found))
+	for _, other := range found {
+		candidates = append(candidates, other)
+	}
+	return candidates
+}
+
+// buildRouteMerge merges the SmartLimiter with the overlapping ones on the common routes, the merged descriptors
+// are the calculated ones of the others
+func (r *SmartLimiterReconciler) buildRouteMerge(spec microservicev1alpha2.SmartLimiterSpec, loc types.NamespacedName,
+	own map[string][]*microservicev1alpha2.SmartLimitDescriptor, others []*microservicev1alpha2.SmartLimiter) *routeMerge {
+	merge := newRouteMerge()
+	// key is the route, value is the overlapping SmartLimiters
+	participants := make(map[string][]*microservicev1alpha2.SmartLimiter)
+	for _, other := range others {
+		for vr := range localRoutes(specDescriptors(other.Spec.Sets)) {
+			if _, ok := own[vr]; ok {
+				participants[vr] = append(participants[vr], other)
+			}
+		}
+	}
+
+	for vr, others := range participants {
+		sort.Slice(others, func(i, j int) bool {
+			return precedes(others[i].Spec.Priority, others[i].Name, others[j].Spec.Priority, others[j].Name)
+		})
+		winner := others[0]
+		if !precedes(spec.Priority, loc.Name, winner.Spec.Priority, winner.Name) {
+			merge.owner[vr] = types.NamespacedName{Namespace: winner.Namespace, Name: winner.Name}
+			merge.conflicts[vr] = fmt.Sprintf("merged into %s", winner.Name)
+			continue
+		}
+		names := make([]string, 0, len(others))
+		for _, other := range others {
+			names = append(names, other.Name)
+			if descriptors := r.calculatedLocalDescriptors(other, vr); len(descriptors) > 0 {
+				merge.merged[vr] = append(merge.merged[vr], mergedDescriptors{
+					scope:       limiterValueScope(other),
+					descriptors: descriptors,
+				})
+			}
+		}
+		merge.conflicts[vr] = fmt.Sprintf("merged %s", strings.Join(names, ","))
+	}
+	return merge
+}
+
+// calculatedLocalDescriptors returns the descriptors of the route calculated in the latest refresh of other,
+// the status of other is used if it is not refreshed yet, e.g. after restart
+func (r *SmartLimiterReconciler) calculatedLocalDescriptors(other *microservicev1alpha2.SmartLimiter, vr string) []*microservicev1alpha2.SmartLimitDescriptor {
+	if i, ok := r.quotaStates.Get(other.Namespace + "/" + other.Name); ok {
+		qs := i.(*quotaStates)
+		qs.Lock()
+		descriptors, ok := qs.mergedInto[vr]
+		qs.Unlock()
+		if ok {
+			return descriptors
+		}
+	}
+	return localRoutes(specDescriptors(other.Status.RatelimitStatus))[vr]
+}
+
+// precedes tells whether the SmartLimiter a takes precedence over b
+func precedes(priorityA int32, nameA string, priorityB int32, nameB string) bool {
+	if priorityA != priorityB {
+		return priorityA > priorityB
+	}
+	return nameA < nameB
+}
+
+func (r *SmartLimiterReconciler) targetsOverlap(target limitTarget, pods map[string]struct{}) bool {
+	targetPods, err := queryServicePods(r.kubeCache, target)
+	if err != nil {
+		return false
+	}
+	for _, pod := range targetPods {
+		if _, ok := pods[pod.Name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *SmartLimiterReconciler) setRouteMerge(loc types.NamespacedName, merge *routeMerge) {
+	qs := r.getQuotaStates(loc)
+	qs.Lock()
+	defer qs.Unlock()
+	qs.merge = merge
+}
+
+// routeConflicts returns the conflicts reported in status
+func (r *SmartLimiterReconciler) routeConflicts(loc types.NamespacedName) map[string]string {
+	qs := r.getQuotaStates(loc)
+	qs.Lock()
+	defer qs.Unlock()
+	if qs.merge == nil || len(qs.merge.conflicts) == 0 {
+		return nil
+	}
+	conflicts := make(map[string]string, len(qs.merge.conflicts))
+	for k, v := range qs.merge.conflicts {
+		conflicts[k] = v
+	}
+	return conflicts
+}
+
+// recordMergedDescriptors records the calculated descriptors of the routes merged into other SmartLimiters, and
+// returns the SmartLimiters which should be refreshed since the descriptors merged into them are changed
+func (r *SmartLimiterReconciler) recordMergedDescriptors(loc types.NamespacedName,
+	calculated map[string]*microservicev1alpha2.SmartLimitDescriptors) map[types.NamespacedName]struct{} {
+	routes := localRoutes(specDescriptors(calculated))
+	qs := r.getQuotaStates(loc)
+	qs.Lock()
+	defer qs.Unlock()
+
+	var mergedOwner map[string]types.NamespacedName
+	if qs.merge != nil {
+		mergedOwner = qs.merge.owner
+	}
+	owners := make(map[types.NamespacedName]struct{})
+	mergedInto := make(map[string][]*microservicev1alpha2.SmartLimitDescriptor, len(mergedOwner))
+	for vr, owner := range mergedOwner {
+		mergedInto[vr] = routes[vr]
+		if old, ok := qs.mergedInto[vr]; !ok || qs.mergedOwner[vr] != owner || !equalDescriptors(old, routes[vr]) {
+			owners[owner] = struct{}{}
+		}
+	}
+	// the owners of the routes no longer merged into them
+	for vr, owner := range qs.mergedOwner {
+		if mergedOwner[vr] != owner {
+			owners[owner] = struct{}{}
+		}
+	}
+	qs.mergedInto, qs.mergedOwner = mergedInto, mergedOwner
+	return owners
+}
+
+func equalDescriptors(a, b []*microservicev1alpha2.SmartLimitDescriptor) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !proto.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// mergedOwners returns the SmartLimiters which the local limits of loc are merged into
+func (r *SmartLimiterReconciler) mergedOwners(loc types.NamespacedName) map[types.NamespacedName]struct{} {
+	owners := make(map[types.NamespacedName]struct{})
+	i, ok := r.quotaStates.Get(loc.Namespace + "/" + loc.Name)
+	if !ok {
+		return owners
+	}
+	qs := i.(*quotaStates)
+	qs.Lock()
+	defer qs.Unlock()
+	for _, owner := range qs.mergedOwner {
+		owners[owner] = struct{}{}
+	}
+	return owners
+}
+
+// refreshRouteOwners refreshes the SmartLimiters which the local limits are merged into, so that they pick up
+// the updated descriptors. it is done out of the current refresh since the refreshes of a SmartLimiter are serialized
+func (r *SmartLimiterReconciler) refreshRouteOwners(owners map[types.NamespacedName]struct{}) {
+	for owner := range owners {
+		owner := owner
+		time.AfterFunc(model.MergeRefreshDelay, func() {
+			r.refreshLimiter(owner)
+		})
+	}
+}
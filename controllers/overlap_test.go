@@ -0,0 +1,219 @@
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	cmap "github.com/orcaman/concurrent-map"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	microservicev1alpha2 "slime.io/slime/modules/limiter/api/v1alpha2"
+)
+
+func routeDescriptor(quota string, routes ...string) *microservicev1alpha2.SmartLimitDescriptor {
+	return &microservicev1alpha2.SmartLimitDescriptor{
+		Action: &microservicev1alpha2.SmartLimitDescriptor_Action{Quota: quota, FillInterval: &microservicev1alpha2.Duration{Seconds: 1}},
+		Target: &microservicev1alpha2.SmartLimitDescriptor_Target{Route: routes},
+	}
+}
+
+func routeLimiter(name string, priority int32, spec, status *microservicev1alpha2.SmartLimitDescriptor) *microservicev1alpha2.SmartLimiter {
+	sl := &microservicev1alpha2.SmartLimiter{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+		Spec: microservicev1alpha2.SmartLimiterSpec{
+			Priority: priority,
+			Sets: map[string]*microservicev1alpha2.SmartLimitDescriptors{
+				"_base": {Descriptor_: []*microservicev1alpha2.SmartLimitDescriptor{spec}},
+			},
+		},
+	}
+	if status != nil {
+		sl.Status.RatelimitStatus = map[string]*microservicev1alpha2.SmartLimitDescriptors{
+			"_base": {Descriptor_: []*microservicev1alpha2.SmartLimitDescriptor{status}},
+		}
+	}
+	return sl
+}
+
+func TestBuildRouteMergeOrdering(t *testing.T) {
+	const vr = "vh/r1"
+	others := func() []*microservicev1alpha2.SmartLimiter {
+		return []*microservicev1alpha2.SmartLimiter{
+			routeLimiter("a", 0, routeDescriptor("{{._base.rt99}}", vr), routeDescriptor("1", vr)),
+			routeLimiter("c", 1, routeDescriptor("3", vr), routeDescriptor("3", vr)),
+			routeLimiter("b", 1, routeDescriptor("2", vr), routeDescriptor("2", vr)),
+			// not calculated yet
+			routeLimiter("d", 0, routeDescriptor("4", vr), nil),
+			// no common route
+			routeLimiter("e", 9, routeDescriptor("5", "vh/r2"), routeDescriptor("5", "vh/r2")),
+		}
+	}
+
+	cases := []struct {
+		name      string
+		self      string
+		priority  int32
+		wantOwner string
+		wantNames []string
+		conflict  string
+	}{
+		{"priority wins", "m", 2, "", []string{"b", "c", "a"}, "merged b,c,a,d"},
+		{"name wins with same priority", "aa", 1, "", []string{"b", "c", "a"}, "merged b,c,a,d"},
+		{"loses by name", "m", 1, "b", nil, "merged into b"},
+		{"loses by priority", "a0", 0, "b", nil, "merged into b"},
+	}
+	for _, c := range cases {
+		r := &SmartLimiterReconciler{quotaStates: cmap.New()}
+		spec := routeLimiter(c.self, c.priority, routeDescriptor("10", vr), nil).Spec
+		own := localRoutes(specDescriptors(spec.Sets))
+		merge := r.buildRouteMerge(spec, types.NamespacedName{Namespace: "default", Name: c.self}, own, others())
+
+		if got := merge.owner[vr].Name; got != c.wantOwner {
+			t.Errorf("%s: got owner %q, want %q", c.name, got, c.wantOwner)
+		}
+		var names []string
+		for _, m := range merge.merged[vr] {
+			names = append(names, m.scope.limiter.Name)
+		}
+		if strings.Join(names, ",") != strings.Join(c.wantNames, ",") {
+			t.Errorf("%s: got merged %v, want %v", c.name, names, c.wantNames)
+		}
+		if merge.conflicts[vr] != c.conflict {
+			t.Errorf("%s: got conflict %q, want %q", c.name, merge.conflicts[vr], c.conflict)
+		}
+		if _, ok := merge.conflicts["vh/r2"]; ok {
+			t.Errorf("%s: route vh/r2 is not common but merged", c.name)
+		}
+	}
+}
+
+func TestOverlappingLimiters(t *testing.T) {
+	kc, pods := newTestKubeCache()
+	for _, pod := range []*v1.Pod{
+		testPod("r1", map[string]string{"app": "reviews", "version": "v1"}),
+		testPod("r2", map[string]string{"app": "reviews", "version": "v2"}),
+		testPod("p1", map[string]string{"app": "productpage"}),
+	} {
+		if err := pods.Add(pod); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := &SmartLimiterReconciler{kubeCache: kc}
+	selected := func(name string, labels map[string]string) *microservicev1alpha2.SmartLimiter {
+		sl := routeLimiter(name, 0, routeDescriptor("10", "vh/r1"), nil)
+		sl.Spec.WorkloadSelector = &microservicev1alpha2.WorkloadSelector{Labels: labels}
+		return sl
+	}
+	target := limitTarget{namespace: "default", labels: map[string]string{"app": "reviews"}}
+	same, partial := r.overlappingLimiters(target, []*microservicev1alpha2.SmartLimiter{
+		selected("same", map[string]string{"app": "reviews"}),
+		selected("subset", map[string]string{"app": "reviews", "version": "v1"}),
+		selected("other", map[string]string{"app": "productpage"}),
+	})
+	names := func(limiters []*microservicev1alpha2.SmartLimiter) string {
+		var names []string
+		for _, sl := range limiters {
+			names = append(names, sl.Name)
+		}
+		return strings.Join(names, ",")
+	}
+	if got := names(same); got != "same" {
+		t.Errorf("got same %s, want same", got)
+	}
+	// the pods only in the target keep their own limit, so they are not merged
+	if got := names(partial); got != "subset" {
+		t.Errorf("got partial %s, want subset", got)
+	}
+}
+
+func TestBuildRouteMergePrefersCalculatedDescriptors(t *testing.T) {
+	const vr = "vh/r1"
+	r := &SmartLimiterReconciler{quotaStates: cmap.New()}
+	other := routeLimiter("b", 0, routeDescriptor("{{._base.rt99}}", vr), routeDescriptor("1", vr))
+	spec := routeLimiter("a", 1, routeDescriptor("10", vr), nil).Spec
+	own := localRoutes(specDescriptors(spec.Sets))
+	loc := types.NamespacedName{Namespace: "default", Name: "a"}
+
+	merge := r.buildRouteMerge(spec, loc, own, []*microservicev1alpha2.SmartLimiter{other})
+	if got := merge.merged[vr][0].descriptors[0].Action.Quota; got != "1" {
+		t.Errorf("got quota %s, want the one in status before b is refreshed", got)
+	}
+
+	r.getQuotaStates(types.NamespacedName{Namespace: "default", Name: "b"}).mergedInto = map[string][]*microservicev1alpha2.SmartLimitDescriptor{
+		vr: {routeDescriptor("7", vr)},
+	}
+	merge = r.buildRouteMerge(spec, loc, own, []*microservicev1alpha2.SmartLimiter{other})
+	if got := merge.merged[vr][0].descriptors[0].Action.Quota; got != "7" {
+		t.Errorf("got quota %s, want the one calculated in the latest refresh of b", got)
+	}
+}
+
+func TestRecordMergedDescriptors(t *testing.T) {
+	const vr = "vh/r1"
+	r := &SmartLimiterReconciler{quotaStates: cmap.New()}
+	loc := types.NamespacedName{Namespace: "default", Name: "b"}
+	owner := types.NamespacedName{Namespace: "default", Name: "a"}
+	calculated := func(quota string) map[string]*microservicev1alpha2.SmartLimitDescriptors {
+		return map[string]*microservicev1alpha2.SmartLimitDescriptors{
+			"_base": {Descriptor_: []*microservicev1alpha2.SmartLimitDescriptor{routeDescriptor(quota, vr)}},
+		}
+	}
+	merged := &routeMerge{owner: map[string]types.NamespacedName{vr: owner}}
+
+	steps := []struct {
+		name       string
+		merge      *routeMerge
+		quota      string
+		wantOwners int
+	}{
+		{"first merged", merged, "1", 1},
+		{"unchanged", merged, "1", 0},
+		{"quota changed", merged, "2", 1},
+		{"unchanged again", merged, "2", 0},
+		{"no longer merged", nil, "2", 1},
+		{"still not merged", nil, "2", 0},
+	}
+	for _, s := range steps {
+		r.setRouteMerge(loc, s.merge)
+		owners := r.recordMergedDescriptors(loc, calculated(s.quota))
+		if len(owners) != s.wantOwners {
+			t.Errorf("%s: got owners %v, want %d", s.name, owners, s.wantOwners)
+		}
+		if _, ok := owners[owner]; s.wantOwners > 0 && !ok {
+			t.Errorf("%s: got owners %v, want %s", s.name, owners, owner)
+		}
+	}
+	if owners := r.mergedOwners(loc); len(owners) != 0 {
+		t.Errorf("got merged owners %v, want none", owners)
+	}
+}
+
+func TestLocalRateLimitPerFilterPatchMergedRoute(t *testing.T) {
+	loc := types.NamespacedName{Namespace: "default", Name: "a"}
+	otherScope := valueScope{service: loc, limiter: types.NamespacedName{Namespace: "default", Name: "b"}}
+	merged := routeDescriptor("5", "vh/r2")
+	merge := &routeMerge{merged: map[string][]mergedDescriptors{
+		"vh/r2": {{scope: otherScope, descriptors: []*microservicev1alpha2.SmartLimitDescriptor{merged}}},
+	}}
+
+	patches := generateLocalRateLimitPerFilterPatch([]*microservicev1alpha2.SmartLimitDescriptor{routeDescriptor("10", "vh/r1")},
+		valueScope{service: loc, limiter: loc}, merge)
+	if len(patches) != 2 {
+		t.Fatalf("got %d patches, want 2", len(patches))
+	}
+	found := false
+	for _, patch := range patches {
+		route := patch.Match.GetRouteConfiguration().GetVhost()
+		if route.GetName() != "vh" || route.GetRoute().GetName() != "r2" {
+			continue
+		}
+		found = true
+		if !strings.Contains(patch.Patch.Value.String(), generateDescriptorValue(merged, otherScope)) {
+			t.Errorf("the route config of r2 does not contain the merged descriptor, %s", patch.Patch.Value)
+		}
+	}
+	if !found {
+		t.Errorf("no route config is generated for the merged route r2 without own descriptors")
+	}
+}
@@ -0,0 +1,190 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"slime.io/slime/framework/apis/networking/v1alpha3"
+	microservicev1alpha2 "slime.io/slime/modules/limiter/api/v1alpha2"
+	"slime.io/slime/modules/limiter/model"
+)
+
+// generatedLabels returns the labels of the EnvoyFilter generated for the set of the SmartLimiter,
+// the name and set are only labeled if they are valid label values
+func generatedLabels(instance *microservicev1alpha2.SmartLimiter, set string) map[string]string {
+	labels := map[string]string{model.LabelManagedBy: model.LabelManagedByValue}
+	if len(validation.IsValidLabelValue(instance.Name)) == 0 {
+		labels[model.LabelSmartLimiter] = instance.Name
+	}
+	if len(validation.IsValidLabelValue(set)) == 0 {
+		labels[model.LabelLimiterSet] = set
+	}
+	return labels
+}
+
+func generatedAnnotations(instance *microservicev1alpha2.SmartLimiter) map[string]string {
+	return map[string]string{
+		model.AnnotationSmartLimiter: instance.Namespace + "/" + instance.Name,
+		model.AnnotationGeneration:   strconv.FormatInt(instance.Generation, 10),
+	}
+}
+
+// descriptorOwners returns the owners of the global descriptors, the set of a descriptor is found by its value
+func descriptorOwners(instance *microservicev1alpha2.SmartLimiter, descriptors map[string]*microservicev1alpha2.SmartLimitDescriptors,
+	gdesc []*model.Descriptor) map[string]model.DescriptorOwner {
+	scope := limiterValueScope(instance)
+	sets := make(map[string]string)
+	for set, desc := range descriptors {
+		if desc == nil {
+			continue
+		}
+		for _, item := range desc.Descriptor_ {
+			sets[generateDescriptorValue(item, scope)] = set
+		}
+	}
+	owners := make(map[string]model.DescriptorOwner, len(gdesc))
+	for _, item := range gdesc {
+		owners[item.Value] = model.DescriptorOwner{
+			SmartLimiter: instance.Namespace + "/" + instance.Name,
+			Set:          sets[item.Value],
+			Generation:   instance.Generation,
+		}
+	}
+	return owners
+}
+
+// ownsDescriptor tells whether the global descriptor value in configmap is generated by the SmartLimiter, the
+// recorded owner should be the SmartLimiter, and the value generated before the owners are recorded should
+// start with Service[<name>.<namespace>]-
+func ownsDescriptor(value string, owners map[string]model.DescriptorOwner, loc types.NamespacedName) bool {
+	if owner, ok := owners[value]; ok {
+		return owner.SmartLimiter == loc.Namespace+"/"+loc.Name
+	}
+	return strings.HasPrefix(value, descriptorValuePrefix(valueScope{service: loc, limiter: loc}))
+}
+
+func parseDescriptorOwners(cm *v1.ConfigMap) map[string]model.DescriptorOwner {
+	owners := make(map[string]model.DescriptorOwner)
+	if s := cm.Annotations[model.AnnotationDescriptorOwners]; s != "" {
+		if err := json.Unmarshal([]byte(s), &owners); err != nil {
+			log.Errorf("unmarshal descriptor owners of configmap %s/%s err, %+v", cm.Namespace, cm.Name, err)
+		}
+	}
+	return owners
+}
+
+func descriptorOwnersAnnotations(owners map[string]model.DescriptorOwner) map[string]string {
+	if len(owners) == 0 {
+		return nil
+	}
+	b, err := json.Marshal(owners)
+	if err != nil {
+		log.Errorf("marshal descriptor owners err, %+v", err)
+		return nil
+	}
+	return map[string]string{model.AnnotationDescriptorOwners: string(b)}
+}
+
+// generatedByLimiter tells whether the EnvoyFilter is generated by limiter, the ones generated
+// before the labels are added are recognized by the owner reference
+func generatedByLimiter(ef *v1alpha3.EnvoyFilter) bool {
+	if ef.Labels[model.LabelManagedBy] == model.LabelManagedByValue {
+		return true
+	}
+	ref := metav1.GetControllerOf(ef)
+	return ref != nil && ref.Kind == "SmartLimiter" && strings.HasPrefix(ref.APIVersion, microservicev1alpha2.GroupVersion.Group+"/")
+}
+
+// Teardown deletes all the config generated by limiter, it runs on start if limiter is disabled.
+// the errors are aggregated and returned, which stops the manager, so the teardown is retried after restart
+func (r *SmartLimiterReconciler) Teardown(stop <-chan struct{}) error {
+	log.Infof("limiter is disabled, delete all the generated config")
+	var errs []error
+
+	efs := &v1alpha3.EnvoyFilterList{}
+	if err := r.Client.List(context.TODO(), efs); err != nil {
+		log.Errorf("list envoyfilters err, %+v", err)
+		errs = append(errs, fmt.Errorf("list envoyfilters err, %v", err))
+	}
+	for i := range efs.Items {
+		ef := &efs.Items[i]
+		if !generatedByLimiter(ef) {
+			continue
+		}
+		log.Infof("delete envoyfilter %s/%s", ef.Namespace, ef.Name)
+		if err := r.Client.Delete(context.TODO(), ef); err != nil && !errors.IsNotFound(err) {
+			log.Errorf("delete envoyfilter %s/%s err, %+v", ef.Namespace, ef.Name, err)
+			errs = append(errs, fmt.Errorf("delete envoyfilter %s/%s err, %v", ef.Namespace, ef.Name, err))
+		}
+	}
+
+	if r.globalRateLimitEnabled() {
+		if err := r.teardownConfigMap(); err != nil {
+			log.Errorf("delete global descriptors err, %+v", err)
+			errs = append(errs, err)
+		}
+	}
+
+	limiters := &microservicev1alpha2.SmartLimiterList{}
+	if err := r.Client.List(context.TODO(), limiters, client.MatchingLabels{model.PolicyLabel: model.PolicyLabelValue}); err != nil {
+		log.Errorf("list smartlimiters of policy err, %+v", err)
+		errs = append(errs, fmt.Errorf("list smartlimiters of policy err, %v", err))
+	}
+	for i := range limiters.Items {
+		instance := &limiters.Items[i]
+		log.Infof("delete smartlimiter %s/%s of policy", instance.Namespace, instance.Name)
+		if err := r.Client.Delete(context.TODO(), instance); err != nil && !errors.IsNotFound(err) {
+			log.Errorf("delete smartlimiter %s/%s err, %+v", instance.Namespace, instance.Name, err)
+			errs = append(errs, fmt.Errorf("delete smartlimiter %s/%s err, %v", instance.Namespace, instance.Name, err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// teardownConfigMap deletes the global descriptors generated by limiter from the rate limit configmap,
+// they are recognized by the owners annotation, the others are kept even if they look like generated
+func (r *SmartLimiterReconciler) teardownConfigMap() error {
+	r.configMapLock.Lock()
+	defer r.configMapLock.Unlock()
+
+	loc := getConfigMapNamespaceName()
+	found := &v1.ConfigMap{}
+	if err := r.Client.Get(context.TODO(), loc, found); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("get configmap %s err, %v", loc, err)
+	}
+	rc, err := parseRateLimitConfig(found)
+	if err != nil {
+		return fmt.Errorf("parse configmap %s err, %v", loc, err)
+	}
+	owners := parseDescriptorOwners(found)
+	if len(owners) == 0 {
+		return nil
+	}
+	kept := make([]*model.Descriptor, 0)
+	for _, item := range rc.Descriptors {
+		if _, ok := owners[item.Value]; ok {
+			continue
+		}
+		kept = append(kept, item)
+	}
+	configmap := constructConfigMap(kept, nil)
+	configmap.ResourceVersion = found.ResourceVersion
+	log.Infof("delete global descriptors in configmap %s", loc)
+	if err := r.Client.Update(context.TODO(), configmap); err != nil {
+		return fmt.Errorf("update configmap %s err, %v", loc, err)
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	istioapi "slime.io/slime/framework/apis"
+	"slime.io/slime/framework/apis/config/v1alpha1"
+	"slime.io/slime/framework/apis/networking/v1alpha3"
+	"slime.io/slime/framework/bootstrap"
+	"slime.io/slime/framework/util"
+	microservicev1alpha2 "slime.io/slime/modules/limiter/api/v1alpha2"
+	"slime.io/slime/modules/limiter/model"
+)
+
+func TestOwnsDescriptor(t *testing.T) {
+	loc := types.NamespacedName{Namespace: "default", Name: "reviews"}
+	owners := map[string]model.DescriptorOwner{
+		"Service[reviews.default]-User[none]-Name[a]": {SmartLimiter: "default/reviews"},
+		"Service[reviews.default]-User[none]-Name[b]": {SmartLimiter: "default/other"},
+	}
+	cases := []struct {
+		value string
+		want  bool
+	}{
+		{"Service[reviews.default]-User[none]-Name[a]", true},
+		// generated before the owners are recorded
+		{"Service[reviews.default]-User[none]-Id[1]", true},
+		{"Service[reviews.default]-User[none]-Name[b]", false},
+		{"Service[my-reviews.default]-User[none]-Name[a]", false},
+		{"Service[reviews.default2]-User[none]-Name[a]", false},
+		{"Service[reviews.v1.default]-User[none]-Name[a]", false},
+		{"Service[other.default]-User[none]-Name[reviews.default]", false},
+	}
+	for _, c := range cases {
+		if got := ownsDescriptor(c.value, owners, loc); got != c.want {
+			t.Errorf("%s: got %v, want %v", c.value, got, c.want)
+		}
+	}
+
+	// the SmartLimiter of the same service not named after it
+	canary := types.NamespacedName{Namespace: "default", Name: "canary"}
+	value := "Service[reviews.default]-SmartLimiter[canary]-User[none]-Name[a]"
+	owners[value] = model.DescriptorOwner{SmartLimiter: "default/canary"}
+	if !ownsDescriptor(value, owners, canary) || ownsDescriptor(value, owners, loc) {
+		t.Errorf("%s should only be owned by %s", value, canary)
+	}
+}
+
+func newFakeReconciler(t *testing.T, objs ...runtime.Object) *SmartLimiterReconciler {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := microservicev1alpha2.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := istioapi.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return &SmartLimiterReconciler{Client: fake.NewFakeClientWithScheme(scheme, objs...), scheme: scheme}
+}
+
+func rateLimitConfigMap(t *testing.T, values ...string) *v1.ConfigMap {
+	t.Helper()
+	desc := make([]*model.Descriptor, 0, len(values))
+	for _, value := range values {
+		desc = append(desc, &model.Descriptor{Key: model.GenericKey, Value: value})
+	}
+	return constructConfigMap(desc, nil)
+}
+
+func configMapValues(t *testing.T, r *SmartLimiterReconciler) []string {
+	t.Helper()
+	cm := &v1.ConfigMap{}
+	if err := r.Client.Get(context.TODO(), getConfigMapNamespaceName(), cm); err != nil {
+		t.Fatal(err)
+	}
+	rc, err := parseRateLimitConfig(cm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := make([]string, 0, len(rc.Descriptors))
+	for _, item := range rc.Descriptors {
+		values = append(values, item.Value)
+	}
+	sort.Strings(values)
+	return values
+}
+
+func TestRefreshConfigMapKeepsOtherLimiters(t *testing.T) {
+	cm := rateLimitConfigMap(t,
+		"Service[reviews.default]-User[none]-Name[old]",
+		"Service[my-reviews.default]-User[none]-Name[a]",
+		"Service[reviews.default2]-User[none]-Name[a]",
+		"Service[ratings.default]-User[none]-Name[a]",
+	)
+	r := newFakeReconciler(t, cm, &microservicev1alpha2.SmartLimiter{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "reviews"},
+	})
+	loc := types.NamespacedName{Namespace: "default", Name: "reviews"}
+
+	value := "Service[reviews.default]-User[none]-Name[new]"
+	owners := map[string]model.DescriptorOwner{value: {SmartLimiter: "default/reviews", Set: "_base"}}
+	if err := refreshConfigMap([]*model.Descriptor{{Key: model.GenericKey, Value: value}}, owners, r, loc); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"Service[my-reviews.default]-User[none]-Name[a]",
+		"Service[ratings.default]-User[none]-Name[a]",
+		"Service[reviews.default2]-User[none]-Name[a]",
+		value,
+	}
+	if got := configMapValues(t, r); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if err := refreshConfigMap(nil, nil, r, loc); err != nil {
+		t.Fatal(err)
+	}
+	if got := configMapValues(t, r); !reflect.DeepEqual(got, want[:3]) {
+		t.Errorf("got %v after deleting, want %v", got, want[:3])
+	}
+}
+
+// failingDeleteClient fails to delete the objects of the names
+type failingDeleteClient struct {
+	client.Client
+	names map[string]bool
+}
+
+func (c *failingDeleteClient) Delete(ctx context.Context, obj runtime.Object, opts ...client.DeleteOption) error {
+	if m, err := meta.Accessor(obj); err == nil && c.names[m.GetName()] {
+		return fmt.Errorf("delete %s is forbidden", m.GetName())
+	}
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+func TestTeardown(t *testing.T) {
+	values := []string{"Service[reviews.default]-User[none]-Name[a]", "Service[reviews.default]-User[none]-Name[manual]", "user-defined"}
+	desc := make([]*model.Descriptor, 0, len(values))
+	for _, value := range values {
+		desc = append(desc, &model.Descriptor{Key: model.GenericKey, Value: value})
+	}
+	cm := constructConfigMap(desc, map[string]model.DescriptorOwner{values[0]: {SmartLimiter: "default/reviews"}})
+	limiter := func(name string) *microservicev1alpha2.SmartLimiter {
+		return &microservicev1alpha2.SmartLimiter{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name}}
+	}
+	generated := generatedEnvoyFilter(limiter("reviews"), util.Wellkonw_BaseSet, "1")
+	failed := generatedEnvoyFilter(limiter("ratings"), util.Wellkonw_BaseSet, "2")
+	r := newFakeReconciler(t, cm, generated, failed)
+	r.env = bootstrap.Environment{Config: &v1alpha1.Config{Limiter: &v1alpha1.Limiter{}}}
+	r.Client = &failingDeleteClient{Client: r.Client, names: map[string]bool{failed.Name: true}}
+
+	if err := r.Teardown(nil); err == nil || !strings.Contains(err.Error(), failed.Name) {
+		t.Errorf("got err %v, want the failure of deleting %s", err, failed.Name)
+	}
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: generated.Name}, &v1alpha3.EnvoyFilter{}); !errors.IsNotFound(err) {
+		t.Errorf("envoyfilter %s is not deleted, err %v", generated.Name, err)
+	}
+	// only the descriptors recorded in the owners annotation are deleted
+	want := []string{"Service[reviews.default]-User[none]-Name[manual]", "user-defined"}
+	if got := configMapValues(t, r); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,401 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+	"slime.io/slime/framework/bootstrap"
+	slime_model "slime.io/slime/framework/model"
+	"slime.io/slime/framework/util"
+	microservicev1alpha2 "slime.io/slime/modules/limiter/api/v1alpha2"
+	"slime.io/slime/modules/limiter/model"
+)
+
+// +kubebuilder:rbac:groups=microservice.slime.io,resources=smartlimiterpolicies,verbs=get;list;watch
+// +kubebuilder:rbac:groups=microservice.slime.io,resources=smartlimiterpolicies/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=microservice.slime.io,resources=smartlimiterpolicies/finalizers,verbs=update
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+
+// PolicyReconciler expands SmartLimiterPolicies into SmartLimiters, the name of request is the namespace
+// of the services
+type PolicyReconciler struct {
+	client.Client
+	scheme        *runtime.Scheme
+	env           bootstrap.Environment
+	meshNamespace string
+	// services are read from the service informer shared with the kube cache
+	services corelisters.ServiceLister
+}
+
+func NewPolicyReconciler(mgr ctrl.Manager, env bootstrap.Environment, cfg *microservicev1alpha2.Limiter) *PolicyReconciler {
+	meshNamespace := model.DefaultMeshPolicyNamespace
+	if cfg != nil && cfg.MeshPolicyNamespace != "" {
+		meshNamespace = cfg.MeshPolicyNamespace
+	}
+	return &PolicyReconciler{
+		Client:        mgr.GetClient(),
+		scheme:        mgr.GetScheme(),
+		env:           env,
+		meshNamespace: meshNamespace,
+	}
+}
+
+// PolicyEnabled tells whether the crd of SmartLimiterPolicy is installed
+func PolicyEnabled(env bootstrap.Environment) bool {
+	if env.K8SClient == nil {
+		return false
+	}
+	resources, err := env.K8SClient.Discovery().ServerResourcesForGroupVersion(microservicev1alpha2.GroupVersion.String())
+	if err != nil {
+		log.Infof("get resources of %s err, %+v", microservicev1alpha2.GroupVersion, err)
+		return false
+	}
+	for _, resource := range resources.APIResources {
+		if resource.Name == model.PolicyResource {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *PolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	services, err := serviceInformer(mgr.GetCache())
+	if err != nil {
+		return err
+	}
+	r.services = corelisters.NewServiceLister(services.GetIndexer())
+	c, err := controller.New("smartlimiterpolicy", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+	watches := []struct {
+		obj        runtime.Object
+		mapper     handler.ToRequestsFunc
+		predicates []predicate.Predicate
+	}{
+		{&microservicev1alpha2.SmartLimiterPolicy{}, r.policyToNamespaces, nil},
+		{&v1.Service{}, objectToNamespace, nil},
+		// the status of SmartLimiters is updated on every refresh, which changes nothing of policies
+		{&microservicev1alpha2.SmartLimiter{}, objectToNamespace, []predicate.Predicate{predicate.GenerationChangedPredicate{}}},
+	}
+	for _, w := range watches {
+		if err := c.Watch(&source.Kind{Type: w.obj}, &handler.EnqueueRequestsFromMapFunc{ToRequests: w.mapper}, w.predicates...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func objectToNamespace(a handler.MapObject) []reconcile.Request {
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: a.Meta.GetNamespace()}}}
+}
+
+// policyToNamespaces enqueues all namespaces if the policy is a mesh policy
+func (r *PolicyReconciler) policyToNamespaces(a handler.MapObject) []reconcile.Request {
+	if a.Meta.GetNamespace() != r.meshNamespace {
+		return objectToNamespace(a)
+	}
+	nsList := &v1.NamespaceList{}
+	if err := r.Client.List(context.TODO(), nsList); err != nil {
+		log.Errorf("list namespaces err, %+v", err)
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(nsList.Items))
+	for _, ns := range nsList.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: ns.Name}})
+	}
+	return requests
+}
+
+func (r *PolicyReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	namespace := req.Name
+	nsPolicies, err := r.listPolicies(namespace)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	var meshPolicies []microservicev1alpha2.SmartLimiterPolicy
+	if namespace != r.meshNamespace {
+		if meshPolicies, err = r.listPolicies(r.meshNamespace); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	services, err := r.services.Services(namespace).List(labels.Everything())
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	limiters := &microservicev1alpha2.SmartLimiterList{}
+	if err := r.Client.List(context.TODO(), limiters, client.InNamespace(namespace)); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	generated := make(map[string]*microservicev1alpha2.SmartLimiter)
+	// the names of SmartLimiters generated by the policies of other revisions, which are left to them
+	others := make(map[string]struct{})
+	users := make([]limitTarget, 0)
+	// the names of SmartLimiters created by user
+	taken := make(map[string]struct{})
+	for i := range limiters.Items {
+		instance := &limiters.Items[i]
+		if isPolicyLimiter(instance) {
+			if !r.env.RevInScope(slime_model.IstioRevFromLabel(instance.Labels)) {
+				others[instance.Name] = struct{}{}
+				continue
+			}
+			generated[instance.Name] = instance
+			continue
+		}
+		taken[instance.Name] = struct{}{}
+		target, err := resolveTarget(instance.Spec, types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name})
+		if err == nil {
+			users = append(users, target)
+		}
+	}
+
+	desired := make(map[string]*microservicev1alpha2.SmartLimiter)
+	// key is namespace/name of the policy, value is the services applied
+	applied := make(map[string]map[string]string)
+	// key is namespace/name of the policy, value is the services not applied since the name is taken
+	collisions := make(map[string]map[string]string)
+	for _, svc := range services {
+		// the pods of services without selector, like ExternalName, are not known
+		if len(svc.Spec.Selector) == 0 || overridden(users, svc) {
+			continue
+		}
+		policy := selectPolicy(nsPolicies, svc)
+		if policy == nil {
+			policy = selectPolicy(meshPolicies, svc)
+		}
+		if policy == nil {
+			continue
+		}
+		name := policyLimiterName(svc.Name)
+		key := policy.Namespace + "/" + policy.Name
+		if _, ok := taken[name]; ok {
+			log.Errorf("smartlimiter %s/%s of policy %s is created by user, skip", namespace, name, key)
+			if collisions[key] == nil {
+				collisions[key] = make(map[string]string)
+			}
+			collisions[key][svc.Namespace+"/"+svc.Name] = fmt.Sprintf("smartlimiter %s is created by user", name)
+			continue
+		}
+		if _, ok := others[name]; ok {
+			log.Debugf("smartlimiter %s/%s of policy %s is generated by other revision, skip", namespace, name, key)
+			continue
+		}
+		instance := generatePolicyLimiter(policy, svc)
+		// owner reference can not point to the policy in another namespace, like the mesh policy,
+		// whose SmartLimiters are only deleted by the reconciler
+		if policy.Namespace == namespace {
+			if err := controllerutil.SetControllerReference(policy, instance, r.scheme); err != nil {
+				log.Errorf("set owner of smartlimiter %s/%s to policy %s err, %+v", namespace, name, key, err)
+			}
+		}
+		desired[name] = instance
+		if applied[key] == nil {
+			applied[key] = make(map[string]string)
+		}
+		applied[key][svc.Namespace+"/"+svc.Name] = name
+	}
+
+	for name, instance := range desired {
+		if err := r.applyPolicyLimiter(instance, generated[name]); err != nil {
+			log.Errorf("apply smartlimiter %s/%s of policy err, %+v", namespace, name, err)
+		}
+	}
+	for name, instance := range generated {
+		if _, ok := desired[name]; ok {
+			continue
+		}
+		log.Infof("delete smartlimiter %s/%s which is not applied by policy", namespace, name)
+		if err := r.Client.Delete(context.TODO(), instance); err != nil && !errors.IsNotFound(err) {
+			log.Errorf("delete smartlimiter %s/%s err, %+v", namespace, name, err)
+		}
+	}
+
+	for _, policies := range [][]microservicev1alpha2.SmartLimiterPolicy{nsPolicies, meshPolicies} {
+		for i := range policies {
+			key := policies[i].Namespace + "/" + policies[i].Name
+			r.updatePolicyStatus(&policies[i], namespace, applied[key], collisions[key])
+		}
+	}
+	return ctrl.Result{}, nil
+}
+
+// updatePolicyStatus replaces the services and collisions in namespace of the policy status
+func (r *PolicyReconciler) updatePolicyStatus(policy *microservicev1alpha2.SmartLimiterPolicy, namespace string, applied, collisions map[string]string) {
+	services := replaceNamespace(policy.Status.Services, namespace, applied)
+	collided := replaceNamespace(policy.Status.Collisions, namespace, collisions)
+	if equalStatusMap(services, policy.Status.Services) && equalStatusMap(collided, policy.Status.Collisions) {
+		return
+	}
+	policy.Status.Services = services
+	policy.Status.Collisions = collided
+	if err := r.Client.Status().Update(context.TODO(), policy); err != nil {
+		log.Errorf("update status of smartlimiterpolicy %s/%s err, %+v", policy.Namespace, policy.Name, err)
+	}
+}
+
+// replaceNamespace replaces the entries of services in namespace with current, the key is namespace/name of service
+func replaceNamespace(status map[string]string, namespace string, current map[string]string) map[string]string {
+	ret := make(map[string]string)
+	for svc, v := range status {
+		if !strings.HasPrefix(svc, namespace+"/") {
+			ret[svc] = v
+		}
+	}
+	for svc, v := range current {
+		ret[svc] = v
+	}
+	if len(ret) == 0 {
+		return nil
+	}
+	return ret
+}
+
+// equalStatusMap treats nil and empty maps as equal
+func equalStatusMap(a, b map[string]string) bool {
+	return len(a) == 0 && len(b) == 0 || reflect.DeepEqual(a, b)
+}
+
+func (r *PolicyReconciler) listPolicies(namespace string) ([]microservicev1alpha2.SmartLimiterPolicy, error) {
+	list := &microservicev1alpha2.SmartLimiterPolicyList{}
+	if err := r.Client.List(context.TODO(), list, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	// the policies of other revisions are left to their limiters
+	policies := make([]microservicev1alpha2.SmartLimiterPolicy, 0, len(list.Items))
+	for _, policy := range list.Items {
+		if !r.env.RevInScope(slime_model.IstioRevFromLabel(policy.Labels)) {
+			log.Debugf("smartlimiterpolicy %s/%s istiorev %s but our %s, skip", policy.Namespace, policy.Name,
+				slime_model.IstioRevFromLabel(policy.Labels), r.env.IstioRev())
+			continue
+		}
+		policies = append(policies, policy)
+	}
+	// larger priority first, then by name
+	sort.Slice(policies, func(i, j int) bool {
+		if policies[i].Spec.Priority != policies[j].Spec.Priority {
+			return policies[i].Spec.Priority > policies[j].Spec.Priority
+		}
+		return policies[i].Name < policies[j].Name
+	})
+	return policies, nil
+}
+
+func (r *PolicyReconciler) applyPolicyLimiter(desired, found *microservicev1alpha2.SmartLimiter) error {
+	if found == nil {
+		log.Infof("create smartlimiter %s/%s of policy %s", desired.Namespace, desired.Name, desired.Annotations[model.PolicyAnnotation])
+		err := r.Client.Create(context.TODO(), desired)
+		if !errors.IsAlreadyExists(err) {
+			return err
+		}
+		// the cache is out of date, update the existing one only if it is generated by policy as well
+		existing := &microservicev1alpha2.SmartLimiter{}
+		if err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: desired.Namespace, Name: desired.Name}, existing); err != nil {
+			return err
+		}
+		if !isPolicyLimiter(existing) {
+			return fmt.Errorf("smartlimiter %s/%s is created by user", desired.Namespace, desired.Name)
+		}
+		found = existing
+	}
+	if reflect.DeepEqual(found.Spec, desired.Spec) && reflect.DeepEqual(found.Labels, desired.Labels) &&
+		reflect.DeepEqual(found.Annotations, desired.Annotations) && reflect.DeepEqual(found.OwnerReferences, desired.OwnerReferences) {
+		return nil
+	}
+	found.Spec = desired.Spec
+	found.Labels = desired.Labels
+	found.Annotations = desired.Annotations
+	found.OwnerReferences = desired.OwnerReferences
+	log.Infof("update smartlimiter %s/%s of policy %s", found.Namespace, found.Name, desired.Annotations[model.PolicyAnnotation])
+	return r.Client.Update(context.TODO(), found)
+}
+
+// selectPolicy returns the first policy selecting the service, the policies are sorted by priority
+func selectPolicy(policies []microservicev1alpha2.SmartLimiterPolicy, svc *v1.Service) *microservicev1alpha2.SmartLimiterPolicy {
+	for i := range policies {
+		policy := &policies[i]
+		if policy.Spec.Template == nil || excluded(policy.Spec.ExcludeNamespaces, svc.Namespace) {
+			continue
+		}
+		if util.IsContain(svc.Labels, policy.Spec.ServiceSelector) {
+			return policy
+		}
+	}
+	return nil
+}
+
+func excluded(namespaces []string, namespace string) bool {
+	for _, ns := range namespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// overridden tells whether the service is targeted by a SmartLimiter created by user
+func overridden(users []limitTarget, svc *v1.Service) bool {
+	for _, target := range users {
+		if target.selects(svc) {
+			return true
+		}
+	}
+	return false
+}
+
+// policyLimiterName returns the name of the SmartLimiter generated for the service, the hash of service name
+// is appended so the name is unlikely to be used by the SmartLimiters created by user
+func policyLimiterName(service string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(service))
+	return fmt.Sprintf("%s%s-%08x", service, model.PolicyLimiterSuffix, h.Sum32())
+}
+
+func isPolicyLimiter(instance *microservicev1alpha2.SmartLimiter) bool {
+	return instance.Labels[model.PolicyLabel] == model.PolicyLabelValue
+}
+
+// generatePolicyLimiter generates the SmartLimiter of the service from the template of policy
+func generatePolicyLimiter(policy *microservicev1alpha2.SmartLimiterPolicy, svc *v1.Service) *microservicev1alpha2.SmartLimiter {
+	spec := policy.Spec.Template.DeepCopy()
+	spec.WorkloadSelector = nil
+	spec.TargetRef = &microservicev1alpha2.TargetRef{Kind: model.TargetKindService, Name: svc.Name}
+
+	labels := map[string]string{
+		model.PolicyLabel:    model.PolicyLabelValue,
+		model.LabelManagedBy: model.LabelManagedByValue,
+	}
+	slime_model.PatchIstioRevLabel(&labels, slime_model.IstioRevFromLabel(policy.Labels))
+	return &microservicev1alpha2.SmartLimiter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      policyLimiterName(svc.Name),
+			Namespace: svc.Namespace,
+			Labels:    labels,
+			Annotations: map[string]string{
+				model.PolicyAnnotation: policy.Namespace + "/" + policy.Name,
+			},
+		},
+		Spec: *spec,
+	}
+}
@@ -0,0 +1,170 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"slime.io/slime/framework/apis/config/v1alpha1"
+	"slime.io/slime/framework/bootstrap"
+	slime_model "slime.io/slime/framework/model"
+	microservicev1alpha2 "slime.io/slime/modules/limiter/api/v1alpha2"
+)
+
+func policyService(name string, labels, selector map[string]string) *v1.Service {
+	return &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Labels: labels},
+		Spec:       v1.ServiceSpec{Selector: selector},
+	}
+}
+
+func limiterPolicy(namespace, name, quota string, selector map[string]string) *microservicev1alpha2.SmartLimiterPolicy {
+	return &microservicev1alpha2.SmartLimiterPolicy{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec: microservicev1alpha2.SmartLimiterPolicySpec{
+			ServiceSelector: selector,
+			Template: &microservicev1alpha2.SmartLimiterSpec{Sets: map[string]*microservicev1alpha2.SmartLimitDescriptors{
+				"_base": {Descriptor_: []*microservicev1alpha2.SmartLimitDescriptor{routeDescriptor(quota)}},
+			}},
+		},
+	}
+}
+
+func TestPolicyLimiterName(t *testing.T) {
+	a, b := policyLimiterName("reviews"), policyLimiterName("reviews-policy")
+	if a == b || a == "reviews-policy" {
+		t.Errorf("got %s and %s", a, b)
+	}
+	if a != policyLimiterName("reviews") {
+		t.Errorf("name is not stable")
+	}
+}
+
+func TestPolicyReconcile(t *testing.T) {
+	web := map[string]string{"tier": "web"}
+	app := func(name string) map[string]string { return map[string]string{"app": name} }
+	user := &microservicev1alpha2.SmartLimiter{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "user"},
+		Spec:       microservicev1alpha2.SmartLimiterSpec{TargetRef: &microservicev1alpha2.TargetRef{Name: "c"}},
+	}
+	// the name of the SmartLimiter of e is taken by user
+	taken := &microservicev1alpha2.SmartLimiter{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: policyLimiterName("e")},
+		Spec:       microservicev1alpha2.SmartLimiterSpec{TargetRef: &microservicev1alpha2.TargetRef{Name: "other"}},
+	}
+	r := &PolicyReconciler{meshNamespace: "istio-system", services: serviceLister(t,
+		// a: namespace policy takes precedence over mesh policy
+		policyService("a", web, app("a")),
+		// b: only selected by mesh policy
+		policyService("b", nil, app("b")),
+		// c: targeted by the SmartLimiter of user
+		policyService("c", web, app("c")),
+		// d: without selector
+		policyService("d", web, nil),
+		policyService("e", web, app("e")),
+	)}
+	// the policy of other revision is skipped even if it has the larger priority
+	canary := limiterPolicy("default", "canary", "1", web)
+	canary.Spec.Priority = 1
+	canary.Labels = map[string]string{slime_model.IstioRevLabel: "canary"}
+	fr := newFakeReconciler(t,
+		limiterPolicy("istio-system", "mesh", "100", nil),
+		limiterPolicy("default", "ns", "10", web),
+		canary, user, taken,
+	)
+	r.Client, r.scheme = fr.Client, fr.scheme
+	r.env = bootstrap.Environment{Config: &v1alpha1.Config{Global: &v1alpha1.Global{IstioRev: "stable"}}}
+
+	if _, err := r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: "default"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	list := &microservicev1alpha2.SmartLimiterList{}
+	if err := r.Client.List(context.TODO(), list, client.InNamespace("default")); err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]string)
+	owners := make(map[string]string)
+	for _, item := range list.Items {
+		if isPolicyLimiter(&item) {
+			got[item.Spec.TargetRef.Name] = item.Spec.Sets["_base"].Descriptor_[0].Action.Quota
+			if ref := metav1.GetControllerOf(&item); ref != nil {
+				owners[item.Spec.TargetRef.Name] = ref.Kind + "/" + ref.Name
+			}
+		}
+	}
+	if want := map[string]string{"a": "10", "b": "100"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got generated %v, want %v", got, want)
+	}
+	// the mesh policy in another namespace can not be the owner
+	if want := map[string]string{"a": "SmartLimiterPolicy/ns"}; !reflect.DeepEqual(owners, want) {
+		t.Errorf("got owners %v, want %v", owners, want)
+	}
+
+	ns := &microservicev1alpha2.SmartLimiterPolicy{}
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "ns"}, ns); err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"default/a": policyLimiterName("a")}; !reflect.DeepEqual(ns.Status.Services, want) {
+		t.Errorf("got services %v, want %v", ns.Status.Services, want)
+	}
+	collided := make([]string, 0)
+	for svc := range ns.Status.Collisions {
+		collided = append(collided, svc)
+	}
+	sort.Strings(collided)
+	if want := []string{"default/e"}; !reflect.DeepEqual(collided, want) {
+		t.Errorf("got collisions %v, want %v", ns.Status.Collisions, want)
+	}
+
+	mesh := &microservicev1alpha2.SmartLimiterPolicy{}
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "istio-system", Name: "mesh"}, mesh); err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"default/b": policyLimiterName("b")}; !reflect.DeepEqual(mesh.Status.Services, want) {
+		t.Errorf("got services %v, want %v", mesh.Status.Services, want)
+	}
+
+	// the SmartLimiter taken by user is kept
+	found := &microservicev1alpha2.SmartLimiter{}
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: taken.Name}, found); err != nil {
+		t.Fatal(err)
+	}
+	if isPolicyLimiter(found) || found.Spec.TargetRef.Name != "other" {
+		t.Errorf("smartlimiter of user is modified, %+v", found)
+	}
+}
+
+func TestApplyPolicyLimiterAlreadyExists(t *testing.T) {
+	policy := limiterPolicy("default", "ns", "10", nil)
+	desired := generatePolicyLimiter(policy, policyService("a", nil, nil))
+
+	// generated by policy, but not in the cache yet
+	existing := desired.DeepCopy()
+	existing.Spec.Sets["_base"].Descriptor_[0].Action.Quota = "1"
+	r := &PolicyReconciler{Client: newFakeReconciler(t, existing).Client}
+	if err := r.applyPolicyLimiter(desired.DeepCopy(), nil); err != nil {
+		t.Fatal(err)
+	}
+	found := &microservicev1alpha2.SmartLimiter{}
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: desired.Name}, found); err != nil {
+		t.Fatal(err)
+	}
+	if got := found.Spec.Sets["_base"].Descriptor_[0].Action.Quota; got != "10" {
+		t.Errorf("got quota %s, want 10", got)
+	}
+
+	// created by user
+	userOwned := desired.DeepCopy()
+	userOwned.Labels = nil
+	r = &PolicyReconciler{Client: newFakeReconciler(t, userOwned).Client}
+	if err := r.applyPolicyLimiter(desired.DeepCopy(), nil); err == nil {
+		t.Errorf("smartlimiter of user should not be updated")
+	}
+}
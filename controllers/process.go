@@ -10,13 +10,13 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
-	"strings"
 
 	"gopkg.in/yaml.v2"
 	networking "istio.io/api/networking/v1alpha3"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/json"
 
@@ -25,6 +25,7 @@ import (
 	"slime.io/slime/framework/apis/networking/v1alpha3"
 	slime_model "slime.io/slime/framework/model"
 	"slime.io/slime/framework/model/metric"
+	"slime.io/slime/framework/model/trigger"
 	"slime.io/slime/framework/util"
 	microservicev1alpha2 "slime.io/slime/modules/limiter/api/v1alpha2"
 	"slime.io/slime/modules/limiter/model"
@@ -86,12 +87,19 @@ func (r *SmartLimiterReconciler) ConsumeMetric(metricMap metric.Metric) {
 		}
 
 		// record the number of pods , specified by subset
+		var deleted []string
 		for subset, number := range nPod {
 			if number == 0 {
+				// the quota divided by the number of pods can not be calculated
+				deleted = append(deleted, subset)
 				continue
 			}
 			Info[subset] = strconv.Itoa(number)
 		}
+		r.deleteMetricInfo(loc, deleted)
+		for k, v := range meta.Vars {
+			Info[k] = v
+		}
 		log.Debugf("exact metric info, %v", Info)
 
 		// use info to refresh SmartLimiter's status
@@ -102,6 +110,7 @@ func (r *SmartLimiterReconciler) ConsumeMetric(metricMap metric.Metric) {
 }
 
 func (r *SmartLimiterReconciler) Refresh(request reconcile.Request, args map[string]string) (reconcile.Result, error) {
+	r.recordMetricTime(request.NamespacedName, args)
 	_, ok := r.metricInfo.Get(request.Namespace + "/" + request.Name)
 	if !ok {
 		r.metricInfo.Set(request.Namespace+"/"+request.Name, &slime_model.Endpoints{
@@ -120,6 +129,8 @@ func (r *SmartLimiterReconciler) Refresh(request reconcile.Request, args map[str
 		}
 	}
 
+	unlock := r.lockRefresh(request.NamespacedName)
+	defer unlock()
 	instance := &microservicev1alpha2.SmartLimiter{}
 	err := r.Client.Get(context.TODO(), request.NamespacedName, instance)
 	if err != nil {
@@ -141,18 +152,38 @@ func (r *SmartLimiterReconciler) Refresh(request reconcile.Request, args map[str
 	}
 }
 
-// refresh envoy filters and configmap
+// lockRefresh serializes the refreshes of the SmartLimiter, the instance should be got after it is locked
+func (r *SmartLimiterReconciler) lockRefresh(loc types.NamespacedName) func() {
+	qs := r.getQuotaStates(loc)
+	qs.refreshing.Lock()
+	return qs.refreshing.Unlock
+}
+
+// refresh envoy filters and configmap, it should be called with lockRefresh held
 func (r *SmartLimiterReconciler) refresh(instance *microservicev1alpha2.SmartLimiter) (reconcile.Result, error) {
 	var err error
 	loc := types.NamespacedName{
 		Namespace: instance.Namespace,
 		Name:      instance.Name,
 	}
+	if r.cfg.GetDisable() {
+		log.Debugf("limiter is disabled, skip refreshing %s", loc)
+		return reconcile.Result{}, nil
+	}
+	if instance.DeletionTimestamp != nil {
+		log.Debugf("smartlimiter %s is being deleted, skip refreshing", loc)
+		return reconcile.Result{}, nil
+	}
 	material := r.getMaterial(loc)
 	if instance.Spec.Sets == nil {
 		return reconcile.Result{}, util.Error{M: "invalid rateLimit spec with none sets"}
 	}
+	if err := validateDamping(instance.Spec.Damping); err != nil {
+		return reconcile.Result{}, err
+	}
 	spec := instance.Spec
+	r.restoreFeedbackStates(instance)
+	r.resetScheduleTimer(loc, spec)
 
 	var efs map[string]*networking.EnvoyFilter
 	var descriptor map[string]*microservicev1alpha2.SmartLimitDescriptors
@@ -162,22 +193,19 @@ func (r *SmartLimiterReconciler) refresh(instance *microservicev1alpha2.SmartLim
 	if err != nil {
 		return reconcile.Result{}, err
 	}
+	invalid := r.recordedInvalidDescriptors(loc)
+	generated := make(map[string]struct{}, len(efs))
 	for k, ef := range efs {
-		var efcr *v1alpha3.EnvoyFilter
-		if k == util.Wellkonw_BaseSet {
-			efcr = &v1alpha3.EnvoyFilter{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      fmt.Sprintf("%s.%s.ratelimit", instance.Name, instance.Namespace),
-					Namespace: instance.Namespace,
-				},
-			}
-		} else {
-			efcr = &v1alpha3.EnvoyFilter{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      fmt.Sprintf("%s.%s.%s.ratelimit", instance.Name, instance.Namespace, k),
-					Namespace: instance.Namespace,
-				},
-			}
+		efcr := &v1alpha3.EnvoyFilter{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        envoyFilterName(instance, k),
+				Namespace:   instance.Namespace,
+				Labels:      generatedLabels(instance, k),
+				Annotations: generatedAnnotations(instance),
+			},
+		}
+		if ef != nil {
+			generated[efcr.Name] = struct{}{}
 		}
 		if ef != nil {
 			if mi, err := util.ProtoToMap(ef); err == nil {
@@ -191,14 +219,27 @@ func (r *SmartLimiterReconciler) refresh(instance *microservicev1alpha2.SmartLim
 			log.Errorf("generated/deleted EnvoyFilter %s failed:%+v", efcr.Name, err)
 		}
 	}
-	if r.env.Config != nil && r.env.Config.Limiter != nil && !r.env.Config.Limiter.GetDisableGlobalRateLimit() {
-		refreshConfigMap(gdesc, r, loc)
+	r.gcEnvoyFilters(instance, generated)
+	r.refreshRouteOwners(r.recordMergedDescriptors(loc, descriptor))
+	if r.globalRateLimitEnabled() {
+		if err := refreshConfigMap(gdesc, descriptorOwners(instance, descriptor, gdesc), r, loc); err != nil {
+			log.Errorf("refresh configmap of %s err, %+v", loc, err)
+		}
 	} else {
 		log.Info("global rate limiter is closed")
 	}
 	instance.Status = microservicev1alpha2.SmartLimiterStatus{
-		RatelimitStatus: descriptor,
-		MetricStatus:    material,
+		RatelimitStatus:    descriptor,
+		MetricStatus:       material,
+		DescriptorValues:   generateDescriptorValues(spec.Sets, invalid, limiterValueScope(instance)),
+		FeedbackStatus:     r.feedbackStatus(loc, spec),
+		MetricUpdateTime:   r.metricUpdateTime(loc),
+		StaleDescriptors:   r.staleDescriptors(loc),
+		Conflicts:          r.routeConflicts(loc),
+		Degraded:           r.degradedMessage(),
+		InvalidDescriptors: invalid,
+		MetricErrors:       r.queryErrors(loc),
+		MatcherConflicts:   matcherConflicts(instance),
 	}
 	if err = r.Client.Status().Update(context.TODO(), instance); err != nil {
 		return reconcile.Result{}, err
@@ -206,22 +247,68 @@ func (r *SmartLimiterReconciler) refresh(instance *microservicev1alpha2.SmartLim
 	return reconcile.Result{}, nil
 }
 
-// TODO different with old version
-// the function will not trigger if subset is changed
-// if subset is deleted, how to delete the exist envoyfilters, add anohter function to delete the efs ?
+// subscribe is called when the subsets of host are changed in DestinationRule, it sends an event to the
+// watcher producer, so the SmartLimiters of the host are refreshed with the pods of the new subsets.
+// the EnvoyFilters of the deleted subsets are deleted by gcEnvoyFilters in refresh
 func (r *SmartLimiterReconciler) subscribe(host string, subset interface{}) {
-	if name, ns, ok := util.IsK8SService(host); ok {
-		loc := types.NamespacedName{Name: name, Namespace: ns}
-		instance := &microservicev1alpha2.SmartLimiter{}
-		err := r.Client.Get(context.TODO(), loc, instance)
-		if err != nil {
-			if !errors.IsNotFound(err) {
-				log.Errorf("failed to get smartlimiter, host:%s, %+v", host, err)
+	name, ns, ok := util.IsK8SService(host)
+	if !ok {
+		return
+	}
+	event := trigger.WatcherEvent{
+		GVK: schema.GroupVersionKind{
+			Group:   "networking.istio.io",
+			Version: "v1alpha3",
+			Kind:    "DestinationRule",
+		},
+		NN: types.NamespacedName{Name: name, Namespace: ns},
+	}
+	// it is called with the lock of HostSubsetMapping held, do not block the DestinationRule reconciler
+	r.sendWatcherEvent(event)
+}
+
+// onEndpoints sends the event of the endpoints changed to the watcher producer, the namespaces without
+// SmartLimiter are skipped
+func (r *SmartLimiterReconciler) onEndpoints(nn types.NamespacedName) {
+	if !r.interestedNamespace(nn.Namespace) {
+		return
+	}
+	r.sendWatcherEvent(trigger.WatcherEvent{GVK: v1.SchemeGroupVersion.WithKind("Endpoints"), NN: nn})
+}
+
+// sendWatcherEvent sends the event to the watcher producer without blocking, nothing receives the events on
+// followers or before the producers start, so the event is dropped if the buffer is full
+func (r *SmartLimiterReconciler) sendWatcherEvent(event trigger.WatcherEvent) bool {
+	select {
+	case r.watcherEventChan <- event:
+		return true
+	default:
+		log.Debugf("watcher event buffer is full, drop the event of %s %s", event.GVK.Kind, event.NN)
+		return false
+	}
+}
+
+// deleteMetricInfo deletes the metrics which are not reported any more, e.g. the number of pods of a subset
+// without ready pods, since Refresh only merges the new metrics into the old ones
+func (r *SmartLimiterReconciler) deleteMetricInfo(loc types.NamespacedName, keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+	if i, ok := r.metricInfo.Get(loc.Namespace + "/" + loc.Name); ok {
+		if ep, ok := i.(*slime_model.Endpoints); ok {
+			ep.Lock.Lock()
+			for _, k := range keys {
+				delete(ep.Info, k)
 			}
-		} else {
-			_, _ = r.refresh(instance)
+			ep.Lock.Unlock()
 		}
 	}
+	qs := r.getQuotaStates(loc)
+	qs.Lock()
+	defer qs.Unlock()
+	for _, k := range keys {
+		delete(qs.metricTime, k)
+	}
 }
 
 func (r *SmartLimiterReconciler) getMaterial(loc types.NamespacedName) map[string]string {
@@ -281,7 +368,8 @@ func refreshEnvoyFilter(instance *microservicev1alpha2.SmartLimiter, r *SmartLim
 		}
 		// spec is not nil , update
 		if obj.Spec != nil {
-			if !reflect.DeepEqual(string(foundSpec), string(objSpec)) {
+			if !reflect.DeepEqual(string(foundSpec), string(objSpec)) || !reflect.DeepEqual(found.Labels, obj.Labels) ||
+				!reflect.DeepEqual(found.Annotations, obj.Annotations) {
 				obj.ResourceVersion = found.ResourceVersion
 				err := r.Client.Update(context.TODO(), obj)
 				if err != nil {
@@ -304,8 +392,18 @@ func refreshEnvoyFilter(instance *microservicev1alpha2.SmartLimiter, r *SmartLim
 	return reconcile.Result{}, nil
 }
 
-// if configmap rate-limit-config not exist, ratelimit server will not running
-func refreshConfigMap(desc []*model.Descriptor, r *SmartLimiterReconciler, serviceLoc types.NamespacedName) {
+// if configmap rate-limit-config not exist, ratelimit server will not running.
+// the descriptors of the SmartLimiter being deleted are always deleted, so they are not added back by
+// a refresh racing with the finalizer
+func refreshConfigMap(desc []*model.Descriptor, owners map[string]model.DescriptorOwner, r *SmartLimiterReconciler, serviceLoc types.NamespacedName) error {
+	r.configMapLock.Lock()
+	defer r.configMapLock.Unlock()
+
+	if len(desc) > 0 && r.deleting(serviceLoc) {
+		log.Infof("smartlimiter %s is being deleted, delete its global descriptors", serviceLoc)
+		desc, owners = nil, nil
+	}
+
 	loc := getConfigMapNamespaceName()
 
 	found := &v1.ConfigMap{}
@@ -313,46 +411,58 @@ func refreshConfigMap(desc []*model.Descriptor, r *SmartLimiterReconciler, servi
 	if err != nil {
 		if errors.IsNotFound(err) {
 			log.Errorf("configmap %s:%s is not found, can not refresh configmap", loc.Namespace, loc.Name)
-			return
+			return nil
 		} else {
-			log.Errorf("get configmap %s:%s err: %+v, cant not refresh configmap", loc.Namespace, loc.Name, err.Error())
-			return
+			return fmt.Errorf("get configmap %s:%s err: %+v", loc.Namespace, loc.Name, err.Error())
 		}
 	}
 
-	config, ok := found.Data[model.ConfigMapConfig]
-	if !ok {
-		log.Errorf("config.yaml not found in configmap %s:%s", loc.Namespace, loc.Name)
-		return
-	}
-	rc := &model.RateLimitConfig{}
-	if err = yaml.Unmarshal([]byte(config), &rc); err != nil {
-		log.Infof("unmarshal ratelimitConfig %s err: %+v", config, err.Error())
-		return
+	// the descriptors of others can not be kept if the config is not parsed, so it is not overwritten, and the
+	// error is returned to keep the finalizer until the config is fixed
+	rc, err := parseRateLimitConfig(found)
+	if err != nil {
+		return fmt.Errorf("parse ratelimitConfig of configmap %s:%s err: %+v", loc.Namespace, loc.Name, err.Error())
 	}
 
 	newCm := make([]*model.Descriptor, 0)
-	serviceInfo := fmt.Sprintf("%s.%s", serviceLoc.Name, serviceLoc.Namespace)
+	newOwners := parseDescriptorOwners(found)
 	for _, item := range rc.Descriptors {
-		if !strings.Contains(item.Value, serviceInfo) {
+		if !ownsDescriptor(item.Value, newOwners, serviceLoc) {
 			newCm = append(newCm, item)
+		} else {
+			delete(newOwners, item.Value)
 		}
 	}
 	newCm = append(newCm, desc...)
+	for value, owner := range owners {
+		newOwners[value] = owner
+	}
 
-	configmap := constructConfigMap(newCm)
-	if !reflect.DeepEqual(found.Data, configmap.Data) {
+	configmap := constructConfigMap(newCm, newOwners)
+	if !reflect.DeepEqual(found.Data, configmap.Data) || !reflect.DeepEqual(found.Labels, configmap.Labels) ||
+		found.Annotations[model.AnnotationDescriptorOwners] != configmap.Annotations[model.AnnotationDescriptorOwners] {
 		log.Infof("update configmap %s:%s", loc.Namespace, loc.Name)
 		configmap.ResourceVersion = found.ResourceVersion
-		err = r.Client.Update(context.TODO(), configmap)
-		if err != nil {
-			log.Infof("update configmap %s:%s err: %+v", loc.Namespace, loc.Name, err.Error())
-			return
+		if err = r.Client.Update(context.TODO(), configmap); err != nil {
+			return fmt.Errorf("update configmap %s:%s err: %+v", loc.Namespace, loc.Name, err.Error())
 		}
 	}
+	return nil
+}
+
+func parseRateLimitConfig(cm *v1.ConfigMap) (*model.RateLimitConfig, error) {
+	config, ok := cm.Data[model.ConfigMapConfig]
+	if !ok {
+		return nil, fmt.Errorf("%s not found", model.ConfigMapConfig)
+	}
+	rc := &model.RateLimitConfig{}
+	if err := yaml.Unmarshal([]byte(config), &rc); err != nil {
+		return nil, err
+	}
+	return rc, nil
 }
 
-func constructConfigMap(desc []*model.Descriptor) *v1.ConfigMap {
+func constructConfigMap(desc []*model.Descriptor, owners map[string]model.DescriptorOwner) *v1.ConfigMap {
 	rateLimitConfig := &model.RateLimitConfig{
 		Domain:      model.Domain,
 		Descriptors: desc,
@@ -361,9 +471,10 @@ func constructConfigMap(desc []*model.Descriptor) *v1.ConfigMap {
 	loc := getConfigMapNamespaceName()
 	configmap := &v1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      loc.Name,
-			Namespace: loc.Namespace,
-			Labels:    generateConfigMapLabels(),
+			Name:        loc.Name,
+			Namespace:   loc.Namespace,
+			Labels:      generateConfigMapLabels(),
+			Annotations: descriptorOwnersAnnotations(owners),
 		},
 		Data: map[string]string{
 			model.ConfigMapConfig: string(b),
@@ -376,5 +487,6 @@ func constructConfigMap(desc []*model.Descriptor) *v1.ConfigMap {
 func generateConfigMapLabels() map[string]string {
 	labels := make(map[string]string)
 	labels["app"] = "rate-limit"
+	labels[model.LabelManagedBy] = model.LabelManagedByValue
 	return labels
 }
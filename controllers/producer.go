@@ -1,25 +1,28 @@
 package controllers
 
 import (
+	"context"
 	stderrors "errors"
 	"fmt"
 	"strings"
+	"sync"
+	"time"
 
 	prometheusApi "github.com/prometheus/client_golang/api"
 	prometheusV1 "github.com/prometheus/client_golang/api/prometheus/v1"
 	"istio.io/api/networking/v1alpha3"
 	v1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/json"
-	"k8s.io/client-go/kubernetes"
 	"slime.io/slime/framework/apis/config/v1alpha1"
 	"slime.io/slime/framework/bootstrap"
 	"slime.io/slime/framework/controllers"
 	"slime.io/slime/framework/model/metric"
 	"slime.io/slime/framework/model/trigger"
 	"slime.io/slime/framework/util"
+	microservicev1alpha2 "slime.io/slime/modules/limiter/api/v1alpha2"
+	"slime.io/slime/modules/limiter/model"
 )
 
 // StaticMeta is static info and do not to query from prometheus
@@ -28,6 +31,12 @@ type StaticMeta struct {
 	Namespace string          `json:"namespace"`
 	NPod      map[string]int  `json:"nPod"`
 	IsGroup   map[string]bool `json:"isGroup"`
+	// Pods and PodIPs are used by the metric sources which query pods directly
+	// the key of Pods is the subset, and the key of PodIPs is the pod name
+	Pods   map[string][]string `json:"pods,omitempty"`
+	PodIPs map[string]string   `json:"podIPs,omitempty"`
+	// Vars is the built-in variables, see generateBuiltinVars
+	Vars map[string]string `json:"vars,omitempty"`
 }
 
 func (s StaticMeta) String() string {
@@ -41,10 +50,77 @@ func (s StaticMeta) String() string {
 
 // the following functions is registered to framework
 
-// handleWatcherEvent is triggered by endpoint event
+// handleWatcherEvent is triggered by endpoint or DestinationRule event, the SmartLimiters targeting the service are handled.
+// the events of the namespaces without SmartLimiter are ignored, and the events of a service are handled at most once
+// in WatcherEventMinInterval, the events in the interval are merged into one which is handled at the end of it
 func (r *SmartLimiterReconciler) handleWatcherEvent(event trigger.WatcherEvent) metric.QueryMap {
+	queryMap := make(map[string][]metric.Handler, 0)
+	if !r.interestedNamespace(event.NN.Namespace) {
+		return queryMap
+	}
+	if !r.watcherThrottle.allow(event.NN, time.Now(), model.WatcherEventMinInterval, func() { r.sendWatcherEvent(event) }) {
+		log.Debugf("%v is throttled, it is handled later", event)
+		return queryMap
+	}
 	log.Infof("%v trigger handleWatcherEvent", event)
-	return r.handleEvent(event.NN)
+	for _, instance := range r.limitersOfService(event.NN) {
+		qm := r.handleEvent(types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name})
+		for meta, handlers := range qm {
+			queryMap[meta] = handlers
+		}
+	}
+	return queryMap
+}
+
+// interestedNamespace returns whether there is any SmartLimiter in the interest map in namespace
+func (r *SmartLimiterReconciler) interestedNamespace(namespace string) bool {
+	for _, k := range r.interest.Keys() {
+		if strings.HasPrefix(k, namespace+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// watcherThrottle limits the frequency of the watcher events of each service
+type watcherThrottle struct {
+	sync.Mutex
+	// last is the time when the event of service is handled
+	last map[types.NamespacedName]time.Time
+	// pending is true if the event of service is going to be replayed
+	pending map[types.NamespacedName]bool
+}
+
+// allow returns whether the event of nn can be handled now, otherwise replay is called at the end of the interval,
+// and it is called only once however many events are throttled in the interval
+func (t *watcherThrottle) allow(nn types.NamespacedName, now time.Time, interval time.Duration, replay func()) bool {
+	t.Lock()
+	defer t.Unlock()
+	if t.last == nil {
+		t.last = make(map[types.NamespacedName]time.Time)
+		t.pending = make(map[types.NamespacedName]bool)
+	}
+	last, ok := t.last[nn]
+	if !ok || now.Sub(last) >= interval {
+		// the services whose events are out of interval are useless
+		for k, v := range t.last {
+			if now.Sub(v) >= interval && !t.pending[k] {
+				delete(t.last, k)
+			}
+		}
+		t.last[nn] = now
+		return true
+	}
+	if !t.pending[nn] {
+		t.pending[nn] = true
+		time.AfterFunc(interval-now.Sub(last), func() {
+			t.Lock()
+			delete(t.pending, nn)
+			t.Unlock()
+			replay()
+		})
+	}
+	return false
 }
 
 // handleTickerEvent is triggered by ticker
@@ -71,27 +147,38 @@ func (r *SmartLimiterReconciler) handleEvent(loc types.NamespacedName) metric.Qu
 		log.Infof("%v is not in interest map", loc)
 		return nil
 	}
-	if r.env.Config != nil && r.env.Config.Limiter != nil && !r.env.Config.Limiter.GetDisableAdaptive() {
+	// the metric source is mocked in non-adaptive or degraded mode
+	if r.adaptiveEnabled() {
 		return r.handlePrometheusEvent(loc)
-	} else {
-		return r.handleLocalEvent(loc)
 	}
-	return nil
+	return r.handleLocalEvent(loc)
 }
 
 func (r *SmartLimiterReconciler) handleLocalEvent(loc types.NamespacedName) metric.QueryMap {
-	pods, err := queryServicePods(r.env.K8SClient, loc)
+	instance := &microservicev1alpha2.SmartLimiter{}
+	if err := r.Client.Get(context.TODO(), loc, instance); err != nil {
+		log.Infof("get smartlimiter %v err, %+v", loc, err)
+		return nil
+	}
+	target, err := resolveTarget(instance.Spec, loc)
+	if err != nil {
+		log.Infof("%+v", err.Error())
+		return nil
+	}
+	pods, err := queryServicePods(r.kubeCache, target)
 	if err != nil {
 		log.Infof("get err in queryServicePods, %+v", err.Error())
 		return nil
 	}
-	subsetsPods, err := querySubsetPods(pods, loc)
+	subsetsPods, err := querySubsetPods(pods, target)
 	if err != nil {
 		log.Infof("%+v", err.Error())
 		return nil
 	}
 	queryMap := make(map[string][]metric.Handler, 0)
-	meta := generateMeta(subsetsPods, loc)
+	ready := queryReadyPods(r.kubeCache, pods, target)
+	meta := generateMeta(subsetsPods, ready, loc)
+	meta.Vars = generateBuiltinVars(r.kubeCache, pods, subsetsPods, ready, loc)
 	metaInfo := meta.String()
 	if metaInfo == "" {
 		return nil
@@ -104,59 +191,193 @@ func (r *SmartLimiterReconciler) handleLocalEvent(loc types.NamespacedName) metr
 // example: handler is a map
 // cpu.max => max(container_cpu_usage_seconds_total{namespace="$namespace",pod=~"$pod_name",image=""})
 func (r *SmartLimiterReconciler) handlePrometheusEvent(loc types.NamespacedName) metric.QueryMap {
-	if r.env.Config == nil || r.env.Config.Metric == nil || r.env.Config.Metric.Prometheus == nil || r.env.Config.Metric.Prometheus.Handlers == nil {
+	instance := &microservicev1alpha2.SmartLimiter{}
+	if err := r.Client.Get(context.TODO(), loc, instance); err != nil {
+		log.Infof("get smartlimiter %v err, %+v", loc, err)
+		return nil
+	}
+	var handlers map[string]*v1alpha1.Prometheus_Source_Handler
+	if metricSourceEnabled(r.cfg, microservicev1alpha2.MetricSource_prometheus) {
+		handlers = r.prometheusHandlers(instance.Spec)
+	}
+	// other sources query the pods in meta
+	podSource := metricSourceEnabled(r.cfg, microservicev1alpha2.MetricSource_kubeMetrics) ||
+		metricSourceEnabled(r.cfg, microservicev1alpha2.MetricSource_envoyStats) ||
+		metricSourceEnabled(r.cfg, microservicev1alpha2.MetricSource_limiterStats) ||
+		metricSourceEnabled(r.cfg, microservicev1alpha2.MetricSource_static)
+	if handlers == nil && !podSource {
 		log.Debugf("query handler is empty, skip query")
 		return nil
 	}
-	handlers := r.env.Config.Metric.Prometheus.Handlers
-	pods, err := queryServicePods(r.env.K8SClient, loc)
+	handlers = referencedHandlers(handlers, instance.Spec)
+	target, err := resolveTarget(instance.Spec, loc)
+	if err != nil {
+		log.Infof("%+v", err.Error())
+		return nil
+	}
+	pods, err := queryServicePods(r.kubeCache, target)
 	if err != nil {
 		log.Infof("get err in queryServicePods, %+v", err.Error())
 		return nil
 	}
 
-	subsetsPods, err := querySubsetPods(pods, loc)
+	subsetsPods, err := querySubsetPods(pods, target)
 	if err != nil {
 		log.Infof("%+v", err.Error())
 		return nil
 	}
-	return generateQueryString(subsetsPods, loc, handlers)
+	ready := queryReadyPods(r.kubeCache, pods, target)
+	meta := generateMeta(subsetsPods, ready, loc)
+	meta.Vars = generateBuiltinVars(r.kubeCache, pods, subsetsPods, ready, loc)
+	if podSource {
+		meta.Pods = subsetsPods
+		meta.PodIPs = make(map[string]string, len(pods))
+		for _, pod := range pods {
+			meta.PodIPs[pod.Name] = pod.Status.PodIP
+		}
+	}
+	return generateQueryString(meta, subsetsPods, loc, handlers)
 }
 
-// QueryServicePods query pods related to service, return pods
-func queryServicePods(c *kubernetes.Clientset, loc types.NamespacedName) ([]v1.Pod, error) {
-	var err error
-	var service *v1.Service
-	pods := make([]v1.Pod, 0)
+// prometheusHandlers merges the handlers in module config and SmartLimiter, the latter takes precedence
+func (r *SmartLimiterReconciler) prometheusHandlers(spec microservicev1alpha2.SmartLimiterSpec) map[string]*v1alpha1.Prometheus_Source_Handler {
+	var handlers map[string]*v1alpha1.Prometheus_Source_Handler
+	if r.env.Config != nil && r.env.Config.Metric != nil && r.env.Config.Metric.Prometheus != nil &&
+		len(r.env.Config.Metric.Prometheus.Handlers) > 0 {
+		handlers = make(map[string]*v1alpha1.Prometheus_Source_Handler, len(r.env.Config.Metric.Prometheus.Handlers))
+		for name, handler := range r.env.Config.Metric.Prometheus.Handlers {
+			handlers[name] = handler
+		}
+	}
+	for name, handler := range spec.PrometheusHandlers {
+		if handler == nil {
+			continue
+		}
+		if handlers == nil {
+			handlers = make(map[string]*v1alpha1.Prometheus_Source_Handler, len(spec.PrometheusHandlers))
+		}
+		handlers[name] = &v1alpha1.Prometheus_Source_Handler{
+			Query: handler.Query,
+			Type:  v1alpha1.Prometheus_Source_Type(handler.Type),
+		}
+	}
+	return handlers
+}
 
-	service, err = c.CoreV1().Services(loc.Namespace).Get(loc.Name, metav1.GetOptions{})
-	if err != nil {
-		return pods, fmt.Errorf("get service %+v faild, %s", loc, err.Error())
+// referencedHandlers returns the handlers referenced by the descriptors in spec,
+// a value handler named cpu.max is referenced by cpu.max or subset.cpu.max, group handlers are always kept
+func referencedHandlers(handlers map[string]*v1alpha1.Prometheus_Source_Handler, spec microservicev1alpha2.SmartLimiterSpec) map[string]*v1alpha1.Prometheus_Source_Handler {
+	referenced := make(map[string]struct{})
+	for _, desc := range spec.Sets {
+		if desc == nil {
+			continue
+		}
+		for _, item := range desc.Descriptor_ {
+			for _, m := range referencedMetrics(item) {
+				referenced[m] = struct{}{}
+			}
+		}
 	}
-	podList, err := c.CoreV1().Pods(loc.Namespace).List(metav1.ListOptions{
-		LabelSelector: labels.SelectorFromSet(service.Spec.Selector).String(),
-	})
-	if err != nil {
-		return pods, fmt.Errorf("query pod list faild, %+v", err.Error())
+
+	ret := make(map[string]*v1alpha1.Prometheus_Source_Handler)
+	for name, handler := range handlers {
+		if handler.Type == v1alpha1.Prometheus_Source_Group {
+			ret[name] = handler
+			continue
+		}
+		for m := range referenced {
+			if m == name || strings.HasSuffix(m, "."+name) {
+				ret[name] = handler
+				break
+			}
+		}
+	}
+	return ret
+}
+
+// QueryServicePods query pods related to the target service or selected by the workload selector
+// from the kube cache, the pods of service are kept by the handlers of kube cache, return pods
+func queryServicePods(kc *kubeCache, target limitTarget) ([]v1.Pod, error) {
+	pods := make([]v1.Pod, 0)
+
+	var podList []*v1.Pod
+	if target.service != "" {
+		names, ok := kc.podsOfService(target.namespace, target.service)
+		if !ok {
+			return pods, fmt.Errorf("get service %s/%s faild, not found", target.namespace, target.service)
+		}
+		for _, name := range names {
+			pod, err := kc.pods.Pods(target.namespace).Get(name)
+			if err != nil {
+				// deleted after the handler of pod is called
+				continue
+			}
+			podList = append(podList, pod)
+		}
+	} else {
+		var err error
+		podList, err = kc.pods.Pods(target.namespace).List(labels.SelectorFromSet(target.labels))
+		if err != nil {
+			return pods, fmt.Errorf("query pod list faild, %+v", err.Error())
+		}
 	}
 
-	for _, item := range podList.Items {
+	for _, item := range podList {
 		if item.DeletionTimestamp != nil {
 			// pod is deleted
 			continue
 		}
-		pods = append(pods, item)
+		// the objects in cache are shared, copy it
+		pods = append(pods, *item.DeepCopy())
 	}
 	return pods, nil
 }
 
+// queryReadyPods returns the names of pods which are ready addresses in the endpoints of service,
+// the ready condition of pods is used if the endpoints is not available or the target has no service
+func queryReadyPods(kc *kubeCache, pods []v1.Pod, target limitTarget) map[string]struct{} {
+	ready := make(map[string]struct{})
+	var ep *v1.Endpoints
+	err := fmt.Errorf("workloads are selected by labels")
+	if target.service != "" {
+		ep, err = kc.endpoints.Endpoints(target.namespace).Get(target.service)
+	}
+	if err != nil {
+		log.Debugf("get endpoints of %s/%s err, %+v, use the ready condition of pods", target.namespace, target.service, err)
+		for i := range pods {
+			if isPodReady(&pods[i]) {
+				ready[pods[i].Name] = struct{}{}
+			}
+		}
+		return ready
+	}
+	for _, subset := range ep.Subsets {
+		for _, addr := range subset.Addresses {
+			if addr.TargetRef != nil && addr.TargetRef.Kind == "Pod" {
+				ready[addr.TargetRef.Name] = struct{}{}
+			}
+		}
+	}
+	return ready
+}
+
+func countReadyPods(pods []string, ready map[string]struct{}) int {
+	n := 0
+	for _, pod := range pods {
+		if _, ok := ready[pod]; ok {
+			n++
+		}
+	}
+	return n
+}
+
 // QuerySubsetPods  query pods related to subset
-func querySubsetPods(pods []v1.Pod, loc types.NamespacedName) (map[string][]string, error) {
+func querySubsetPods(pods []v1.Pod, target limitTarget) (map[string][]string, error) {
 	subsetsPods := make(map[string][]string)
-	host := util.UnityHost(loc.Name, loc.Namespace)
+	host := target.host()
 
 	// if subset is existed, assign pods to subset
-	if controllers.HostSubsetMapping.Get(host) != nil {
+	if host != "" && controllers.HostSubsetMapping.Get(host) != nil {
 		subsets, ok := controllers.HostSubsetMapping.Get(host).([]*v1alpha3.Subset)
 		if ok {
 			for _, pod := range pods {
@@ -191,12 +412,9 @@ func append2Base(subsetsPods map[string][]string, pod v1.Pod) {
 }
 
 // GenerateQueryString
-func generateQueryString(subsetsPods map[string][]string, loc types.NamespacedName, handlers map[string]*v1alpha1.Prometheus_Source_Handler) map[string][]metric.Handler {
+func generateQueryString(meta StaticMeta, subsetsPods map[string][]string, loc types.NamespacedName, handlers map[string]*v1alpha1.Prometheus_Source_Handler) map[string][]metric.Handler {
 	queryMap := make(map[string][]metric.Handler, 0)
 	queryHandlers := make([]metric.Handler, 0)
-	isGroup := make(map[string]bool)
-
-	meta := generateMeta(subsetsPods, loc)
 
 	//  example
 	//	item 	=>  cpu.max: max(container_cpu_usage_seconds_total{namespace="$namespace",pod=~"$pod_name",image=""})
@@ -204,7 +422,8 @@ func generateQueryString(subsetsPods map[string][]string, loc types.NamespacedNa
 		if handler.Query == "" {
 			continue
 		}
-		queryHandlers, isGroup = replaceQueryString(customMetricName, handler.Query, handler.Type, loc, subsetsPods)
+		hs, isGroup := replaceQueryString(customMetricName, handler.Query, handler.Type, loc, subsetsPods)
+		queryHandlers = append(queryHandlers, hs...)
 
 		for name, group := range isGroup {
 			meta.IsGroup[name] = group
@@ -219,16 +438,15 @@ func generateQueryString(subsetsPods map[string][]string, loc types.NamespacedNa
 }
 
 // some metric is not query from prometheus, so add it to staticMeta
-func generateMeta(subsetsPods map[string][]string, loc types.NamespacedName) StaticMeta {
-	// NPOD record like
+func generateMeta(subsetsPods map[string][]string, ready map[string]struct{}, loc types.NamespacedName) StaticMeta {
+	// NPOD record the number of ready pods like
 	// _base.pod: 6
 	// v1.pod: 2
 	// v2.pod: 4
+	// subsets without ready pods are recorded as 0, so the number of the last query is deleted
 	nPod := make(map[string]int)
 	for k, v := range subsetsPods {
-		if len(v) > 0 {
-			nPod[k+".pod"] = len(v)
-		}
+		nPod[k+".pod"] = countReadyPods(v, ready)
 	}
 	meta := StaticMeta{
 		Name:      loc.Name,
@@ -278,13 +496,21 @@ func replaceQueryString(metricName string, query string, typ v1alpha1.Prometheus
 	return handlers, isGroup
 }
 
-func newPrometheusSourceConfig(env bootstrap.Environment) (metric.PrometheusSourceConfig, error) {
+func newPrometheusSourceConfig(env bootstrap.Environment, s *microservicev1alpha2.MetricSource, timeout time.Duration) (metric.PrometheusSourceConfig, error) {
 	if env.Config == nil || env.Config.Metric == nil || env.Config.Metric.Prometheus == nil {
 		return metric.PrometheusSourceConfig{}, stderrors.New("failure create prometheus client, empty prometheus config")
 	}
+	address := env.Config.Metric.Prometheus.Address
+	if s.GetPrometheusConfig().GetAddress() != "" {
+		address = s.GetPrometheusConfig().GetAddress()
+	}
+	rt, err := newPrometheusRoundTripper(s.GetPrometheusConfig(), timeout)
+	if err != nil {
+		return metric.PrometheusSourceConfig{}, err
+	}
 	promClient, err := prometheusApi.NewClient(prometheusApi.Config{
-		Address:      env.Config.Metric.Prometheus.Address,
-		RoundTripper: nil,
+		Address:      address,
+		RoundTripper: rt,
 	})
 	if err != nil {
 		return metric.PrometheusSourceConfig{}, err
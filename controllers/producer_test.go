@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cmap "github.com/orcaman/concurrent-map"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	slime_model "slime.io/slime/framework/model"
+	"slime.io/slime/framework/model/metric"
+	"slime.io/slime/framework/model/trigger"
+)
+
+func TestGenerateMetaEmptySubset(t *testing.T) {
+	subsetsPods := map[string][]string{
+		"_base": {"a", "b"},
+		"v1":    {"a"},
+		"v2":    {"b"},
+	}
+	ready := map[string]struct{}{"a": {}}
+	meta := generateMeta(subsetsPods, ready, types.NamespacedName{Namespace: "default", Name: "reviews"})
+	want := map[string]int{"_base.pod": 1, "v1.pod": 1, "v2.pod": 0}
+	if !reflect.DeepEqual(meta.NPod, want) {
+		t.Errorf("got %v, want %v", meta.NPod, want)
+	}
+}
+
+func TestConsumeMetricDeletesEmptySubset(t *testing.T) {
+	loc := types.NamespacedName{Namespace: "default", Name: "reviews"}
+	r := newFakeReconciler(t)
+	r.metricInfo, r.interest, r.quotaStates = cmap.New(), cmap.New(), cmap.New()
+	r.metricInfo.Set(loc.String(), &slime_model.Endpoints{
+		Location: loc,
+		Info:     map[string]string{"_base.pod": "2", "v2.pod": "1"},
+	})
+	r.recordMetricTime(loc, map[string]string{"_base.pod": "2", "v2.pod": "1"})
+
+	meta := StaticMeta{Namespace: loc.Namespace, Name: loc.Name, NPod: map[string]int{"_base.pod": 1, "v2.pod": 0}}
+	r.ConsumeMetric(metric.Metric{meta.String(): nil})
+
+	i, _ := r.metricInfo.Get(loc.String())
+	if got, want := i.(*slime_model.Endpoints).Info, map[string]string{"_base.pod": "1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if _, ok := r.metricUpdateTime(loc)["v2.pod"]; ok {
+		t.Errorf("update time of v2.pod is not deleted")
+	}
+}
+
+func TestWatcherThrottle(t *testing.T) {
+	var throttle watcherThrottle
+	nn := types.NamespacedName{Namespace: "default", Name: "reviews"}
+	interval := 50 * time.Millisecond
+	var replayed int32
+	replay := func() { atomic.AddInt32(&replayed, 1) }
+
+	now := time.Now()
+	if !throttle.allow(nn, now, interval, replay) {
+		t.Fatalf("first event should be allowed")
+	}
+	for i := 1; i <= 3; i++ {
+		if throttle.allow(nn, now.Add(time.Duration(i)*time.Millisecond), interval, replay) {
+			t.Fatalf("event %d in the interval should be throttled", i)
+		}
+	}
+	other := types.NamespacedName{Namespace: "default", Name: "ratings"}
+	if !throttle.allow(other, now, interval, replay) {
+		t.Errorf("events of other services should not be throttled")
+	}
+
+	time.Sleep(2 * interval)
+	if got := atomic.LoadInt32(&replayed); got != 1 {
+		t.Errorf("got %d replays, want 1", got)
+	}
+	if !throttle.allow(nn, time.Now(), interval, replay) {
+		t.Errorf("event after the interval should be allowed")
+	}
+}
+
+func TestSubscribeWithoutProducers(t *testing.T) {
+	// nothing receives the events, like on followers
+	r := &SmartLimiterReconciler{watcherEventChan: make(chan trigger.WatcherEvent, 2)}
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 4; i++ {
+			r.subscribe("reviews.default.svc.cluster.local", nil)
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("subscribe blocks without producers")
+	}
+	if got := len(r.watcherEventChan); got != 2 {
+		t.Errorf("got %d buffered events, want 2", got)
+	}
+	event := <-r.watcherEventChan
+	if want := (types.NamespacedName{Namespace: "default", Name: "reviews"}); event.NN != want || event.GVK.Kind != "DestinationRule" {
+		t.Errorf("got event %v", event)
+	}
+}
+
+func TestProducersRunnable(t *testing.T) {
+	var started int32
+	start := func() { atomic.AddInt32(&started, 1) }
+
+	runnable := producersRunnable(func(<-chan struct{}) bool { return true }, start)
+	// the runnables not implementing LeaderElectionRunnable need leader election
+	if le, ok := runnable.(manager.LeaderElectionRunnable); ok && !le.NeedLeaderElection() {
+		t.Errorf("producers should only run on the leader")
+	}
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() { done <- runnable.Start(stop) }()
+	select {
+	case err := <-done:
+		t.Fatalf("runnable returns %v before stop", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if atomic.LoadInt32(&started) != 1 {
+		t.Errorf("producers are not started")
+	}
+	close(stop)
+	if err := <-done; err != nil {
+		t.Errorf("got %v after stop", err)
+	}
+
+	failed := producersRunnable(func(<-chan struct{}) bool { return false }, start)
+	if err := failed.Start(make(chan struct{})); err == nil {
+		t.Errorf("should fail if the cache is not synced")
+	}
+	if atomic.LoadInt32(&started) != 1 {
+		t.Errorf("producers are started before the cache is synced")
+	}
+}
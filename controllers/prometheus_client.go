@@ -0,0 +1,148 @@
+package controllers
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	microservicev1alpha2 "slime.io/slime/modules/limiter/api/v1alpha2"
+)
+
+// newPrometheusRoundTripper returns the round tripper of the prometheus client with the auth, tls, headers
+// and timeout of config, the config may be nil
+func newPrometheusRoundTripper(config *microservicev1alpha2.PrometheusConfig, timeout time.Duration) (http.RoundTripper, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if config.GetTls() != nil {
+		tlsConfig, err := newPrometheusTLSConfig(config.GetTls())
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+	var rt http.RoundTripper = transport
+	if config != nil {
+		rt = &prometheusAuthRoundTripper{config: config, next: rt}
+	}
+	if timeout > 0 {
+		rt = &timeoutRoundTripper{timeout: timeout, next: rt}
+	}
+	return rt, nil
+}
+
+func newPrometheusTLSConfig(config *microservicev1alpha2.TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName:         config.ServerName,
+		InsecureSkipVerify: config.InsecureSkipVerify,
+	}
+	if config.CaFile != "" {
+		ca, err := ioutil.ReadFile(config.CaFile)
+		if err != nil {
+			return nil, fmt.Errorf("read ca file %s err, %v", config.CaFile, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificate found in ca file %s", config.CaFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if config.CertFile != "" || config.KeyFile != "" {
+		if config.CertFile == "" || config.KeyFile == "" {
+			return nil, fmt.Errorf("both certFile and keyFile should be specified")
+		}
+		certFile, keyFile := config.CertFile, config.KeyFile
+		if _, err := tls.LoadX509KeyPair(certFile, keyFile); err != nil {
+			return nil, fmt.Errorf("load client certificate err, %v", err)
+		}
+		// the certificate is loaded in every handshake so the rotated one is used
+		tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+			if err != nil {
+				return nil, err
+			}
+			return &cert, nil
+		}
+	}
+	return tlsConfig, nil
+}
+
+// prometheusAuthRoundTripper sets the headers and the credential of requests
+type prometheusAuthRoundTripper struct {
+	config *microservicev1alpha2.PrometheusConfig
+	next   http.RoundTripper
+}
+
+func (rt *prometheusAuthRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	// the request should not be modified by round tripper
+	req = cloneRequest(req)
+	for k, v := range rt.config.Headers {
+		req.Header.Set(k, v)
+	}
+
+	token := rt.config.BearerToken
+	if rt.config.BearerTokenFile != "" {
+		b, err := ioutil.ReadFile(rt.config.BearerTokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("read bearer token file %s err, %v", rt.config.BearerTokenFile, err)
+		}
+		token = strings.TrimSpace(string(b))
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else if auth := rt.config.BasicAuth; auth != nil && auth.Username != "" {
+		password := auth.Password
+		if auth.PasswordFile != "" {
+			b, err := ioutil.ReadFile(auth.PasswordFile)
+			if err != nil {
+				return nil, fmt.Errorf("read password file %s err, %v", auth.PasswordFile, err)
+			}
+			password = strings.TrimSpace(string(b))
+		}
+		req.SetBasicAuth(auth.Username, password)
+	}
+	return rt.next.RoundTrip(req)
+}
+
+// timeoutRoundTripper cancels the request if the response is not read in timeout, the prometheus source
+// of framework queries without deadline
+type timeoutRoundTripper struct {
+	timeout time.Duration
+	next    http.RoundTripper
+}
+
+func (rt *timeoutRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), rt.timeout)
+	resp, err := rt.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelReadCloser{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelReadCloser releases the context of request when the body is closed
+type cancelReadCloser struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelReadCloser) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
+
+func cloneRequest(req *http.Request) *http.Request {
+	r := req.WithContext(req.Context())
+	r.Header = make(http.Header, len(req.Header))
+	for k, v := range req.Header {
+		r.Header[k] = append([]string(nil), v...)
+	}
+	return r
+}
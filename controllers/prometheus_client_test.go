@@ -0,0 +1,273 @@
+package controllers
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"slime.io/slime/framework/apis/config/v1alpha1"
+	"slime.io/slime/framework/bootstrap"
+	microservicev1alpha2 "slime.io/slime/modules/limiter/api/v1alpha2"
+)
+
+const prometheusStubResponse = `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1,"3"]}]}}`
+
+// prometheusStub serves the query api of prometheus, the request is rejected if check returns false
+func prometheusStub(check func(r *http.Request) bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if check != nil && !check(r) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(prometheusStubResponse))
+	})
+}
+
+func prometheusEnv(address string) bootstrap.Environment {
+	return bootstrap.Environment{
+		Config: &v1alpha1.Config{
+			Metric: &v1alpha1.Metric{
+				Prometheus: &v1alpha1.Prometheus_Source{Address: address},
+			},
+		},
+	}
+}
+
+func queryPrometheus(t *testing.T, address string, config *microservicev1alpha2.PrometheusConfig, timeout time.Duration) error {
+	t.Helper()
+	s := &microservicev1alpha2.MetricSource{Type: microservicev1alpha2.MetricSource_prometheus, PrometheusConfig: config}
+	pc, err := newPrometheusSourceConfig(prometheusEnv(address), s, timeout)
+	if err != nil {
+		t.Fatalf("new prometheus source config err, %v", err)
+	}
+	_, _, err = pc.Api.Query(context.Background(), "up", time.Now())
+	return err
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPrometheusBearerTokenAndHeaders(t *testing.T) {
+	srv := httptest.NewServer(prometheusStub(func(r *http.Request) bool {
+		return r.Header.Get("Authorization") == "Bearer token" && r.Header.Get("X-Scope-OrgID") == "tenant"
+	}))
+	defer srv.Close()
+
+	config := &microservicev1alpha2.PrometheusConfig{
+		BearerToken: "token",
+		Headers:     map[string]string{"X-Scope-OrgID": "tenant"},
+	}
+	if err := queryPrometheus(t, srv.URL, config, time.Second); err != nil {
+		t.Fatalf("query with bearer token err, %v", err)
+	}
+	if err := queryPrometheus(t, srv.URL, nil, time.Second); err == nil {
+		t.Fatalf("query without bearer token should fail")
+	}
+}
+
+func TestPrometheusBearerTokenFileRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "prometheus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	want := "old"
+	srv := httptest.NewServer(prometheusStub(func(r *http.Request) bool {
+		return r.Header.Get("Authorization") == "Bearer "+want
+	}))
+	defer srv.Close()
+
+	config := &microservicev1alpha2.PrometheusConfig{
+		BearerToken:     "ignored",
+		BearerTokenFile: writeFile(t, dir, "token", "old\n"),
+	}
+	s := &microservicev1alpha2.MetricSource{PrometheusConfig: config}
+	pc, err := newPrometheusSourceConfig(prometheusEnv(srv.URL), s, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := pc.Api.Query(context.Background(), "up", time.Now()); err != nil {
+		t.Fatalf("query with token file err, %v", err)
+	}
+
+	want = "new"
+	writeFile(t, dir, "token", "new")
+	if _, _, err := pc.Api.Query(context.Background(), "up", time.Now()); err != nil {
+		t.Fatalf("query with rotated token file err, %v", err)
+	}
+}
+
+func TestPrometheusBasicAuth(t *testing.T) {
+	dir, err := ioutil.TempDir("", "prometheus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	srv := httptest.NewServer(prometheusStub(func(r *http.Request) bool {
+		user, password, ok := r.BasicAuth()
+		return ok && user == "admin" && password == "secret"
+	}))
+	defer srv.Close()
+
+	cases := []struct {
+		name    string
+		auth    *microservicev1alpha2.BasicAuth
+		wantErr bool
+	}{
+		{"password", &microservicev1alpha2.BasicAuth{Username: "admin", Password: "secret"}, false},
+		{"password file", &microservicev1alpha2.BasicAuth{Username: "admin", Password: "wrong",
+			PasswordFile: writeFile(t, dir, "password", "secret")}, false},
+		{"wrong password", &microservicev1alpha2.BasicAuth{Username: "admin", Password: "wrong"}, true},
+	}
+	for _, c := range cases {
+		err := queryPrometheus(t, srv.URL, &microservicev1alpha2.PrometheusConfig{BasicAuth: c.auth}, time.Second)
+		if (err != nil) != c.wantErr {
+			t.Errorf("%s: got err %v, want err %v", c.name, err, c.wantErr)
+		}
+	}
+}
+
+func TestPrometheusAddressOverride(t *testing.T) {
+	srv := httptest.NewServer(prometheusStub(nil))
+	defer srv.Close()
+
+	config := &microservicev1alpha2.PrometheusConfig{Address: srv.URL}
+	if err := queryPrometheus(t, "http://127.0.0.1:1", config, time.Second); err != nil {
+		t.Fatalf("query overridden address err, %v", err)
+	}
+}
+
+func TestPrometheusTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(300 * time.Millisecond):
+		case <-r.Context().Done():
+			return
+		}
+		prometheusStub(nil).ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	start := time.Now()
+	if err := queryPrometheus(t, srv.URL, nil, 50*time.Millisecond); err == nil {
+		t.Fatalf("query should time out")
+	}
+	if elapsed := time.Since(start); elapsed > 250*time.Millisecond {
+		t.Fatalf("query returns after %s, want about the timeout", elapsed)
+	}
+}
+
+func TestPrometheusTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "prometheus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	srv := httptest.NewTLSServer(prometheusStub(nil))
+	defer srv.Close()
+	caFile := writeFile(t, dir, "ca.crt", string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})))
+
+	if err := queryPrometheus(t, srv.URL, nil, time.Second); err == nil {
+		t.Fatalf("query with unknown ca should fail")
+	}
+	config := &microservicev1alpha2.PrometheusConfig{Tls: &microservicev1alpha2.TLSConfig{CaFile: caFile}}
+	if err := queryPrometheus(t, srv.URL, config, time.Second); err != nil {
+		t.Fatalf("query with ca err, %v", err)
+	}
+	config = &microservicev1alpha2.PrometheusConfig{Tls: &microservicev1alpha2.TLSConfig{InsecureSkipVerify: true}}
+	if err := queryPrometheus(t, srv.URL, config, time.Second); err != nil {
+		t.Fatalf("query skipping verification err, %v", err)
+	}
+}
+
+func TestPrometheusClientCertificate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "prometheus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	certPEM, keyPEM := generateClientCertificate(t)
+	certFile := writeFile(t, dir, "tls.crt", string(certPEM))
+	keyFile := writeFile(t, dir, "tls.key", string(keyPEM))
+	clientCAs := x509.NewCertPool()
+	clientCAs.AppendCertsFromPEM(certPEM)
+
+	srv := httptest.NewUnstartedServer(prometheusStub(nil))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	srv.StartTLS()
+	defer srv.Close()
+
+	tlsConfig := &microservicev1alpha2.TLSConfig{InsecureSkipVerify: true}
+	if err := queryPrometheus(t, srv.URL, &microservicev1alpha2.PrometheusConfig{Tls: tlsConfig}, time.Second); err == nil {
+		t.Fatalf("query without client certificate should fail")
+	}
+	tlsConfig = &microservicev1alpha2.TLSConfig{InsecureSkipVerify: true, CertFile: certFile, KeyFile: keyFile}
+	if err := queryPrometheus(t, srv.URL, &microservicev1alpha2.PrometheusConfig{Tls: tlsConfig}, time.Second); err != nil {
+		t.Fatalf("query with client certificate err, %v", err)
+	}
+}
+
+func TestPrometheusInvalidTLSConfig(t *testing.T) {
+	cases := []*microservicev1alpha2.TLSConfig{
+		{CaFile: "/nonexistent/ca.crt"},
+		{CertFile: "/nonexistent/tls.crt"},
+	}
+	for _, c := range cases {
+		if _, err := newPrometheusRoundTripper(&microservicev1alpha2.PrometheusConfig{Tls: c}, time.Second); err == nil {
+			t.Errorf("tls config %+v should be invalid", c)
+		}
+	}
+}
+
+// generateClientCertificate returns a self-signed client certificate and its key in pem
+func generateClientCertificate(t *testing.T) ([]byte, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "limiter"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
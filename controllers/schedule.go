@@ -0,0 +1,348 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	microservicev1alpha2 "slime.io/slime/modules/limiter/api/v1alpha2"
+	"slime.io/slime/modules/limiter/model"
+)
+
+// cronSchedule is a parsed standard cron expression, each field is a bitset of the allowed values
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// day-of-month and day-of-week are ORed if both of them are restricted
+	domStar, dowStar bool
+}
+
+type cronField struct {
+	min, max int
+}
+
+var cronFields = []cronField{
+	{0, 59}, // minute
+	{0, 23}, // hour
+	{1, 31}, // day of month
+	{1, 12}, // month
+	{0, 7},  // day of week, both 0 and 7 are Sunday
+}
+
+// parseCron parses the cron expression with 5 fields, each of which supports *, a-b, */n, a-b/n and lists
+func parseCron(spec string) (*cronSchedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("invalid cron %q, expected %d fields", spec, len(cronFields))
+	}
+	bits := make([]uint64, len(fields))
+	for i, field := range fields {
+		b, err := parseCronField(field, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron %q, %v", spec, err)
+		}
+		bits[i] = b
+	}
+	// 7 is Sunday as well
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+	return &cronSchedule{
+		minute:  bits[0],
+		hour:    bits[1],
+		dom:     bits[2],
+		month:   bits[3],
+		dow:     bits[4],
+		domStar: strings.HasPrefix(fields[2], "*"),
+		dowStar: strings.HasPrefix(fields[4], "*"),
+	}, nil
+}
+
+func parseCronField(field string, r cronField) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			step, part = n, part[:i]
+		}
+		start, end := r.min, r.max
+		if part != "*" {
+			if i := strings.Index(part, "-"); i >= 0 {
+				var err1, err2 error
+				start, err1 = strconv.Atoi(part[:i])
+				end, err2 = strconv.Atoi(part[i+1:])
+				if err1 != nil || err2 != nil {
+					return 0, fmt.Errorf("invalid range %q", part)
+				}
+			} else {
+				n, err := strconv.Atoi(part)
+				if err != nil {
+					return 0, fmt.Errorf("invalid value %q", part)
+				}
+				start = n
+				// a/n means from a to max
+				if step > 1 {
+					end = r.max
+				} else {
+					end = n
+				}
+			}
+		}
+		if start < r.min || end > r.max || start > end {
+			return 0, fmt.Errorf("%q is out of range [%d, %d]", part, r.min, r.max)
+		}
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func (c *cronSchedule) matchDay(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domStar || c.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// next returns the first time matching the cron after t, the zero time is returned if not found in 5 years
+func (c *cronSchedule) next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			t = forward(t, time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc))
+		case !c.matchDay(t):
+			t = forward(t, time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc))
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = forward(t, time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc))
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// prev returns the last time matching the cron not after t and not before since
+func (c *cronSchedule) prev(t, since time.Time) (time.Time, bool) {
+	loc := t.Location()
+	t = t.Truncate(time.Minute)
+	for !t.Before(since) {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			t = backward(t, time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc).Add(-time.Minute))
+		case !c.matchDay(t):
+			t = backward(t, time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc).Add(-time.Minute))
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = backward(t, time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc).Add(-time.Minute))
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(-time.Minute)
+		default:
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// forward returns the wall clock jump to if it is after t, otherwise the next minute. time.Date normalizes
+// the clock skipped by DST backward, so the jump may not make progress
+func forward(t, to time.Time) time.Time {
+	if to.After(t) {
+		return to
+	}
+	return t.Add(time.Minute)
+}
+
+// backward returns the wall clock jump to if it is before t, otherwise the previous minute
+func backward(t, to time.Time) time.Time {
+	if to.Before(t) {
+		return to
+	}
+	return t.Add(-time.Minute)
+}
+
+// scheduleWindow tells whether the schedule is active at now, and returns the next time the result may change
+func scheduleWindow(s *microservicev1alpha2.Schedule, now time.Time) (active bool, boundary time.Time, err error) {
+	loc := time.UTC
+	if s.Timezone != "" {
+		if loc, err = time.LoadLocation(s.Timezone); err != nil {
+			return false, time.Time{}, err
+		}
+	}
+	now = now.In(loc)
+
+	if s.Cron != "" {
+		c, err := parseCron(s.Cron)
+		if err != nil {
+			return false, time.Time{}, err
+		}
+		if s.Duration == nil {
+			return false, time.Time{}, fmt.Errorf("duration of cron %q is not specified", s.Cron)
+		}
+		duration := time.Duration(s.Duration.Seconds)*time.Second + time.Duration(s.Duration.Nanos)
+		boundary = c.next(now)
+		if start, ok := c.prev(now, now.Add(-duration)); ok && now.Before(start.Add(duration)) {
+			active = true
+			if end := start.Add(duration); boundary.IsZero() || end.Before(boundary) {
+				boundary = end
+			}
+		}
+		return active, boundary, nil
+	}
+
+	if s.Start == "" || s.End == "" {
+		return false, time.Time{}, fmt.Errorf("either cron or start and end should be specified")
+	}
+	if startClock, err1 := time.ParseInLocation(model.ScheduleClockLayout, s.Start, loc); err1 == nil {
+		endClock, err := time.ParseInLocation(model.ScheduleClockLayout, s.End, loc)
+		if err != nil {
+			return false, time.Time{}, fmt.Errorf("invalid end %q, %v", s.End, err)
+		}
+		return dailyWindow(startClock, endClock, now)
+	}
+
+	start, err := parseScheduleTime(s.Start, loc)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+	end, err := parseScheduleTime(s.End, loc)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+	switch {
+	case now.Before(start):
+		return false, start, nil
+	case now.Before(end):
+		return true, end, nil
+	default:
+		return false, time.Time{}, nil
+	}
+}
+
+// dailyWindow handles the window from the clock of start to the clock of end every day
+func dailyWindow(startClock, endClock, now time.Time) (bool, time.Time, error) {
+	at := func(day time.Time, clock time.Time) time.Time {
+		return time.Date(day.Year(), day.Month(), day.Day(), clock.Hour(), clock.Minute(), 0, 0, now.Location())
+	}
+	start, end := at(now, startClock), at(now, endClock)
+	if !end.After(start) {
+		// crosses midnight, the window may start yesterday
+		if now.Before(end) {
+			return true, end, nil
+		}
+		end = end.AddDate(0, 0, 1)
+	}
+	switch {
+	case now.Before(start):
+		return false, start, nil
+	case now.Before(end):
+		return true, end, nil
+	default:
+		return false, start.AddDate(0, 0, 1), nil
+	}
+}
+
+func parseScheduleTime(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation(model.ScheduleTimeLayout, value, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, %v", value, err)
+	}
+	return t, nil
+}
+
+// scheduledQuota returns the quota of the first active schedule, or action.quota if none is active
+func scheduledQuota(des *microservicev1alpha2.SmartLimitDescriptor, now time.Time) string {
+	for _, s := range des.Schedules {
+		if s == nil {
+			continue
+		}
+		active, _, err := scheduleWindow(s, now)
+		if err != nil {
+			log.Errorf("invalid schedule %+v, %v", s, err)
+			continue
+		}
+		if active {
+			return s.Quota
+		}
+	}
+	return des.Action.Quota
+}
+
+// nextScheduleBoundary returns the earliest time any schedule in spec switches
+func nextScheduleBoundary(spec microservicev1alpha2.SmartLimiterSpec, now time.Time) time.Time {
+	var next time.Time
+	for _, set := range spec.Sets {
+		if set == nil {
+			continue
+		}
+		for _, des := range set.Descriptor_ {
+			for _, s := range des.Schedules {
+				if s == nil {
+					continue
+				}
+				_, boundary, err := scheduleWindow(s, now)
+				if err != nil || boundary.IsZero() {
+					continue
+				}
+				if next.IsZero() || boundary.Before(next) {
+					next = boundary
+				}
+			}
+		}
+	}
+	return next
+}
+
+// resetScheduleTimer refreshes the SmartLimiter at the next schedule boundary, independent of the metric ticker
+func (r *SmartLimiterReconciler) resetScheduleTimer(loc types.NamespacedName, spec microservicev1alpha2.SmartLimiterSpec) {
+	key := loc.Namespace + "/" + loc.Name
+	next := nextScheduleBoundary(spec, time.Now())
+	if next.IsZero() {
+		r.stopScheduleTimer(loc)
+		return
+	}
+	d := time.Until(next) + model.ScheduleBoundaryDelay
+	timer := time.AfterFunc(d, func() {
+		log.Infof("schedule boundary %s of %s reached, refresh it", next, loc)
+		r.refreshLimiter(loc)
+	})
+	if old, ok := r.scheduleTimers.Get(key); ok {
+		old.(*time.Timer).Stop()
+	}
+	r.scheduleTimers.Set(key, timer)
+}
+
+func (r *SmartLimiterReconciler) stopScheduleTimer(loc types.NamespacedName) {
+	if old, ok := r.scheduleTimers.Pop(loc.Namespace + "/" + loc.Name); ok {
+		old.(*time.Timer).Stop()
+	}
+}
+
+// refreshLimiter refreshes the SmartLimiter with the latest metrics, it is triggered out of the metric producers
+func (r *SmartLimiterReconciler) refreshLimiter(loc types.NamespacedName) {
+	unlock := r.lockRefresh(loc)
+	defer unlock()
+	instance := &microservicev1alpha2.SmartLimiter{}
+	if err := r.Client.Get(context.TODO(), loc, instance); err != nil {
+		log.Errorf("get smartlimiter %v err, %+v", loc, err)
+		return
+	}
+	if _, err := r.refresh(instance); err != nil {
+		log.Errorf("refresh %v err, %+v", loc, err)
+	}
+}
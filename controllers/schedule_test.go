@@ -0,0 +1,204 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	microservicev1alpha2 "slime.io/slime/modules/limiter/api/v1alpha2"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("load location %s err, %v", name, err)
+	}
+	return loc
+}
+
+func TestParseCron(t *testing.T) {
+	cases := []struct {
+		spec  string
+		valid bool
+	}{
+		{"* * * * *", true},
+		{"*/15 0-6,22-23 1 1-12/2 1-5", true},
+		{"0 0 * * 7", true},
+		{"5/10 * * * *", true},
+		{"* * * *", false},
+		{"60 * * * *", false},
+		{"* 24 * * *", false},
+		{"* * 0 * *", false},
+		{"* * * 13 *", false},
+		{"* * * * 8", false},
+		{"*/0 * * * *", false},
+		{"5-1 * * * *", false},
+		{"a * * * *", false},
+	}
+	for _, c := range cases {
+		if _, err := parseCron(c.spec); (err == nil) != c.valid {
+			t.Errorf("cron %q: got err %v, want valid %v", c.spec, err, c.valid)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	ny := mustLoadLocation(t, "America/New_York")
+	utc := func(year int, month time.Month, day, hour, min int) time.Time {
+		return time.Date(year, month, day, hour, min, 0, 0, time.UTC)
+	}
+	cases := []struct {
+		name string
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{"month end", "0 0 1 * *", utc(2021, 1, 31, 12, 0), utc(2021, 2, 1, 0, 0)},
+		{"year end", "0 0 * * *", utc(2021, 12, 31, 23, 59), utc(2022, 1, 1, 0, 0)},
+		{"strictly after", "30 23 31 12 *", utc(2021, 12, 31, 23, 30), utc(2022, 12, 31, 23, 30)},
+		{"leap day", "0 0 29 2 *", utc(2021, 3, 1, 0, 0), utc(2024, 2, 29, 0, 0)},
+		{"skip short month", "0 0 31 * *", utc(2021, 4, 1, 0, 0), utc(2021, 5, 31, 0, 0)},
+		{"never", "0 0 30 2 *", utc(2021, 1, 1, 0, 0), time.Time{}},
+		// 2021-09-07 is Tuesday
+		{"dom or dow by dom", "0 0 8 * 5", utc(2021, 9, 7, 0, 0), utc(2021, 9, 8, 0, 0)},
+		{"dom or dow by dow", "0 0 8 * 5", utc(2021, 9, 8, 0, 0), utc(2021, 9, 10, 0, 0)},
+		{"dom only", "0 0 8 * *", utc(2021, 9, 8, 0, 0), utc(2021, 10, 8, 0, 0)},
+		{"dow only", "0 0 * * 5", utc(2021, 9, 7, 0, 0), utc(2021, 9, 10, 0, 0)},
+		{"dom and star dow step", "0 0 8 * */1", utc(2021, 9, 8, 0, 0), utc(2021, 10, 8, 0, 0)},
+		{"sunday as 7", "0 0 * * 7", utc(2021, 9, 7, 0, 0), utc(2021, 9, 12, 0, 0)},
+		// the clock skipped by DST does not match, the next day is used
+		{"dst skipped clock", "30 2 * * *", time.Date(2021, 3, 14, 1, 0, 0, 0, ny), time.Date(2021, 3, 15, 2, 30, 0, 0, ny)},
+		{"dst after skipped clock", "0 3 * * *", time.Date(2021, 3, 14, 1, 0, 0, 0, ny), utc(2021, 3, 14, 7, 0)},
+		{"dst repeated clock first", "30 1 * * *", time.Date(2021, 11, 7, 0, 0, 0, 0, ny), utc(2021, 11, 7, 5, 30)},
+		{"dst repeated clock second", "30 1 * * *", utc(2021, 11, 7, 5, 31).In(ny), utc(2021, 11, 7, 6, 30)},
+		{"dst hourly", "0 * * * *", utc(2021, 11, 7, 5, 0).In(ny), utc(2021, 11, 7, 6, 0)},
+	}
+	for _, c := range cases {
+		cron, err := parseCron(c.spec)
+		if err != nil {
+			t.Fatalf("%s: parse cron %q err, %v", c.name, c.spec, err)
+		}
+		if got := cron.next(c.from); !got.Equal(c.want) {
+			t.Errorf("%s: next of %q from %s got %s, want %s", c.name, c.spec, c.from, got, c.want)
+		}
+	}
+}
+
+func TestCronPrev(t *testing.T) {
+	ny := mustLoadLocation(t, "America/New_York")
+	utc := func(year int, month time.Month, day, hour, min int) time.Time {
+		return time.Date(year, month, day, hour, min, 0, 0, time.UTC)
+	}
+	cases := []struct {
+		name   string
+		spec   string
+		from   time.Time
+		since  time.Time
+		want   time.Time
+		wantOk bool
+	}{
+		{"inclusive", "0 0 1 * *", utc(2021, 3, 1, 0, 0), utc(2021, 1, 1, 0, 0), utc(2021, 3, 1, 0, 0), true},
+		{"month start", "0 0 1 * *", utc(2021, 3, 15, 0, 0), utc(2021, 1, 1, 0, 0), utc(2021, 3, 1, 0, 0), true},
+		{"month end", "0 0 31 * *", utc(2021, 5, 1, 0, 0), utc(2021, 1, 1, 0, 0), utc(2021, 3, 31, 0, 0), true},
+		{"year end", "59 23 31 12 *", utc(2022, 1, 1, 0, 0), utc(2021, 1, 1, 0, 0), utc(2021, 12, 31, 23, 59), true},
+		{"dom or dow by dom", "0 0 8 * 5", utc(2021, 9, 9, 12, 0), utc(2021, 9, 1, 0, 0), utc(2021, 9, 8, 0, 0), true},
+		{"dom or dow by dow", "0 0 8 * 5", utc(2021, 9, 11, 12, 0), utc(2021, 9, 1, 0, 0), utc(2021, 9, 10, 0, 0), true},
+		{"before since", "0 0 1 1 *", utc(2021, 6, 1, 0, 0), utc(2021, 3, 1, 0, 0), time.Time{}, false},
+		{"dst skipped clock", "30 2 * * *", time.Date(2021, 3, 14, 4, 0, 0, 0, ny), utc(2021, 3, 12, 0, 0),
+			time.Date(2021, 3, 13, 2, 30, 0, 0, ny), true},
+		{"dst repeated clock", "30 1 * * *", utc(2021, 11, 7, 8, 0).In(ny), utc(2021, 11, 6, 0, 0), utc(2021, 11, 7, 6, 30), true},
+	}
+	for _, c := range cases {
+		cron, err := parseCron(c.spec)
+		if err != nil {
+			t.Fatalf("%s: parse cron %q err, %v", c.name, c.spec, err)
+		}
+		got, ok := cron.prev(c.from, c.since)
+		if ok != c.wantOk || !got.Equal(c.want) {
+			t.Errorf("%s: prev of %q from %s got %s %v, want %s %v", c.name, c.spec, c.from, got, ok, c.want, c.wantOk)
+		}
+	}
+}
+
+func TestScheduleWindow(t *testing.T) {
+	utc := func(year int, month time.Month, day, hour, min int) time.Time {
+		return time.Date(year, month, day, hour, min, 0, 0, time.UTC)
+	}
+	daily := &microservicev1alpha2.Schedule{Start: "09:00", End: "17:00"}
+	overnight := &microservicev1alpha2.Schedule{Start: "22:00", End: "02:00"}
+	cases := []struct {
+		name         string
+		schedule     *microservicev1alpha2.Schedule
+		now          time.Time
+		wantActive   bool
+		wantBoundary time.Time
+		wantErr      bool
+	}{
+		{"daily before", daily, utc(2021, 9, 7, 8, 0), false, utc(2021, 9, 7, 9, 0), false},
+		{"daily in", daily, utc(2021, 9, 7, 9, 0), true, utc(2021, 9, 7, 17, 0), false},
+		{"daily after", daily, utc(2021, 9, 7, 17, 0), false, utc(2021, 9, 8, 9, 0), false},
+		{"overnight before midnight", overnight, utc(2021, 9, 7, 23, 0), true, utc(2021, 9, 8, 2, 0), false},
+		{"overnight after midnight", overnight, utc(2021, 9, 8, 1, 0), true, utc(2021, 9, 8, 2, 0), false},
+		{"overnight end", overnight, utc(2021, 9, 8, 2, 0), false, utc(2021, 9, 8, 22, 0), false},
+		{"overnight day", overnight, utc(2021, 9, 8, 12, 0), false, utc(2021, 9, 8, 22, 0), false},
+		{"overnight year end", overnight, utc(2021, 12, 31, 23, 0), true, utc(2022, 1, 1, 2, 0), false},
+		{"overnight in timezone", &microservicev1alpha2.Schedule{Start: "22:00", End: "02:00", Timezone: "Asia/Shanghai"},
+			utc(2021, 9, 7, 15, 0), true, utc(2021, 9, 7, 18, 0), false},
+		{"absolute in", &microservicev1alpha2.Schedule{Start: "2021-09-07 00:00", End: "2021-09-08 00:00"},
+			utc(2021, 9, 7, 12, 0), true, utc(2021, 9, 8, 0, 0), false},
+		{"absolute expired", &microservicev1alpha2.Schedule{Start: "2021-09-07 00:00", End: "2021-09-08 00:00"},
+			utc(2021, 9, 8, 0, 0), false, time.Time{}, false},
+		{"cron crossing midnight", &microservicev1alpha2.Schedule{Cron: "0 22 * * *", Duration: &microservicev1alpha2.Duration{Seconds: 4 * 3600}},
+			utc(2022, 1, 1, 1, 0), true, utc(2022, 1, 1, 2, 0), false},
+		{"cron inactive", &microservicev1alpha2.Schedule{Cron: "0 22 * * *", Duration: &microservicev1alpha2.Duration{Seconds: 4 * 3600}},
+			utc(2022, 1, 1, 2, 0), false, utc(2022, 1, 1, 22, 0), false},
+		{"cron without duration", &microservicev1alpha2.Schedule{Cron: "0 22 * * *"}, utc(2022, 1, 1, 2, 0), false, time.Time{}, true},
+		{"invalid timezone", &microservicev1alpha2.Schedule{Start: "22:00", End: "02:00", Timezone: "Nowhere/City"},
+			utc(2022, 1, 1, 2, 0), false, time.Time{}, true},
+		{"missing end", &microservicev1alpha2.Schedule{Start: "22:00"}, utc(2022, 1, 1, 2, 0), false, time.Time{}, true},
+	}
+	for _, c := range cases {
+		active, boundary, err := scheduleWindow(c.schedule, c.now)
+		if (err != nil) != c.wantErr {
+			t.Errorf("%s: got err %v, want err %v", c.name, err, c.wantErr)
+			continue
+		}
+		if active != c.wantActive || !boundary.Equal(c.wantBoundary) {
+			t.Errorf("%s: got %v %s, want %v %s", c.name, active, boundary, c.wantActive, c.wantBoundary)
+		}
+	}
+}
+
+func TestScheduleWindowDST(t *testing.T) {
+	ny := mustLoadLocation(t, "America/New_York")
+	s := &microservicev1alpha2.Schedule{Start: "00:00", End: "04:00", Timezone: "America/New_York"}
+	// the window lasts 3 hours on the day clocks spring forward
+	active, boundary, err := scheduleWindow(s, time.Date(2021, 3, 14, 3, 30, 0, 0, ny))
+	if err != nil || !active || !boundary.Equal(time.Date(2021, 3, 14, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("spring forward got %v %s %v, want active until 04:00 EDT", active, boundary, err)
+	}
+	// and 5 hours on the day clocks fall back
+	active, boundary, err = scheduleWindow(s, time.Date(2021, 11, 7, 6, 30, 0, 0, time.UTC))
+	if err != nil || !active || !boundary.Equal(time.Date(2021, 11, 7, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("fall back got %v %s %v, want active until 04:00 EST", active, boundary, err)
+	}
+}
+
+func TestNextScheduleBoundary(t *testing.T) {
+	now := time.Date(2021, 9, 7, 12, 0, 0, 0, time.UTC)
+	spec := microservicev1alpha2.SmartLimiterSpec{
+		Sets: map[string]*microservicev1alpha2.SmartLimitDescriptors{
+			"_base": {Descriptor_: []*microservicev1alpha2.SmartLimitDescriptor{
+				{Schedules: []*microservicev1alpha2.Schedule{{Start: "22:00", End: "02:00"}, nil}},
+				{Schedules: []*microservicev1alpha2.Schedule{{Start: "09:00", End: "17:00"}}},
+			}},
+			"v1": nil,
+		},
+	}
+	if got, want := nextScheduleBoundary(spec, now), time.Date(2021, 9, 7, 17, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if got := nextScheduleBoundary(microservicev1alpha2.SmartLimiterSpec{}, now); !got.IsZero() {
+		t.Errorf("got %s without schedules, want zero", got)
+	}
+}
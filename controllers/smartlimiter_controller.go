@@ -18,7 +18,7 @@ package controllers
 
 import (
 	"context"
-	"os"
+	"fmt"
 	"reflect"
 	"sync"
 	"time"
@@ -26,13 +26,14 @@ import (
 	cmap "github.com/orcaman/concurrent-map"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 	"slime.io/slime/framework/bootstrap"
+	"slime.io/slime/framework/controllers"
 	slime_model "slime.io/slime/framework/model"
 	"slime.io/slime/framework/model/metric"
 	"slime.io/slime/framework/model/trigger"
@@ -58,16 +59,38 @@ type SmartLimiterReconciler struct {
 
 	metricInfoLock sync.RWMutex
 
+	// the reason why limiter runs in degraded mode, it is reported in status
+	degraded string
+	// adaptive is true if the adaptive limits are enabled and the metric source works
+	adaptive     bool
+	degradedLock sync.RWMutex
+
+	// serializes the updates of the rate limit configmap
+	configMapLock sync.Mutex
+
+	// key is the interested namespace/name, value is the *quotaStates
+	quotaStates cmap.ConcurrentMap
+
+	// key is the interested namespace/name, value is the *time.Timer of the next schedule boundary
+	scheduleTimers cmap.ConcurrentMap
+
+	kubeCache *kubeCache
+
 	lastUpdatePolicy     microservicev1alpha2.SmartLimiterSpec
 	lastUpdatePolicyLock *sync.RWMutex
 
 	watcherMetricChan <-chan metric.Metric
 	tickerMetricChan  <-chan metric.Metric
+	// watcherEventChan is the event chan of the watcher producer, DestinationRule events are sent to it
+	watcherEventChan chan trigger.WatcherEvent
+	// watcherThrottle limits the frequency of the watcher events of each service
+	watcherThrottle watcherThrottle
 	// Interest     cmap.ConcurrentMap
 }
 
 // +kubebuilder:rbac:groups=microservice.slime.io,resources=smartlimiters,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=microservice.slime.io,resources=smartlimiters/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=microservice.slime.io,resources=smartlimiters/finalizers,verbs=update
 
 func (r *SmartLimiterReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	_ = context.Background()
@@ -85,19 +108,18 @@ func (r *SmartLimiterReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error
 	}
 	// deleted
 	if instance == nil {
-		log.Infof("metricInfo.Pop, name %s, namespace,%s", req.Name, req.Namespace)
-		r.metricInfo.Pop(req.Namespace + "/" + req.Name)
-		r.interest.Pop(req.Namespace + "/" + req.Name)
-		r.lastUpdatePolicyLock.Lock()
-		r.lastUpdatePolicy = microservicev1alpha2.SmartLimiterSpec{}
-		r.lastUpdatePolicyLock.Unlock()
+		r.forget(req.NamespacedName)
 		// if contain global smart limiter, should delete info in configmap
-		if r.env.Config != nil && r.env.Config.Limiter != nil && !r.env.Config.Limiter.GetDisableGlobalRateLimit() {
-			refreshConfigMap([]*model.Descriptor{}, r, req.NamespacedName)
+		if r.globalRateLimitEnabled() {
+			if err := refreshConfigMap([]*model.Descriptor{}, nil, r, req.NamespacedName); err != nil {
+				log.Errorf("delete global descriptors of smartlimiter %v err, %+v", req.NamespacedName, err)
+			}
 		} else {
 			log.Info("global rate limiter is closed")
 		}
 		return reconcile.Result{}, nil
+	} else if instance.DeletionTimestamp != nil {
+		return reconcile.Result{}, r.finalize(instance)
 	} else {
 		// add or update
 		if !r.env.RevInScope(slime_model.IstioRevFromLabel(instance.Labels)) {
@@ -105,6 +127,10 @@ func (r *SmartLimiterReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error
 				req.NamespacedName, slime_model.IstioRevFromLabel(instance.Labels), r.env.IstioRev())
 			return ctrl.Result{}, nil
 		}
+		if err := r.ensureFinalizer(instance); err != nil {
+			log.Errorf("add finalizer to smartlimiter %v err, %+v", req.NamespacedName, err)
+			return reconcile.Result{}, err
+		}
 		r.lastUpdatePolicyLock.RLock()
 		if reflect.DeepEqual(instance.Spec, r.lastUpdatePolicy) {
 			r.lastUpdatePolicyLock.RUnlock()
@@ -121,53 +147,90 @@ func (r *SmartLimiterReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error
 }
 
 func (r *SmartLimiterReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := mgr.GetFieldIndexer().IndexField(&microservicev1alpha2.SmartLimiter{}, model.IndexLocalRoute, localRouteIndex); err != nil {
+		return err
+	}
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&microservicev1alpha2.SmartLimiter{}).
 		Complete(r)
 }
 
-func NewReconciler(mgr ctrl.Manager, env bootstrap.Environment) *SmartLimiterReconciler {
+func NewReconciler(mgr ctrl.Manager, env bootstrap.Environment, cfg *microservicev1alpha2.Limiter) (*SmartLimiterReconciler, error) {
 	r := &SmartLimiterReconciler{
 		Client:               mgr.GetClient(),
 		scheme:               mgr.GetScheme(),
+		cfg:                  cfg,
 		metricInfo:           cmap.New(),
 		interest:             cmap.New(),
+		quotaStates:          cmap.New(),
+		scheduleTimers:       cmap.New(),
 		env:                  env,
 		lastUpdatePolicyLock: &sync.RWMutex{},
 	}
 
-	pc, err := newProducerConfig(env)
+	kc, err := newKubeCache(mgr.GetCache(), mgr.GetAPIReader(), r.onEndpoints)
 	if err != nil {
-		log.Errorf("new producer config err, %v", err)
-		os.Exit(1)
+		return nil, fmt.Errorf("new kube cache err, %v", err)
+	}
+	r.kubeCache = kc
+
+	adaptive := env.Config != nil && env.Config.Limiter != nil && !env.Config.Limiter.GetDisableAdaptive()
+	pc, sources, err := newProducerConfig(env, cfg, adaptive, r.recordQueryErrors)
+	if err != nil {
+		// start without the adaptive limits instead of exiting, the static limits still work
+		log.Errorf("new producer config err, %v, fall back to non-adaptive mode", err)
+		r.setDegraded(model.DegradedReasonMetricSource, fmt.Sprintf("adaptive ratelimit is disabled, %v", err))
+		if pc, sources, err = newProducerConfig(env, cfg, false, r.recordQueryErrors); err != nil {
+			return nil, fmt.Errorf("new producer config err, %v", err)
+		}
+	} else {
+		r.clearDegraded(model.DegradedReasonMetricSource)
+		r.adaptive = adaptive
 	}
 	r.watcherMetricChan = pc.WatcherProducerConfig.MetricChan
 	r.tickerMetricChan = pc.TickerProducerConfig.MetricChan
+	r.watcherEventChan = pc.WatcherProducerConfig.WatcherTriggerConfig.EventChan
 	pc.WatcherProducerConfig.NeedUpdateMetricHandler = r.handleWatcherEvent
 	pc.TickerProducerConfig.NeedUpdateMetricHandler = r.handleTickerEvent
-	metric.NewProducer(pc)
-	log.Infof("producers starts")
+	// subset changes of DestinationRule trigger the SmartLimiters of the host
+	controllers.HostSubsetMapping.Subscribe(r.subscribe)
 
-	go r.WatchMetric()
-	return r
+	if err := mgr.Add(producersRunnable(mgr.GetCache().WaitForCacheSync, func() {
+		startProducers(pc, sources)
+		log.Infof("producers starts")
+		go r.WatchMetric()
+	})); err != nil {
+		return nil, fmt.Errorf("add producers to manager err, %v", err)
+	}
+	return r, nil
 }
 
-func newProducerConfig(env bootstrap.Environment) (*metric.ProducerConfig, error) {
+// producersRunnable starts the producers and metric sources once the kube cache, which is read by the producers,
+// is synced. it needs leader election, so they only run on the leader if leader election is enabled, the followers
+// only keep the informers of the manager cache warm, and start the producers and sources after taking over
+func producersRunnable(waitForCacheSync func(stop <-chan struct{}) bool, start func()) manager.Runnable {
+	return manager.RunnableFunc(func(stop <-chan struct{}) error {
+		if !waitForCacheSync(stop) {
+			return fmt.Errorf("wait for kube cache sync failed")
+		}
+		start()
+		<-stop
+		return nil
+	})
+}
+
+func newProducerConfig(env bootstrap.Environment, cfg *microservicev1alpha2.Limiter, adaptive bool,
+	onQueryErrors func(meta string, errs map[string]string)) (*metric.ProducerConfig, producerSources, error) {
+	var sources producerSources
 	pc := &metric.ProducerConfig{
-		EnableWatcherProducer: false,
+		EnableWatcherProducer: true,
 		WatcherProducerConfig: metric.WatcherProducerConfig{
 			Name:       "smartLimiter-watcher",
 			MetricChan: make(chan metric.Metric),
+			// the trigger watches nothing, the events of endpoints are sent by the endpoints informer
+			// of the manager cache, see kubeCache.onEndpoints, and the ones of DestinationRule by subscribe
 			WatcherTriggerConfig: trigger.WatcherTriggerConfig{
-				Kinds: []schema.GroupVersionKind{
-					{
-						Group:   "",
-						Version: "v1",
-						Kind:    "Endpoints",
-					},
-				},
-				EventChan:     make(chan trigger.WatcherEvent),
-				DynamicClient: env.DynamicClient,
+				EventChan: make(chan trigger.WatcherEvent, model.WatcherEventBufferSize),
 			},
 		},
 		EnableTickerProducer: true,
@@ -184,20 +247,20 @@ func newProducerConfig(env bootstrap.Environment) (*metric.ProducerConfig, error
 		StopChan: env.Stop,
 	}
 
-	if env.Config != nil && env.Config.Limiter != nil && !env.Config.Limiter.GetDisableAdaptive() {
+	if adaptive {
 		log.Info("enable adaptive ratelimiter")
-		prometheusSourceConfig, err := newPrometheusSourceConfig(env)
-		if err != nil {
-			return nil, err
+		var err error
+		if sources.watcher, err = newMetricSource(env, cfg, onQueryErrors); err != nil {
+			return nil, sources, err
+		}
+		if sources.ticker, err = newMetricSource(env, cfg, onQueryErrors); err != nil {
+			return nil, sources, err
 		}
-		log.Infof("create new prometheus client success")
-		pc.PrometheusSourceConfig = prometheusSourceConfig
-		pc.EnablePrometheusSource = true
-		pc.EnableWatcherProducer = true
 	} else {
 		log.Info("disable adaptive ratelimiter and promql is closed")
 		pc.EnableMockSource = true
+		sources.watcher, sources.ticker = metric.NewMockSource(), metric.NewMockSource()
 	}
 
-	return pc, nil
+	return pc, sources, nil
 }
@@ -0,0 +1,325 @@
+package controllers
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"slime.io/slime/framework/model/metric"
+	microservicev1alpha2 "slime.io/slime/modules/limiter/api/v1alpha2"
+	"slime.io/slime/modules/limiter/model"
+)
+
+// envoyStatsSource scrapes the stats from the prometheus endpoint of sidecars,
+// the values of pods in a subset are summed, like _base.rq_total.
+// the counters are reported as the per-second rate since last query, and the gauges as they are
+type envoyStatsSource struct {
+	client *http.Client
+	port   uint32
+	path   string
+	// timeout is the deadline of scraping all the pods of a SmartLimiter
+	timeout time.Duration
+	// key is the metric name
+	stats map[string]*statSelector
+
+	sync.Mutex
+	// samples of last query, the key is namespace/name of the SmartLimiter, the key of value is the pod
+	last map[string]map[string]*statSample
+}
+
+// statSample is the values of the stats scraped from a pod
+type statSample struct {
+	time   time.Time
+	values map[string]float64
+}
+
+// statSelector selects the series with the name and all the labels
+type statSelector struct {
+	name   string
+	labels []string
+}
+
+func newEnvoyStatsSource(cfg *microservicev1alpha2.MetricSource, timeout time.Duration) *envoyStatsSource {
+	s := &envoyStatsSource{
+		client:  &http.Client{Timeout: timeout},
+		port:    cfg.EnvoyStatsPort,
+		path:    cfg.EnvoyStatsPath,
+		timeout: timeout,
+		stats:   make(map[string]*statSelector, len(cfg.Stats)),
+		last:    make(map[string]map[string]*statSample),
+	}
+	if s.port == 0 {
+		s.port = model.DefaultEnvoyStatsPort
+	}
+	if s.path == "" {
+		s.path = model.DefaultEnvoyStatsPath
+	}
+	for name, stat := range cfg.Stats {
+		s.stats[name] = parseStatSelector(stat)
+	}
+	return s
+}
+
+// parseStatSelector parses the stat like envoy_cluster_upstream_rq_total{cluster_name="inbound|9080||"}
+func parseStatSelector(stat string) *statSelector {
+	stat = strings.TrimSpace(stat)
+	i := strings.Index(stat, "{")
+	if i < 0 {
+		return &statSelector{name: stat}
+	}
+	selector := &statSelector{name: stat[:i]}
+	for _, label := range strings.Split(strings.TrimSuffix(stat[i+1:], "}"), ",") {
+		if label = strings.TrimSpace(label); label != "" {
+			selector.labels = append(selector.labels, label)
+		}
+	}
+	return selector
+}
+
+func (s *statSelector) match(name, labels string) bool {
+	if name != s.name {
+		return false
+	}
+	for _, label := range s.labels {
+		if !strings.Contains(labels, label) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *envoyStatsSource) Start() error {
+	return nil
+}
+
+func (s *envoyStatsSource) QueryMetric(queryMap metric.QueryMap) (metric.Metric, error) {
+	result := make(map[string][]metric.Result)
+	for metaInfo := range queryMap {
+		meta, err := parseStaticMeta(metaInfo)
+		if err != nil {
+			return nil, err
+		}
+		podStats, counters := s.scrapePods(meta)
+		podValues := s.rates(meta.Namespace+"/"+meta.Name, podStats, counters, meta.Pods, time.Now())
+
+		results := make([]metric.Result, 0)
+		for subset, pods := range meta.Pods {
+			for name := range s.stats {
+				var sum float64
+				found := false
+				for _, pod := range pods {
+					if v, ok := podValues[pod][name]; ok {
+						sum += v
+						found = true
+					}
+				}
+				// no pod is scraped or the counter has no rate yet, keep the last value
+				if !found {
+					continue
+				}
+				results = append(results, subsetResult(subset, name, strconv.FormatFloat(sum, 'f', -1, 64)))
+			}
+		}
+		result[metaInfo] = results
+	}
+	return result, nil
+}
+
+// rates returns the values of stats of each pod, the counters are converted to the per-second rate since last query,
+// the first query of a pod has no rate. the pods not in the SmartLimiter any more are forgotten
+func (s *envoyStatsSource) rates(limiter string, podStats map[string]map[string]float64, counters map[string]bool,
+	pods map[string][]string, now time.Time) map[string]map[string]float64 {
+	s.Lock()
+	defer s.Unlock()
+	last := s.last[limiter]
+	current := make(map[string]*statSample, len(podStats))
+	values := make(map[string]map[string]float64, len(podStats))
+	for pod, stats := range podStats {
+		prev := last[pod]
+		v := make(map[string]float64, len(stats))
+		for name, value := range stats {
+			if !counters[name] {
+				v[name] = value
+				continue
+			}
+			if prev == nil {
+				continue
+			}
+			elapsed := now.Sub(prev.time).Seconds()
+			if elapsed <= 0 {
+				continue
+			}
+			d := value - prev.values[name]
+			if d < 0 {
+				// counter is reset, e.g. the sidecar is restarted
+				d = value
+			}
+			v[name] = d / elapsed
+		}
+		current[pod] = &statSample{time: now, values: stats}
+		values[pod] = v
+	}
+	// keep the samples of pods failed to scrape this time
+	for _, subsetPods := range pods {
+		for _, pod := range subsetPods {
+			if _, ok := current[pod]; !ok && last[pod] != nil {
+				current[pod] = last[pod]
+			}
+		}
+	}
+	if len(current) == 0 {
+		delete(s.last, limiter)
+	} else {
+		s.last[limiter] = current
+	}
+	return values
+}
+
+// scrapePods scrapes each pod in meta once, the key is the pod name. at most EnvoyStatsScrapeConcurrency pods
+// are scraped at the same time, and the pods not scraped before the deadline are skipped.
+// the stats which are counters are returned as well
+func (s *envoyStatsSource) scrapePods(meta *StaticMeta) (map[string]map[string]float64, map[string]bool) {
+	ctx := context.Background()
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	var (
+		lock     sync.Mutex
+		wg       sync.WaitGroup
+		podStats = make(map[string]map[string]float64)
+		counters = make(map[string]bool)
+		scraped  = make(map[string]struct{})
+		sem      = make(chan struct{}, model.EnvoyStatsScrapeConcurrency)
+	)
+	for _, pods := range meta.Pods {
+		for _, pod := range pods {
+			if _, ok := scraped[pod]; ok {
+				continue
+			}
+			scraped[pod] = struct{}{}
+			ip := meta.PodIPs[pod]
+			if ip == "" {
+				continue
+			}
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				log.Infof("scrape stats of pod %s/%s err, %+v", meta.Namespace, pod, ctx.Err())
+				continue
+			}
+			wg.Add(1)
+			go func(pod, ip string) {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				stats, isCounter, err := s.scrape(ctx, ip)
+				if err != nil {
+					log.Infof("scrape stats of pod %s/%s err, %+v", meta.Namespace, pod, err)
+					return
+				}
+				lock.Lock()
+				defer lock.Unlock()
+				podStats[pod] = stats
+				for name := range isCounter {
+					counters[name] = true
+				}
+			}(pod, ip)
+		}
+	}
+	wg.Wait()
+	return podStats, counters
+}
+
+// scrape returns the values of the configured stats of the pod, and the stats which are counters
+func (s *envoyStatsSource) scrape(ctx context.Context, ip string) (map[string]float64, map[string]bool, error) {
+	url := fmt.Sprintf("http://%s%s", net.JoinHostPort(ip, strconv.Itoa(int(s.port))), s.path)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := s.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("get %s failed, status code %d", url, resp.StatusCode)
+	}
+	return s.parse(resp.Body)
+}
+
+// parse sums the values of the series selected by each stat in prometheus text format,
+// the stats are counters if the type of the series is counter
+func (s *envoyStatsSource) parse(r io.Reader) (map[string]float64, map[string]bool, error) {
+	values := make(map[string]float64, len(s.stats))
+	counters := make(map[string]bool)
+	types := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			// # TYPE name counter
+			if fields := strings.Fields(line); len(fields) == 4 && fields[1] == "TYPE" {
+				types[fields[2]] = fields[3]
+			}
+			continue
+		}
+		series, value, ok := splitSample(line)
+		if !ok {
+			continue
+		}
+		name, labels := series, ""
+		if i := strings.Index(series, "{"); i >= 0 {
+			name, labels = series[:i], series[i:]
+		}
+		for metricName, selector := range s.stats {
+			if selector.match(name, labels) {
+				values[metricName] += value
+				if types[name] == "counter" {
+					counters[metricName] = true
+				}
+			}
+		}
+	}
+	return values, counters, scanner.Err()
+}
+
+// splitSample splits the sample line like name{labels} value [timestamp]
+func splitSample(line string) (string, float64, bool) {
+	end := strings.LastIndex(line, "}")
+	rest := line
+	series := ""
+	if end >= 0 {
+		series, rest = line[:end+1], line[end+1:]
+	} else {
+		i := strings.IndexAny(line, " \t")
+		if i < 0 {
+			return "", 0, false
+		}
+		series, rest = line[:i], line[i:]
+	}
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return "", 0, false
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return series, value, true
+}
@@ -0,0 +1,172 @@
+package controllers
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	microservicev1alpha2 "slime.io/slime/modules/limiter/api/v1alpha2"
+	"slime.io/slime/modules/limiter/model"
+)
+
+const envoyStatsText = `# TYPE envoy_cluster_upstream_rq_total counter
+envoy_cluster_upstream_rq_total{cluster_name="inbound|9080||"} 100
+envoy_cluster_upstream_rq_total{cluster_name="outbound|9080||ratings"} 7
+# TYPE envoy_cluster_upstream_rq_active gauge
+envoy_cluster_upstream_rq_active{cluster_name="inbound|9080||"} 3
+`
+
+func newTestEnvoyStatsSource(timeout time.Duration) *envoyStatsSource {
+	return newEnvoyStatsSource(&microservicev1alpha2.MetricSource{
+		Stats: map[string]string{
+			"rq_total":  `envoy_cluster_upstream_rq_total{cluster_name="inbound|9080||"}`,
+			"rq_active": `envoy_cluster_upstream_rq_active{cluster_name="inbound|9080||"}`,
+		},
+	}, timeout)
+}
+
+func TestEnvoyStatsParse(t *testing.T) {
+	s := newTestEnvoyStatsSource(0)
+	values, counters, err := s.parse(strings.NewReader(envoyStatsText))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]float64{"rq_total": 100, "rq_active": 3}; !reflect.DeepEqual(values, want) {
+		t.Errorf("got values %v, want %v", values, want)
+	}
+	if want := map[string]bool{"rq_total": true}; !reflect.DeepEqual(counters, want) {
+		t.Errorf("got counters %v, want %v", counters, want)
+	}
+}
+
+func TestEnvoyStatsRates(t *testing.T) {
+	s := newTestEnvoyStatsSource(0)
+	counters := map[string]bool{"rq_total": true}
+	stats := func(total, active float64) map[string]float64 {
+		return map[string]float64{"rq_total": total, "rq_active": active}
+	}
+	pods := map[string][]string{"_base": {"p1", "p2"}}
+	now := time.Now()
+
+	steps := []struct {
+		name     string
+		podStats map[string]map[string]float64
+		pods     map[string][]string
+		elapsed  time.Duration
+		want     map[string]map[string]float64
+	}{
+		// counters have no rate in the first query, gauges are reported as they are
+		{"first query", map[string]map[string]float64{"p1": stats(100, 3), "p2": stats(50, 1)}, pods, 0,
+			map[string]map[string]float64{"p1": {"rq_active": 3}, "p2": {"rq_active": 1}}},
+		{"rate", map[string]map[string]float64{"p1": stats(400, 2), "p2": stats(110, 1)}, pods, 30 * time.Second,
+			map[string]map[string]float64{"p1": {"rq_total": 10, "rq_active": 2}, "p2": {"rq_total": 2, "rq_active": 1}}},
+		{"counter reset", map[string]map[string]float64{"p1": stats(60, 2), "p2": stats(170, 1)}, pods, 30 * time.Second,
+			map[string]map[string]float64{"p1": {"rq_total": 2, "rq_active": 2}, "p2": {"rq_total": 2, "rq_active": 1}}},
+		// p2 fails to scrape, the rate is calculated with its last sample once it recovers
+		{"scrape failed", map[string]map[string]float64{"p1": stats(120, 2)}, pods, 30 * time.Second,
+			map[string]map[string]float64{"p1": {"rq_total": 2, "rq_active": 2}}},
+		{"scrape recovered", map[string]map[string]float64{"p1": stats(180, 2), "p2": stats(290, 1)}, pods, 30 * time.Second,
+			map[string]map[string]float64{"p1": {"rq_total": 2, "rq_active": 2}, "p2": {"rq_total": 2, "rq_active": 1}}},
+		{"pod removed", map[string]map[string]float64{"p1": stats(240, 2)}, map[string][]string{"_base": {"p1"}}, 30 * time.Second,
+			map[string]map[string]float64{"p1": {"rq_total": 2, "rq_active": 2}}},
+		{"pod added back", map[string]map[string]float64{"p1": stats(300, 2), "p2": stats(0, 0)}, pods, 30 * time.Second,
+			map[string]map[string]float64{"p1": {"rq_total": 2, "rq_active": 2}, "p2": {"rq_active": 0}}},
+	}
+	for _, step := range steps {
+		now = now.Add(step.elapsed)
+		if got := s.rates("default/a", step.podStats, counters, step.pods, now); !reflect.DeepEqual(got, step.want) {
+			t.Errorf("%s: got %v, want %v", step.name, got, step.want)
+		}
+	}
+
+	s.rates("default/a", nil, counters, nil, now)
+	if _, ok := s.last["default/a"]; ok {
+		t.Errorf("samples of default/a are not forgotten")
+	}
+}
+
+// statsServer serves the envoy stats, and the port is used as the stats port of pods
+func statsServer(t *testing.T, handler http.HandlerFunc) (*httptest.Server, uint32) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return server, uint32(p)
+}
+
+func localPods(n int) *StaticMeta {
+	meta := &StaticMeta{Namespace: "default", Name: "reviews", Pods: map[string][]string{}, PodIPs: map[string]string{}}
+	for i := 0; i < n; i++ {
+		pod := fmt.Sprintf("pod-%d", i)
+		meta.Pods["_base"] = append(meta.Pods["_base"], pod)
+		meta.PodIPs[pod] = "127.0.0.1"
+	}
+	return meta
+}
+
+func TestEnvoyStatsScrapePodsConcurrency(t *testing.T) {
+	var inflight, peak int32
+	server, port := statsServer(t, func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inflight, 1)
+		defer atomic.AddInt32(&inflight, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		fmt.Fprint(w, envoyStatsText)
+	})
+	defer server.Close()
+
+	s := newTestEnvoyStatsSource(5 * time.Second)
+	s.port = port
+	podStats, counters := s.scrapePods(localPods(3 * model.EnvoyStatsScrapeConcurrency))
+	if len(podStats) != 3*model.EnvoyStatsScrapeConcurrency {
+		t.Errorf("got stats of %d pods, want %d", len(podStats), 3*model.EnvoyStatsScrapeConcurrency)
+	}
+	if !counters["rq_total"] {
+		t.Errorf("rq_total should be a counter")
+	}
+	if p := atomic.LoadInt32(&peak); p > model.EnvoyStatsScrapeConcurrency {
+		t.Errorf("got %d concurrent scrapes, want at most %d", p, model.EnvoyStatsScrapeConcurrency)
+	}
+}
+
+func TestEnvoyStatsScrapePodsDeadline(t *testing.T) {
+	done := make(chan struct{})
+	server, port := statsServer(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	})
+	defer server.Close()
+	defer close(done)
+
+	timeout := 100 * time.Millisecond
+	s := newTestEnvoyStatsSource(timeout)
+	s.port = port
+	start := time.Now()
+	podStats, _ := s.scrapePods(localPods(3 * model.EnvoyStatsScrapeConcurrency))
+	if elapsed := time.Since(start); elapsed > 10*timeout {
+		t.Errorf("scrape takes %v, the deadline is %v", elapsed, timeout)
+	}
+	if len(podStats) != 0 {
+		t.Errorf("got stats of %d pods after deadline", len(podStats))
+	}
+}
@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/client-go/kubernetes"
+	"slime.io/slime/framework/model/metric"
+)
+
+// kubeMetricsSource queries the cpu and memory usage of pods from metrics.k8s.io,
+// the metrics of a subset are like _base.cpu.sum, _base.cpu.max, _base.memory.sum and _base.memory.max
+type kubeMetricsSource struct {
+	client  *kubernetes.Clientset
+	timeout time.Duration
+}
+
+// podMetricsList is the subset of metrics.k8s.io/v1beta1 PodMetricsList used here
+type podMetricsList struct {
+	Items []struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Containers []struct {
+			Name  string            `json:"name"`
+			Usage map[string]string `json:"usage"`
+		} `json:"containers"`
+	} `json:"items"`
+}
+
+type podUsage struct {
+	cpu    int64
+	memory int64
+}
+
+func newKubeMetricsSource(client *kubernetes.Clientset, timeout time.Duration) *kubeMetricsSource {
+	return &kubeMetricsSource{client: client, timeout: timeout}
+}
+
+func (s *kubeMetricsSource) Start() error {
+	return nil
+}
+
+func (s *kubeMetricsSource) QueryMetric(queryMap metric.QueryMap) (metric.Metric, error) {
+	result := make(map[string][]metric.Result)
+	// the SmartLimiters in the same namespace share one list of pod metrics in a query
+	namespaceUsages := make(map[string]map[string]podUsage)
+	for metaInfo := range queryMap {
+		meta, err := parseStaticMeta(metaInfo)
+		if err != nil {
+			return nil, err
+		}
+		usages, ok := namespaceUsages[meta.Namespace]
+		if !ok {
+			if usages, err = s.queryPodUsages(meta.Namespace); err != nil {
+				return nil, err
+			}
+			namespaceUsages[meta.Namespace] = usages
+		}
+		results := make([]metric.Result, 0)
+		for subset, pods := range meta.Pods {
+			var cpuSum, cpuMax, memSum, memMax int64
+			for _, pod := range pods {
+				usage, ok := usages[pod]
+				if !ok {
+					continue
+				}
+				cpuSum += usage.cpu
+				memSum += usage.memory
+				if usage.cpu > cpuMax {
+					cpuMax = usage.cpu
+				}
+				if usage.memory > memMax {
+					memMax = usage.memory
+				}
+			}
+			results = append(results,
+				subsetResult(subset, "cpu.sum", strconv.FormatInt(cpuSum, 10)),
+				subsetResult(subset, "cpu.max", strconv.FormatInt(cpuMax, 10)),
+				subsetResult(subset, "memory.sum", strconv.FormatInt(memSum, 10)),
+				subsetResult(subset, "memory.max", strconv.FormatInt(memMax, 10)),
+			)
+		}
+		result[metaInfo] = results
+	}
+	return result, nil
+}
+
+// queryPodUsages returns the usage of pods in namespace, cpu is in millicores and memory is in bytes
+func (s *kubeMetricsSource) queryPodUsages(namespace string) (map[string]podUsage, error) {
+	raw, err := s.client.Discovery().RESTClient().Get().
+		AbsPath("/apis/metrics.k8s.io/v1beta1/namespaces", namespace, "pods").
+		Timeout(s.timeout).
+		Do().Raw()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metric from metrics.k8s.io, error: %+v", err)
+	}
+	list := &podMetricsList{}
+	if err = json.Unmarshal(raw, list); err != nil {
+		return nil, fmt.Errorf("unmarshal pod metrics err, %+v", err)
+	}
+
+	usages := make(map[string]podUsage, len(list.Items))
+	for _, item := range list.Items {
+		usage := podUsage{}
+		for _, c := range item.Containers {
+			if q, err := resource.ParseQuantity(c.Usage["cpu"]); err == nil {
+				usage.cpu += q.MilliValue()
+			}
+			if q, err := resource.ParseQuantity(c.Usage["memory"]); err == nil {
+				usage.memory += q.Value()
+			}
+		}
+		usages[item.Metadata.Name] = usage
+	}
+	return usages, nil
+}
@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"slime.io/slime/framework/model/metric"
+)
+
+const podMetricsStubResponse = `{"items":[
+{"metadata":{"name":"r1"},"containers":[{"name":"app","usage":{"cpu":"100m","memory":"1Ki"}},{"name":"istio-proxy","usage":{"cpu":"50m","memory":"1Ki"}}]},
+{"metadata":{"name":"r2"},"containers":[{"name":"app","usage":{"cpu":"200m","memory":"4Ki"}}]}]}`
+
+func TestKubeMetricsSourceListsOncePerNamespace(t *testing.T) {
+	lists := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lists[r.URL.Path]++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(podMetricsStubResponse))
+	}))
+	defer server.Close()
+	client, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newKubeMetricsSource(client, time.Second)
+
+	pods := map[string][]string{"_base": {"r1", "r2"}}
+	queryMap := metric.QueryMap{
+		StaticMeta{Namespace: "default", Name: "reviews", Pods: pods}.String():        nil,
+		StaticMeta{Namespace: "default", Name: "reviews-canary", Pods: pods}.String(): nil,
+		StaticMeta{Namespace: "other", Name: "reviews", Pods: pods}.String():          nil,
+	}
+	m, err := s.QueryMetric(queryMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{
+		"/apis/metrics.k8s.io/v1beta1/namespaces/default/pods": 1,
+		"/apis/metrics.k8s.io/v1beta1/namespaces/other/pods":   1,
+	}
+	if !reflect.DeepEqual(lists, want) {
+		t.Errorf("got lists %v, want %v", lists, want)
+	}
+	for meta := range queryMap {
+		values := make(map[string]string)
+		for _, result := range m[meta] {
+			for k, v := range result.Value {
+				values[k] = v
+			}
+		}
+		if values["_base.cpu.sum"] != "350" || values["_base.cpu.max"] != "200" || values["_base.memory.sum"] != "6144" {
+			t.Errorf("%s: got %v", meta, values)
+		}
+	}
+}
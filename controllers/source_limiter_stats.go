@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"slime.io/slime/framework/model/metric"
+	microservicev1alpha2 "slime.io/slime/modules/limiter/api/v1alpha2"
+	"slime.io/slime/modules/limiter/model"
+)
+
+// limiterStatsSource reads back the stats of rate limit filters generated by limiter, the counters of pods
+// in a subset are summed, and reported as the increments since last query:
+// rate_limited and ok of envoy.filters.http.local_ratelimit, over_limit and ratelimit_ok of envoy.filters.http.ratelimit,
+// and limited_ratio which is the percent of limited requests
+type limiterStatsSource struct {
+	stats *envoyStatsSource
+
+	sync.Mutex
+	// counters of last query, the key is namespace/name of the SmartLimiter, the key of value is the pod
+	last map[string]map[string]map[string]float64
+}
+
+func newLimiterStatsSource(cfg *microservicev1alpha2.MetricSource, timeout time.Duration) *limiterStatsSource {
+	statsCfg := &microservicev1alpha2.MetricSource{
+		EnvoyStatsPort: cfg.EnvoyStatsPort,
+		EnvoyStatsPath: cfg.EnvoyStatsPath,
+		Stats: map[string]string{
+			model.LimiterStatRateLimited: model.EnvoyStatLocalRateLimited,
+			model.LimiterStatOk:          model.EnvoyStatLocalOk,
+			model.LimiterStatOverLimit:   model.EnvoyStatGlobalOverLimit,
+			model.LimiterStatGlobalOk:    model.EnvoyStatGlobalOk,
+		},
+	}
+	return &limiterStatsSource{
+		stats: newEnvoyStatsSource(statsCfg, timeout),
+		last:  make(map[string]map[string]map[string]float64),
+	}
+}
+
+func (s *limiterStatsSource) Start() error {
+	return nil
+}
+
+func (s *limiterStatsSource) QueryMetric(queryMap metric.QueryMap) (metric.Metric, error) {
+	result := make(map[string][]metric.Result)
+	for metaInfo := range queryMap {
+		meta, err := parseStaticMeta(metaInfo)
+		if err != nil {
+			return nil, err
+		}
+		podStats, _ := s.stats.scrapePods(meta)
+		podDeltas := s.deltas(meta.Namespace+"/"+meta.Name, podStats, meta.Pods)
+
+		results := make([]metric.Result, 0)
+		for subset, pods := range meta.Pods {
+			sum := make(map[string]float64)
+			for _, pod := range pods {
+				for name, v := range podDeltas[pod] {
+					sum[name] += v
+				}
+			}
+			for name := range s.stats.stats {
+				results = append(results, subsetResult(subset, name, strconv.FormatFloat(sum[name], 'f', -1, 64)))
+			}
+			results = append(results, subsetResult(subset, model.LimiterStatLimitedRatio, strconv.Itoa(limitedRatio(sum))))
+		}
+		result[metaInfo] = results
+	}
+	return result, nil
+}
+
+// deltas returns the increments of counters of the SmartLimiter since last query, the first query of a pod
+// returns zero. the pods not in the SmartLimiter any more are forgotten
+func (s *limiterStatsSource) deltas(limiter string, podStats map[string]map[string]float64, pods map[string][]string) map[string]map[string]float64 {
+	s.Lock()
+	defer s.Unlock()
+	last := s.last[limiter]
+	current := make(map[string]map[string]float64, len(podStats))
+	deltas := make(map[string]map[string]float64, len(podStats))
+	for pod, stats := range podStats {
+		prev, ok := last[pod]
+		delta := make(map[string]float64, len(stats))
+		if ok {
+			for name, v := range stats {
+				d := v - prev[name]
+				if d < 0 {
+					// counter is reset, e.g. the sidecar is restarted
+					d = v
+				}
+				delta[name] = d
+			}
+		}
+		current[pod] = stats
+		deltas[pod] = delta
+	}
+	// keep the counters of pods failed to scrape this time
+	for _, subsetPods := range pods {
+		for _, pod := range subsetPods {
+			if _, ok := current[pod]; !ok && last[pod] != nil {
+				current[pod] = last[pod]
+			}
+		}
+	}
+	if len(current) == 0 {
+		delete(s.last, limiter)
+	} else {
+		s.last[limiter] = current
+	}
+	return deltas
+}
+
+// limitedRatio returns the percent of requests limited by local or global rate limit,
+// requests passing local rate limit are counted by global rate limit again
+func limitedRatio(sum map[string]float64) int {
+	limited := sum[model.LimiterStatRateLimited] + sum[model.LimiterStatOverLimit]
+	local := sum[model.LimiterStatRateLimited] + sum[model.LimiterStatOk]
+	global := sum[model.LimiterStatRateLimited] + sum[model.LimiterStatOverLimit] + sum[model.LimiterStatGlobalOk]
+	total := local
+	if global > total {
+		total = global
+	}
+	if total <= 0 {
+		return 0
+	}
+	return int(limited * 100 / total)
+}
@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"testing"
+
+	microservicev1alpha2 "slime.io/slime/modules/limiter/api/v1alpha2"
+	"slime.io/slime/modules/limiter/model"
+)
+
+func TestLimiterStatsDeltas(t *testing.T) {
+	s := newLimiterStatsSource(&microservicev1alpha2.MetricSource{}, 0)
+	stats := func(ok float64) map[string]float64 {
+		return map[string]float64{model.LimiterStatOk: ok}
+	}
+	pods := map[string][]string{"_base": {"p1", "p2"}}
+
+	steps := []struct {
+		name     string
+		limiter  string
+		podStats map[string]map[string]float64
+		pods     map[string][]string
+		want     map[string]float64
+	}{
+		{"first query", "default/a", map[string]map[string]float64{"p1": stats(10), "p2": stats(5)}, pods,
+			map[string]float64{"p1": 0, "p2": 0}},
+		{"increments", "default/a", map[string]map[string]float64{"p1": stats(15), "p2": stats(8)}, pods,
+			map[string]float64{"p1": 5, "p2": 3}},
+		// another SmartLimiter of the same pods does not share the counters
+		{"other limiter", "default/b", map[string]map[string]float64{"p1": stats(20)}, pods,
+			map[string]float64{"p1": 0}},
+		{"counter reset", "default/a", map[string]map[string]float64{"p1": stats(4), "p2": stats(8)}, pods,
+			map[string]float64{"p1": 4, "p2": 0}},
+		// p2 fails to scrape, its counters are kept
+		{"scrape failed", "default/a", map[string]map[string]float64{"p1": stats(6)}, pods,
+			map[string]float64{"p1": 2}},
+		{"scrape recovered", "default/a", map[string]map[string]float64{"p1": stats(6), "p2": stats(9)}, pods,
+			map[string]float64{"p1": 0, "p2": 1}},
+		// p2 is removed and then added back with the same name
+		{"pod removed", "default/a", map[string]map[string]float64{"p1": stats(7)}, map[string][]string{"_base": {"p1"}},
+			map[string]float64{"p1": 1}},
+		{"pod added back", "default/a", map[string]map[string]float64{"p1": stats(7), "p2": stats(100)}, pods,
+			map[string]float64{"p1": 0, "p2": 0}},
+	}
+	for _, step := range steps {
+		deltas := s.deltas(step.limiter, step.podStats, step.pods)
+		if len(deltas) != len(step.want) {
+			t.Errorf("%s: got deltas %v, want %v", step.name, deltas, step.want)
+			continue
+		}
+		for pod, want := range step.want {
+			if got := deltas[pod][model.LimiterStatOk]; got != want {
+				t.Errorf("%s: got delta %v of %s, want %v", step.name, got, pod, want)
+			}
+		}
+	}
+	if _, ok := s.last["default/a"]["p2"]; !ok {
+		t.Errorf("counters of p2 are not recorded")
+	}
+
+	s.deltas("default/a", nil, nil)
+	if _, ok := s.last["default/a"]; ok {
+		t.Errorf("counters of default/a are kept without pods")
+	}
+}
+
+func TestLimitedRatio(t *testing.T) {
+	cases := []struct {
+		sum  map[string]float64
+		want int
+	}{
+		{map[string]float64{}, 0},
+		{map[string]float64{model.LimiterStatRateLimited: 25, model.LimiterStatOk: 75}, 25},
+		{map[string]float64{model.LimiterStatOverLimit: 10, model.LimiterStatGlobalOk: 90}, 10},
+		{map[string]float64{model.LimiterStatRateLimited: 20, model.LimiterStatOk: 80,
+			model.LimiterStatOverLimit: 20, model.LimiterStatGlobalOk: 60}, 40},
+	}
+	for _, c := range cases {
+		if got := limitedRatio(c.sum); got != c.want {
+			t.Errorf("limited ratio of %v: got %d, want %d", c.sum, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"gopkg.in/yaml.v2"
+	"slime.io/slime/framework/model/metric"
+)
+
+// staticSource reads the metrics from a yaml or json file on each query, it is used for testing.
+// the key of the file is namespace/name of the SmartLimiter, or * for all
+type staticSource struct {
+	file string
+}
+
+func newStaticSource(file string) *staticSource {
+	return &staticSource{file: file}
+}
+
+func (s *staticSource) Start() error {
+	return nil
+}
+
+func (s *staticSource) QueryMetric(queryMap metric.QueryMap) (metric.Metric, error) {
+	b, err := ioutil.ReadFile(s.file)
+	if err != nil {
+		return nil, fmt.Errorf("read static metric file %s err, %+v", s.file, err)
+	}
+	metrics := make(map[string]map[string]string)
+	if err = yaml.Unmarshal(b, &metrics); err != nil {
+		return nil, fmt.Errorf("unmarshal static metric file %s err, %+v", s.file, err)
+	}
+
+	result := make(map[string][]metric.Result)
+	for metaInfo := range queryMap {
+		meta, err := parseStaticMeta(metaInfo)
+		if err != nil {
+			return nil, err
+		}
+		values := make(map[string]string)
+		for k, v := range metrics["*"] {
+			values[k] = v
+		}
+		for k, v := range metrics[meta.Namespace+"/"+meta.Name] {
+			values[k] = v
+		}
+		results := make([]metric.Result, 0, len(values))
+		for k, v := range values {
+			results = append(results, metric.Result{
+				Name:  k,
+				Value: map[string]string{k: v},
+			})
+		}
+		result[metaInfo] = results
+	}
+	return result, nil
+}
@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"regexp"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	microservicev1alpha2 "slime.io/slime/modules/limiter/api/v1alpha2"
+	"slime.io/slime/modules/limiter/model"
+)
+
+// templateKeyRegex extracts the metric name from the template like {{._base.cpu.max}}
+var templateKeyRegex = regexp.MustCompile(`\{\{\s*\.([^\s{}]+)\s*\}\}`)
+
+// stalenessChecker checks whether the metrics referenced by descriptors are stale
+type stalenessChecker struct {
+	ttl        time.Duration
+	now        time.Time
+	metricTime map[string]time.Time
+	stale      map[string]string
+}
+
+func (r *SmartLimiterReconciler) newStalenessChecker(loc types.NamespacedName, ttl *microservicev1alpha2.Duration) *stalenessChecker {
+	c := &stalenessChecker{
+		now:        time.Now(),
+		metricTime: make(map[string]time.Time),
+		stale:      make(map[string]string),
+	}
+	if ttl != nil {
+		c.ttl = time.Duration(ttl.Seconds)*time.Second + time.Duration(ttl.Nanos)
+	}
+	qs := r.getQuotaStates(loc)
+	qs.Lock()
+	defer qs.Unlock()
+	for k, v := range qs.metricTime {
+		c.metricTime[k] = v
+	}
+	return c
+}
+
+// isStale returns true if any metric referenced by condition, quota or feedback is not updated within ttl
+func (c *stalenessChecker) isStale(descriptor *microservicev1alpha2.SmartLimitDescriptor) bool {
+	if c.ttl <= 0 {
+		return false
+	}
+	for _, metric := range referencedMetrics(descriptor) {
+		t, ok := c.metricTime[metric]
+		if !ok || c.now.Sub(t) > c.ttl {
+			return true
+		}
+	}
+	return false
+}
+
+// sampleTime returns the update time of the metric, zero if it is never updated
+func (c *stalenessChecker) sampleTime(metric string) time.Time {
+	return c.metricTime[metric]
+}
+
+// latestSampleTime returns the latest update time of the metrics referenced by the descriptor
+func (c *stalenessChecker) latestSampleTime(descriptor *microservicev1alpha2.SmartLimitDescriptor) time.Time {
+	var latest time.Time
+	for _, metric := range referencedMetrics(descriptor) {
+		if t := c.metricTime[metric]; t.After(latest) {
+			latest = t
+		}
+	}
+	return latest
+}
+
+func referencedMetrics(descriptor *microservicev1alpha2.SmartLimitDescriptor) []string {
+	metrics := make([]string, 0)
+	templates := []string{descriptor.Condition}
+	if descriptor.Action != nil {
+		templates = append(templates, descriptor.Action.Quota)
+	}
+	for _, s := range descriptor.Schedules {
+		if s != nil {
+			templates = append(templates, s.Quota)
+		}
+	}
+	for _, t := range templates {
+		for _, match := range templateKeyRegex.FindAllStringSubmatch(t, -1) {
+			metrics = append(metrics, match[1])
+		}
+	}
+	if descriptor.Feedback != nil && descriptor.Feedback.Metric != "" {
+		metrics = append(metrics, descriptor.Feedback.Metric)
+	}
+	return metrics
+}
+
+func fallbackPolicy(descriptor *microservicev1alpha2.SmartLimitDescriptor) string {
+	if descriptor.Fallback == nil || descriptor.Fallback.Policy == "" {
+		return model.FallbackKeep
+	}
+	return descriptor.Fallback.Policy
+}
+
+// recordMetricTime records the update time of the metrics
+func (r *SmartLimiterReconciler) recordMetricTime(loc types.NamespacedName, metrics map[string]string) {
+	qs := r.getQuotaStates(loc)
+	qs.Lock()
+	defer qs.Unlock()
+	now := time.Now()
+	for k := range metrics {
+		qs.metricTime[k] = now
+	}
+}
+
+func (r *SmartLimiterReconciler) metricUpdateTime(loc types.NamespacedName) map[string]int64 {
+	qs := r.getQuotaStates(loc)
+	qs.Lock()
+	defer qs.Unlock()
+	if len(qs.metricTime) == 0 {
+		return nil
+	}
+	times := make(map[string]int64, len(qs.metricTime))
+	for k, v := range qs.metricTime {
+		times[k] = v.Unix()
+	}
+	return times
+}
+
+func (r *SmartLimiterReconciler) setStaleDescriptors(loc types.NamespacedName, stale map[string]string) {
+	qs := r.getQuotaStates(loc)
+	qs.Lock()
+	defer qs.Unlock()
+	qs.stale = stale
+}
+
+func (r *SmartLimiterReconciler) staleDescriptors(loc types.NamespacedName) map[string]string {
+	qs := r.getQuotaStates(loc)
+	qs.Lock()
+	defer qs.Unlock()
+	if len(qs.stale) == 0 {
+		return nil
+	}
+	stale := make(map[string]string, len(qs.stale))
+	for k, v := range qs.stale {
+		stale[k] = v
+	}
+	return stale
+}
@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	cmap "github.com/orcaman/concurrent-map"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"slime.io/slime/framework/model/metric"
+	microservicev1alpha2 "slime.io/slime/modules/limiter/api/v1alpha2"
+	"slime.io/slime/modules/limiter/model"
+)
+
+// failedSource fails every query
+type failedSource struct{}
+
+func (failedSource) Start() error { return nil }
+
+func (failedSource) QueryMetric(metric.QueryMap) (metric.Metric, error) {
+	return nil, fmt.Errorf("source is unavailable")
+}
+
+// newRefreshReconciler returns the reconciler which refreshes the SmartLimiters of service reviews in default
+func newRefreshReconciler(t *testing.T, objs ...runtime.Object) *SmartLimiterReconciler {
+	t.Helper()
+	r := newFakeReconciler(t, objs...)
+	r.metricInfo, r.interest, r.quotaStates, r.scheduleTimers = cmap.New(), cmap.New(), cmap.New(), cmap.New()
+	kc, _ := newTestKubeCache()
+	kc.services = serviceLister(t, policyService("reviews", nil, map[string]string{"app": "reviews"}))
+	r.kubeCache = kc
+	return r
+}
+
+func TestCompositeSourceAllFailed(t *testing.T) {
+	s := &compositeSource{sources: []metric.Source{failedSource{}, failedSource{}}}
+	meta := StaticMeta{Namespace: "default", Name: "reviews", NPod: map[string]int{"_base.pod": 2}}.String()
+	m, err := s.QueryMetric(metric.QueryMap{meta: {{Name: "cpu.max", Query: "max(cpu)"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results, ok := m[meta]; !ok || len(results) != 0 {
+		t.Errorf("got %v, want the meta without results", m)
+	}
+}
+
+func TestStaleMetricsFallback(t *testing.T) {
+	loc := types.NamespacedName{Namespace: "default", Name: "reviews"}
+	descriptor := func(name, policy string) *microservicev1alpha2.SmartLimitDescriptor {
+		d := namedDescriptor(name, "{{._base.cpu.max}}")
+		d.Condition = "true"
+		d.Action.FillInterval = &microservicev1alpha2.Duration{Seconds: 1}
+		d.Fallback = &microservicev1alpha2.Fallback{Policy: policy, Quota: 50}
+		return d
+	}
+	instance := &microservicev1alpha2.SmartLimiter{
+		ObjectMeta: metav1.ObjectMeta{Namespace: loc.Namespace, Name: loc.Name},
+		Spec: microservicev1alpha2.SmartLimiterSpec{
+			MetricTtl: &microservicev1alpha2.Duration{Seconds: 60},
+			Sets: map[string]*microservicev1alpha2.SmartLimitDescriptors{"_base": {Descriptor_: []*microservicev1alpha2.SmartLimitDescriptor{
+				descriptor("fixed", model.FallbackFixed),
+				descriptor("disable", model.FallbackDisable),
+				descriptor("keep", model.FallbackKeep),
+			}}},
+		},
+	}
+	r := newRefreshReconciler(t, instance)
+	if _, err := r.Refresh(reconcile.Request{NamespacedName: loc}, map[string]string{"_base.cpu.max": "100"}); err != nil {
+		t.Fatal(err)
+	}
+	// the metric is updated before the ttl
+	qs := r.getQuotaStates(loc)
+	qs.Lock()
+	qs.metricTime["_base.cpu.max"] = time.Now().Add(-time.Hour)
+	qs.Unlock()
+
+	// all the sources fail, the SmartLimiter is still refreshed with the pods
+	s := &compositeSource{sources: []metric.Source{failedSource{}}}
+	meta := StaticMeta{Namespace: loc.Namespace, Name: loc.Name, NPod: map[string]int{"_base.pod": 2}}.String()
+	m, err := s.QueryMetric(metric.QueryMap{meta: {{Name: "cpu.max", Query: "max(cpu)"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.ConsumeMetric(m)
+
+	found := &microservicev1alpha2.SmartLimiter{}
+	if err := r.Client.Get(context.TODO(), loc, found); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"_base/fixed": model.FallbackFixed, "_base/disable": model.FallbackDisable, "_base/keep": model.FallbackKeep}
+	if !reflect.DeepEqual(found.Status.StaleDescriptors, want) {
+		t.Errorf("got stale descriptors %v, want %v", found.Status.StaleDescriptors, want)
+	}
+	quotas := make(map[string]string)
+	for _, d := range found.Status.RatelimitStatus["_base"].Descriptor_ {
+		quotas[d.Name] = d.Action.Quota
+	}
+	if want := map[string]string{"fixed": "50", "keep": "100"}; !reflect.DeepEqual(quotas, want) {
+		t.Errorf("got quotas %v, want %v", quotas, want)
+	}
+}
@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"slime.io/slime/framework/util"
+	microservicev1alpha2 "slime.io/slime/modules/limiter/api/v1alpha2"
+	"slime.io/slime/modules/limiter/model"
+)
+
+// limitTarget is the workloads limited by a SmartLimiter, they are either the pods of a service,
+// or the pods selected by labels
+type limitTarget struct {
+	namespace string
+	// name of the target service, empty if the workloads are selected by labels
+	service string
+	// labels of the workloads, only used if service is empty
+	labels map[string]string
+}
+
+// resolveTarget returns the target of the SmartLimiter, workload_selector takes precedence over target_ref,
+// and the service with the same name as the SmartLimiter is the default
+func resolveTarget(spec microservicev1alpha2.SmartLimiterSpec, loc types.NamespacedName) (limitTarget, error) {
+	if spec.WorkloadSelector != nil && len(spec.WorkloadSelector.Labels) > 0 {
+		return limitTarget{namespace: loc.Namespace, labels: spec.WorkloadSelector.Labels}, nil
+	}
+	if ref := spec.TargetRef; ref != nil && ref.Name != "" {
+		if ref.Kind != "" && ref.Kind != model.TargetKindService {
+			return limitTarget{}, fmt.Errorf("unsupported kind %s of target_ref in %s", ref.Kind, loc)
+		}
+		return limitTarget{namespace: loc.Namespace, service: ref.Name}, nil
+	}
+	return limitTarget{namespace: loc.Namespace, service: loc.Name}, nil
+}
+
+// host returns the host of target service which is used to find the subsets, empty if there is no service
+func (t limitTarget) host() string {
+	if t.service == "" {
+		return ""
+	}
+	return util.UnityHost(t.service, t.namespace)
+}
+
+// services returns the target service, or the services whose selector is contained in the labels
+func (t limitTarget) services(kc *kubeCache) ([]*v1.Service, error) {
+	if t.service != "" {
+		svc, err := kc.services.Services(t.namespace).Get(t.service)
+		if err != nil {
+			return nil, err
+		}
+		return []*v1.Service{svc}, nil
+	}
+	all, err := kc.services.Services(t.namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	services := make([]*v1.Service, 0)
+	for _, svc := range all {
+		if t.selects(svc) {
+			services = append(services, svc)
+		}
+	}
+	return services, nil
+}
+
+// selects tells whether the pods of svc are limited by the target
+func (t limitTarget) selects(svc *v1.Service) bool {
+	if svc.Namespace != t.namespace {
+		return false
+	}
+	if t.service != "" {
+		return svc.Name == t.service
+	}
+	return len(svc.Spec.Selector) > 0 && util.IsContain(t.labels, svc.Spec.Selector)
+}
+
+// valueScope scopes the generated descriptor values, they are prefixed by the target service, and the
+// SmartLimiter is hashed into them, so the values of the SmartLimiters of the same service do not collide
+type valueScope struct {
+	service types.NamespacedName
+	limiter types.NamespacedName
+}
+
+// valueScope returns the value scope of the SmartLimiter loc, the SmartLimiter is used as the service
+// if the workloads are selected by labels
+func (t limitTarget) valueScope(loc types.NamespacedName) valueScope {
+	scope := valueScope{service: loc, limiter: loc}
+	if t.service != "" {
+		scope.service = types.NamespacedName{Namespace: t.namespace, Name: t.service}
+	}
+	return scope
+}
+
+// limiterValueScope returns the value scope of the SmartLimiter, the SmartLimiter is used as the service
+// if the target is invalid
+func limiterValueScope(instance *microservicev1alpha2.SmartLimiter) valueScope {
+	loc := types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}
+	target, err := resolveTarget(instance.Spec, loc)
+	if err != nil {
+		return valueScope{service: loc, limiter: loc}
+	}
+	return target.valueScope(loc)
+}
+
+// limitersOfService returns the SmartLimiters whose target contains the pods of the service
+func (r *SmartLimiterReconciler) limitersOfService(nn types.NamespacedName) []*microservicev1alpha2.SmartLimiter {
+	svc, err := r.kubeCache.services.Services(nn.Namespace).Get(nn.Name)
+	if err != nil {
+		svc = &v1.Service{}
+		svc.Name, svc.Namespace = nn.Name, nn.Namespace
+	}
+	list := &microservicev1alpha2.SmartLimiterList{}
+	if err := r.Client.List(context.TODO(), list, client.InNamespace(nn.Namespace)); err != nil {
+		log.Errorf("list smartlimiters in %s err, %+v", nn.Namespace, err)
+		return nil
+	}
+	limiters := make([]*microservicev1alpha2.SmartLimiter, 0)
+	for i := range list.Items {
+		instance := &list.Items[i]
+		target, err := resolveTarget(instance.Spec, types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name})
+		if err != nil {
+			continue
+		}
+		if target.selects(svc) {
+			limiters = append(limiters, instance)
+		}
+	}
+	return limiters
+}
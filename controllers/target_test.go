@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	microservicev1alpha2 "slime.io/slime/modules/limiter/api/v1alpha2"
+	"slime.io/slime/modules/limiter/model"
+)
+
+// targetLimiter returns the SmartLimiter in default, targeting the service by ref or the pods by selector
+func targetLimiter(name string, ref *microservicev1alpha2.TargetRef, selector map[string]string) *microservicev1alpha2.SmartLimiter {
+	instance := &microservicev1alpha2.SmartLimiter{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+		Spec:       microservicev1alpha2.SmartLimiterSpec{TargetRef: ref},
+	}
+	if selector != nil {
+		instance.Spec.WorkloadSelector = &microservicev1alpha2.WorkloadSelector{Labels: selector}
+	}
+	return instance
+}
+
+func TestResolveTarget(t *testing.T) {
+	loc := types.NamespacedName{Namespace: "default", Name: "reviews"}
+	selector := map[string]string{"app": "reviews"}
+	cases := []struct {
+		name    string
+		spec    microservicev1alpha2.SmartLimiterSpec
+		want    limitTarget
+		wantErr bool
+	}{
+		{"default service", microservicev1alpha2.SmartLimiterSpec{}, limitTarget{namespace: "default", service: "reviews"}, false},
+		{"target_ref", targetLimiter("reviews", &microservicev1alpha2.TargetRef{Name: "ratings"}, nil).Spec,
+			limitTarget{namespace: "default", service: "ratings"}, false},
+		{"target_ref of service kind", targetLimiter("reviews", &microservicev1alpha2.TargetRef{Kind: model.TargetKindService, Name: "ratings"}, nil).Spec,
+			limitTarget{namespace: "default", service: "ratings"}, false},
+		{"target_ref without name", targetLimiter("reviews", &microservicev1alpha2.TargetRef{}, nil).Spec,
+			limitTarget{namespace: "default", service: "reviews"}, false},
+		{"unsupported kind", targetLimiter("reviews", &microservicev1alpha2.TargetRef{Kind: "Deployment", Name: "reviews-v1"}, nil).Spec,
+			limitTarget{}, true},
+		{"workload_selector takes precedence", targetLimiter("reviews", &microservicev1alpha2.TargetRef{Name: "ratings"}, selector).Spec,
+			limitTarget{namespace: "default", labels: selector}, false},
+		{"empty workload_selector", targetLimiter("reviews", nil, map[string]string{}).Spec,
+			limitTarget{namespace: "default", service: "reviews"}, false},
+	}
+	for _, c := range cases {
+		got, err := resolveTarget(c.spec, loc)
+		if (err != nil) != c.wantErr {
+			t.Errorf("%s: got err %v, want err %v", c.name, err, c.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got %+v, want %+v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestLimitersOfService(t *testing.T) {
+	reviews := map[string]string{"app": "reviews"}
+	canary := map[string]string{"app": "reviews", "version": "canary"}
+	other := targetLimiter("reviews", nil, nil)
+	other.Namespace = "other"
+	r := newFakeReconciler(t,
+		// the default SmartLimiter of the service
+		targetLimiter("reviews", nil, nil),
+		// several SmartLimiters of the same service
+		targetLimiter("reviews-canary", &microservicev1alpha2.TargetRef{Name: "reviews"}, nil),
+		targetLimiter("reviews-burst", &microservicev1alpha2.TargetRef{Kind: model.TargetKindService, Name: "reviews"}, nil),
+		// the labels contain the selector of the service
+		targetLimiter("canary-pods", nil, canary),
+		// the labels do not contain the selector of the service
+		targetLimiter("version-pods", nil, map[string]string{"version": "canary"}),
+		// targeting another service, though named after the service
+		targetLimiter("reviews-ratings", &microservicev1alpha2.TargetRef{Name: "ratings"}, nil),
+		targetLimiter("reviews-deployment", &microservicev1alpha2.TargetRef{Kind: "Deployment", Name: "reviews"}, nil),
+		other,
+	)
+	kc, _ := newTestKubeCache()
+	kc.services = serviceLister(t, policyService("reviews", nil, reviews), policyService("ratings", nil, map[string]string{"app": "ratings"}))
+	r.kubeCache = kc
+
+	names := func(nn types.NamespacedName) []string {
+		ret := make([]string, 0)
+		for _, instance := range r.limitersOfService(nn) {
+			ret = append(ret, instance.Name)
+		}
+		sort.Strings(ret)
+		return ret
+	}
+	cases := []struct {
+		service types.NamespacedName
+		want    []string
+	}{
+		{types.NamespacedName{Namespace: "default", Name: "reviews"}, []string{"canary-pods", "reviews", "reviews-burst", "reviews-canary"}},
+		{types.NamespacedName{Namespace: "default", Name: "ratings"}, []string{"reviews-ratings"}},
+		// the selector of a deleted service is unknown, only the ones targeting it by name are found
+		{types.NamespacedName{Namespace: "default", Name: "deleted"}, []string{}},
+		{types.NamespacedName{Namespace: "other", Name: "reviews"}, []string{"reviews"}},
+	}
+	for _, c := range cases {
+		if got := names(c.service); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got %v, want %v", c.service, got, c.want)
+		}
+	}
+}
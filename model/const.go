@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 const (
 	ConfigMapName = "slime-rate-limit-config"
 
@@ -47,4 +49,180 @@ const (
 	InlineMetricPod = "pod"
 
 	InboundDefaultRoute = "default"
+
+	HeaderPath = ":path"
+
+	HeaderMethod = ":method"
+
+	// QueryStringRegex matches the optional query string of :path
+	QueryStringRegex = `(\?.*)?`
+
+	EnvoyFiltersNetworkTcpProxy = "envoy.filters.network.tcp_proxy"
+
+	EnvoyFiltersNetworkLocalRateLimit = "envoy.filters.network.local_ratelimit"
+
+	EnvoyFiltersNetworkRateLimit = "envoy.filters.network.ratelimit"
+
+	TypeUrlEnvoyNetworkLocalRateLimit = "type.googleapis.com/envoy.extensions.filters.network.local_ratelimit.v3.LocalRateLimit"
+
+	TypeUrlEnvoyNetworkRateLimit = "type.googleapis.com/envoy.extensions.filters.network.ratelimit.v3.RateLimit"
+
+	EnvoyTcpLocalRateLimiterStatPrefix = "tcp_local_rate_limiter"
+
+	EnvoyTcpRateLimiterStatPrefix = "tcp_rate_limiter"
+
+	FeedbackAIMD = "aimd"
+
+	FeedbackPID = "pid"
+
+	DefaultFeedbackIncrease = 1
+
+	DefaultFeedbackDecrease = 0.5
+
+	FallbackKeep = "keep"
+
+	FallbackFixed = "fixed"
+
+	FallbackDisable = "disable"
+
+	DefaultMetricSourceTimeout = 5 * time.Second
+
+	// daily window of schedule, like 09:00
+	ScheduleClockLayout = "15:04"
+
+	// absolute window of schedule, like 2022-11-11 00:00
+	ScheduleTimeLayout = "2006-01-02 15:04"
+
+	// refresh a little later than the schedule boundary
+	ScheduleBoundaryDelay = time.Second
+
+	// refresh the SmartLimiter which the local limits are merged into a little later, out of the current refresh
+	MergeRefreshDelay = time.Second
+
+	// the watcher events of a service, e.g. the endpoints changes, are handled at most once in the interval
+	WatcherEventMinInterval = 3 * time.Second
+
+	// the watcher events sent before the producers start, or faster than they are handled, are buffered,
+	// the ones beyond the buffer are dropped, the limiters are still refreshed by the ticker
+	WatcherEventBufferSize = 1024
+
+	// the field index of SmartLimiters by the routes of local descriptors
+	IndexLocalRoute = "spec.localRoutes"
+
+	// the supported kind of target_ref
+	TargetKindService = "Service"
+
+	DefaultMeshPolicyNamespace = "istio-system"
+
+	PolicyResource = "smartlimiterpolicies"
+
+	// the SmartLimiter generated by SmartLimiterPolicy is named as service name with the suffix and the hash of service name
+	PolicyLimiterSuffix = "-policy"
+
+	// the label of the SmartLimiters generated by SmartLimiterPolicy
+	PolicyLabel = "microservice.slime.io/generated-by"
+
+	PolicyLabelValue = "smartlimiterpolicy"
+
+	// the annotation records namespace/name of the SmartLimiterPolicy
+	PolicyAnnotation = "microservice.slime.io/policy"
+
+	// the label of all the resources generated by limiter
+	LabelManagedBy = "app.kubernetes.io/managed-by"
+
+	LabelManagedByValue = "slime-limiter"
+
+	// the label records the name of the SmartLimiter which generates the resource
+	LabelSmartLimiter = "microservice.slime.io/smartlimiter"
+
+	// the label records the set of the SmartLimiter which generates the EnvoyFilter
+	LabelLimiterSet = "microservice.slime.io/set"
+
+	// the annotation records namespace/name of the SmartLimiter which generates the resource
+	AnnotationSmartLimiter = "microservice.slime.io/smartlimiter"
+
+	// the annotation records the generation of the SmartLimiter which generates the resource
+	AnnotationGeneration = "microservice.slime.io/generation"
+
+	// the annotation of the rate limit configmap, it records the owners of global descriptors in json,
+	// the config of ratelimit service does not allow unknown fields in descriptors
+	AnnotationDescriptorOwners = "microservice.slime.io/descriptor-owners"
+
+	// the finalizer of SmartLimiter, it is removed after the global descriptors are deleted from the configmap
+	LimiterFinalizer = "microservice.slime.io/limiter"
+
+	// the reason of degraded mode if the metric source is misconfigured, it is the label of the degraded metric
+	DegradedReasonMetricSource = "metric_source"
+
+	// the prefix of the values of the descriptors generated by limiter
+	DescriptorValuePrefix = "Service["
+
+	DefaultEnvoyStatsPort = 15090
+
+	DefaultEnvoyStatsPath = "/stats/prometheus"
+
+	// the max number of pods whose stats are scraped at the same time
+	EnvoyStatsScrapeConcurrency = 16
+
+	// stats of envoy.filters.http.local_ratelimit with stat_prefix http_local_rate_limiter
+	EnvoyStatLocalRateLimited = "envoy_http_local_rate_limiter_http_local_rate_limit_rate_limited"
+
+	EnvoyStatLocalOk = "envoy_http_local_rate_limiter_http_local_rate_limit_ok"
+
+	// stats of envoy.filters.http.ratelimit, they are recorded on the upstream cluster
+	EnvoyStatGlobalOverLimit = "envoy_cluster_ratelimit_over_limit"
+
+	EnvoyStatGlobalOk = "envoy_cluster_ratelimit_ok"
+
+	LimiterStatRateLimited = "rate_limited"
+
+	LimiterStatOk = "ok"
+
+	LimiterStatOverLimit = "over_limit"
+
+	LimiterStatGlobalOk = "ratelimit_ok"
+
+	LimiterStatLimitedRatio = "limited_ratio"
+)
+
+// built-in variables in templates, the ones of subset are prefixed with the subset name, like _base.ready_pod
+const (
+	VarReadyPod = "ready_pod"
+
+	VarTotalPod = "total_pod"
+
+	VarHpaMin = "hpa_min"
+
+	VarHpaMax = "hpa_max"
+
+	VarHpaDesired = "hpa_desired"
+
+	// millicores
+	VarCpuRequest = "cpu_request"
+
+	VarCpuLimit = "cpu_limit"
+
+	VarNodeCount = "node_count"
+
+	VarTimeHour = "time.hour"
+
+	VarTimeMinute = "time.minute"
+
+	// 0 is Sunday
+	VarTimeWeekday = "time.weekday"
+)
+
+// the prefix of service port name, the port is served by tcp_proxy in sidecar
+const (
+	ProtocolTCP = "tcp"
+
+	ProtocolTLS = "tls"
+
+	ProtocolHTTPS = "https"
+
+	ProtocolMongo = "mongo"
+
+	ProtocolMySQL = "mysql"
+
+	ProtocolRedis = "redis"
 )
@@ -15,9 +15,17 @@ type RateLimitConfig struct {
 }
 
 type Descriptor struct {
-	Key       string     `yaml:"key,omitempty"`
-	Value     string     `yaml:"value,omitempty"`
-	RateLimit *RateLimit `yaml:"rate_limit,omitempty"`
+	Key         string        `yaml:"key,omitempty"`
+	Value       string        `yaml:"value,omitempty"`
+	RateLimit   *RateLimit    `yaml:"rate_limit,omitempty"`
+	Descriptors []*Descriptor `yaml:"descriptors,omitempty"`
+}
+
+// DescriptorOwner is the SmartLimiter generating a global descriptor, key of the owners is the descriptor value
+type DescriptorOwner struct {
+	SmartLimiter string `json:"smartlimiter"`
+	Set          string `json:"set"`
+	Generation   int64  `json:"generation"`
 }
 
 type RateLimit struct {
@@ -1,8 +1,6 @@
 package module
 
 import (
-	"os"
-
 	"slime.io/slime/framework/model/module"
 	"slime.io/slime/modules/limiter/model"
 
@@ -49,10 +47,28 @@ func (m *Module) Clone() module.Module {
 }
 
 func (m *Module) InitManager(mgr manager.Manager, env bootstrap.Environment, cbs module.InitCallbacks) error {
-	reconciler := controllers.NewReconciler(mgr, env)
+	reconciler, err := controllers.NewReconciler(mgr, env, &m.config)
+	if err != nil {
+		log.Errorf("unable to create reconciler of SmartLimiter, %+v", err)
+		return err
+	}
 	if err := reconciler.SetupWithManager(mgr); err != nil {
 		log.Errorf("unable to create controller SmartLimiter, %+v", err)
-		os.Exit(1)
+		return err
+	}
+
+	if m.config.GetDisable() {
+		if err := mgr.Add(manager.RunnableFunc(reconciler.Teardown)); err != nil {
+			log.Errorf("unable to add teardown of limiter, %+v", err)
+			return err
+		}
+	} else if controllers.PolicyEnabled(env) {
+		if err := controllers.NewPolicyReconciler(mgr, env, &m.config).SetupWithManager(mgr); err != nil {
+			log.Errorf("unable to create controller SmartLimiterPolicy, %+v", err)
+			return err
+		}
+	} else {
+		log.Infof("crd of SmartLimiterPolicy is not installed, skip the controller")
 	}
 
 	// add dr reconcile
@@ -62,7 +78,7 @@ func (m *Module) InitManager(mgr manager.Manager, env bootstrap.Environment, cbs
 		Env:    &env,
 	}).SetupWithManager(mgr); err != nil {
 		log.Errorf("unable to create controller DestinationRule, %+v", err)
-		os.Exit(1)
+		return err
 	}
 
 	log.Infof("init manager successful")